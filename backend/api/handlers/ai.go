@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/llm"
+)
+
+// systemPrompt 流式生成使用的系统提示词，由服务端固定，客户端不能替换
+const systemPrompt = "你是一个专业的文本编辑助手，请根据用户的要求对文本进行处理。直接返回处理后的结果，不要添加额外的解释或格式。"
+
+// AIHandler AI生成相关处理器
+type AIHandler struct {
+	provider  llm.Provider
+	maxTokens int
+	limiter   *streamLimiter
+}
+
+// NewAIHandler 创建新的AI处理器，maxTokens 为单次生成的 token 上限（llm.max_tokens），
+// maxConcurrentPerUser 为每个用户同时进行的流式生成上限
+func NewAIHandler(provider llm.Provider, maxTokens, maxConcurrentPerUser int) *AIHandler {
+	return &AIHandler{
+		provider:  provider,
+		maxTokens: maxTokens,
+		limiter:   newStreamLimiter(maxConcurrentPerUser),
+	}
+}
+
+// AIStreamRequest 流式生成请求，MaxTokens 不能超过服务端配置的上限
+type AIStreamRequest struct {
+	Prompt      string  `json:"prompt"`
+	Text        string  `json:"text"`
+	Temperature float64 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+}
+
+// Stream 以 Server-Sent Events 推送大模型的流式生成结果。
+// 事件依次为若干 delta，最后以 done（含token用量）或 error 结束；客户端断开时中止上游生成。
+func (h *AIHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.GetSubject(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var requestData AIStreamRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestData.Prompt == "" && requestData.Text == "" {
+		http.Error(w, "Missing prompt", http.StatusBadRequest)
+		return
+	}
+
+	// 流式生成可能超过服务端写超时，取消本请求的写截止时间
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	if !h.limiter.acquire(userID) {
+		http.Error(w, "Too many concurrent generations", http.StatusTooManyRequests)
+		return
+	}
+	defer h.limiter.release(userID)
+
+	maxTokens := requestData.MaxTokens
+	if maxTokens <= 0 || maxTokens > h.maxTokens {
+		maxTokens = h.maxTokens
+	}
+	chatReq := llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: systemPrompt},
+			{Role: llm.RoleUser, Content: requestData.Prompt + "\n\n" + requestData.Text},
+		},
+		Temperature: requestData.Temperature,
+		MaxTokens:   maxTokens,
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	resp, err := h.provider.ChatStream(r.Context(), chatReq, func(delta string) error {
+		if err := writeSSE(w, "delta", map[string]string{"content": delta}); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		if r.Context().Err() != nil {
+			// 客户端已断开，无需再写入
+			return
+		}
+		slog.ErrorContext(r.Context(), "AI stream failed", "user", userID, "error", err)
+		message := "Generation failed"
+		if errors.Is(err, llm.ErrNotConfigured) {
+			message = "AI service is not configured"
+		}
+		writeSSE(w, "error", map[string]string{"message": message})
+		flusher.Flush()
+		return
+	}
+
+	writeSSE(w, "done", map[string]interface{}{
+		"model":   resp.Model,
+		"content": resp.Content,
+		"usage":   resp.Usage,
+	})
+	flusher.Flush()
+}
+
+// writeSSE 写入一条 Server-Sent Event
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+	return err
+}
+
+// streamLimiter 限制每个用户同时进行的流式生成数量
+type streamLimiter struct {
+	max    int
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func newStreamLimiter(max int) *streamLimiter {
+	if max <= 0 {
+		max = 1
+	}
+	return &streamLimiter{max: max, active: make(map[string]int)}
+}
+
+func (l *streamLimiter) acquire(userID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active[userID] >= l.max {
+		return false
+	}
+	l.active[userID]++
+	return true
+}
+
+// busy 判断用户是否有正在进行的流式生成
+func (l *streamLimiter) busy(userID string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active[userID] > 0
+}
+
+func (l *streamLimiter) release(userID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.active[userID]--
+	if l.active[userID] <= 0 {
+		delete(l.active, userID)
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/profile"
+)
+
+// AuthHandler 与登录平台无关的会话处理器：当前用户、刷新令牌与退出登录
+type AuthHandler struct {
+	sessions *auth.SessionManager
+	profiles *profile.Service
+}
+
+// NewAuthHandler 创建会话处理器
+func NewAuthHandler(sessions *auth.SessionManager, profiles *profile.Service) *AuthHandler {
+	return &AuthHandler{sessions: sessions, profiles: profiles}
+}
+
+// User 返回当前登录用户的资料，需经过 AuthMiddleware
+func (h *AuthHandler) User(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.GetPrincipal(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	me, err := h.profiles.Get(r.Context(), principal)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get user profile", "error", err)
+		http.Error(w, "Failed to get user info", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(me); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode user profile", "error", err)
+	}
+}
+
+// Refresh 用刷新令牌换取新的访问令牌，旧刷新令牌随即失效
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var requestData models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestData.RefreshToken == "" {
+		http.Error(w, "Missing refresh token", http.StatusBadRequest)
+		return
+	}
+
+	pair, err := h.sessions.Refresh(r.Context(), requestData.RefreshToken)
+	if errors.Is(err, auth.ErrInvalidRefreshToken) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to refresh token", "error", err)
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(newTokenResponse(pair)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode refresh response", "error", err)
+	}
+}
+
+// Logout 撤销当前会话，需经过 AuthMiddleware
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := auth.GetSessionID(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.sessions.Logout(r.Context(), sessionID); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to logout", "error", err)
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAll 撤销当前用户在所有设备上的会话，需经过 AuthMiddleware
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	subject, ok := auth.GetSubject(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	revoked, err := h.sessions.LogoutAll(r.Context(), subject)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to logout all sessions", "error", err)
+		http.Error(w, "Failed to logout", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Logged out from all devices",
+		"revoked": revoked,
+	})
+}
+
+// consumeState 校验并作废 OAuth state，失败时返回 400 并返回 false
+func consumeState(w http.ResponseWriter, r *http.Request, states *auth.StateManager, state, platform string) bool {
+	err := states.Consume(r.Context(), state, platform)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, auth.ErrStateExpired):
+		http.Error(w, "OAuth state expired, please login again", http.StatusBadRequest)
+	case errors.Is(err, auth.ErrInvalidState):
+		http.Error(w, "Invalid OAuth state", http.StatusBadRequest)
+	default:
+		slog.ErrorContext(r.Context(), "Failed to validate oauth state", "error", err)
+		http.Error(w, "Failed to validate state", http.StatusInternalServerError)
+	}
+	return false
+}
+
+// newTokenResponse 将签发的令牌转换为接口响应
+func newTokenResponse(pair *auth.TokenPair) models.TokenResponse {
+	return models.TokenResponse{
+		Token:            pair.AccessToken,
+		ExpiresAt:        pair.AccessExpiresAt,
+		RefreshToken:     pair.RefreshToken,
+		RefreshExpiresAt: pair.RefreshExpiresAt,
+	}
+}
+
+// newAuthResponse 登录成功后的响应：令牌与用户信息
+func newAuthResponse(pair *auth.TokenPair, user *models.User) models.AuthResponse {
+	return models.AuthResponse{
+		TokenResponse: newTokenResponse(pair),
+		User:          *user,
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/api/middleware"
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/profile"
+)
+
+func newTestSessions() *auth.SessionManager {
+	return auth.NewSessionManager(storage.NewMemoryStore(), &models.AuthConfig{
+		JWTSecret:       "test-jwt-secret",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+}
+
+var testPrincipal = auth.NewPrincipal(auth.PlatformDingTalk, &models.User{UserID: "user-1", Name: "张三", CorpID: "corp-1"})
+
+func postWithToken(h http.Handler, path, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestRefreshEndpoint(t *testing.T) {
+	sessions := newTestSessions()
+	h := NewAuthHandler(sessions, profile.NewService(nil, time.Minute))
+	pair, _ := sessions.Login(context.Background(), testPrincipal)
+
+	w := postWithToken(http.HandlerFunc(h.Refresh), "/api/auth/refresh", "", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp models.TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response failed: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == pair.RefreshToken {
+		t.Fatalf("unexpected refresh response: %+v", resp)
+	}
+
+	// 宽限期内的重复刷新返回同一令牌对
+	w = postWithToken(http.HandlerFunc(h.Refresh), "/api/auth/refresh", "", `{"refresh_token":"`+pair.RefreshToken+`"}`)
+	var repeated models.TokenResponse
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &repeated) != nil || repeated.RefreshToken != resp.RefreshToken {
+		t.Fatalf("expected repeat refresh to return the same pair, got %d: %s", w.Code, w.Body.String())
+	}
+	w = postWithToken(http.HandlerFunc(h.Refresh), "/api/auth/refresh", "", `{"refresh_token":"unknown.secret"}`)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 on unknown refresh token, got %d", w.Code)
+	}
+	w = postWithToken(http.HandlerFunc(h.Refresh), "/api/auth/refresh", "", `{}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 on missing refresh token, got %d", w.Code)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	sessions := newTestSessions()
+	h := NewAuthHandler(sessions, profile.NewService(nil, time.Minute))
+	protect := middleware.AuthMiddleware(sessions)
+	ok := protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	laptop, _ := sessions.Login(context.Background(), testPrincipal)
+	phone, _ := sessions.Login(context.Background(), testPrincipal)
+
+	if w := postWithToken(protect(http.HandlerFunc(h.Logout)), "/api/auth/logout", laptop.AccessToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("logout failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := postWithToken(ok, "/api/graphql", laptop.AccessToken, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked access token to be rejected, got %d", w.Code)
+	}
+	if w := postWithToken(ok, "/api/graphql", phone.AccessToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("other device should stay logged in, got %d", w.Code)
+	}
+
+	if w := postWithToken(protect(http.HandlerFunc(h.LogoutAll)), "/api/auth/logout-all", phone.AccessToken, ""); w.Code != http.StatusOK {
+		t.Fatalf("logout-all failed: %d %s", w.Code, w.Body.String())
+	}
+	if w := postWithToken(ok, "/api/graphql", phone.AccessToken, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected all sessions revoked, got %d", w.Code)
+	}
+}
+
+func TestUserEndpointReturnsProfile(t *testing.T) {
+	sessions := newTestSessions()
+	h := NewAuthHandler(sessions, profile.NewService(nil, time.Minute))
+	pair, _ := sessions.Login(context.Background(), testPrincipal)
+
+	r := httptest.NewRequest("GET", "/api/auth/user", nil)
+	r.Header.Set("Authorization", "Bearer "+pair.AccessToken)
+	w := httptest.NewRecorder()
+	middleware.AuthMiddleware(sessions)(http.HandlerFunc(h.User)).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var me profile.Profile
+	if err := json.Unmarshal(w.Body.Bytes(), &me); err != nil {
+		t.Fatalf("decode profile failed: %v", err)
+	}
+	if me.UserID != "user-1" || me.Name != "张三" || me.Platform != auth.PlatformDingTalk || me.CorpID != "corp-1" {
+		t.Fatalf("unexpected profile: %+v", me)
+	}
+}
@@ -3,9 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 
-	"github.com/hellodeveye/report/internal/config"
 	"github.com/hellodeveye/report/internal/models"
 	"github.com/hellodeveye/report/pkg/auth"
 	"github.com/hellodeveye/report/pkg/dingtalk"
@@ -13,18 +13,20 @@ import (
 
 // DingTalkHandler 钉钉相关处理器
 type DingTalkHandler struct {
+	sessions      *auth.SessionManager
+	states        *auth.StateManager
 	authService   *dingtalk.AuthService
 	reportService *dingtalk.ReportService
 }
 
 // NewDingTalkHandler 创建新的钉钉处理器
-func NewDingTalkHandler() *DingTalkHandler {
-	dingTalkConfig := config.GetDingTalkConfig()
-	authService := dingtalk.NewAuthService(dingTalkConfig)
-	dingtalkClient := dingtalk.NewClient(dingTalkConfig)
+func NewDingTalkHandler(dingtalkClient *dingtalk.Client, sessions *auth.SessionManager, states *auth.StateManager) *DingTalkHandler {
+	authService := dingtalk.NewAuthService(dingtalkClient)
 	reportService := dingtalk.NewReportService(dingtalkClient)
 
 	return &DingTalkHandler{
+		sessions:      sessions,
+		states:        states,
 		authService:   authService,
 		reportService: reportService,
 	}
@@ -32,15 +34,14 @@ func NewDingTalkHandler() *DingTalkHandler {
 
 // Login 钉钉登录处理 - 返回授权URL给前端
 func (h *DingTalkHandler) Login(w http.ResponseWriter, r *http.Request) {
-	authURL, state, err := h.authService.GenerateAuthURL()
+	// 签发一次性 state，回调换取 token 时校验
+	state, err := h.states.Issue(r.Context(), auth.PlatformDingTalk)
 	if err != nil {
-		fmt.Printf("Failed to generate DingTalk auth URL: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to issue DingTalk oauth state", "error", err)
 		http.Error(w, "Failed to generate login URL", http.StatusInternalServerError)
 		return
 	}
-
-	fmt.Printf("Generated DingTalk authURL: %s\n", authURL)
-	fmt.Printf("State: %s\n", state)
+	authURL := h.authService.GenerateAuthURL(state)
 
 	// 返回授权URL和state给前端
 	response := map[string]string{
@@ -50,7 +51,7 @@ func (h *DingTalkHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		fmt.Printf("Failed to encode DingTalk login response: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to encode DingTalk login response", "error", err)
 		http.Error(w, "Failed to generate login URL", http.StatusInternalServerError)
 		return
 	}
@@ -59,7 +60,7 @@ func (h *DingTalkHandler) Login(w http.ResponseWriter, r *http.Request) {
 // ExchangeCode 处理前端发送的授权码，返回JWT token
 func (h *DingTalkHandler) ExchangeCode(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		fmt.Printf("Invalid method: %s, expected POST\n", r.Method)
+		slog.WarnContext(r.Context(), "Invalid method, expected POST", "method", r.Method)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -67,58 +68,46 @@ func (h *DingTalkHandler) ExchangeCode(w http.ResponseWriter, r *http.Request) {
 	var requestData models.AuthRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
-		fmt.Printf("Failed to decode request body: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to decode request body", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("Received DingTalk auth request - Code: %s, State: %s\n", requestData.Code, requestData.State)
-
 	if requestData.Code == "" {
-		fmt.Printf("Missing authorization code\n")
 		http.Error(w, "Missing authorization code", http.StatusBadRequest)
 		return
 	}
 
+	// 校验 state，防止登录 CSRF
+	if !consumeState(w, r, h.states, requestData.State, auth.PlatformDingTalk) {
+		return
+	}
+
 	// 用授权码换取用户信息
-	user, err := h.authService.ExchangeCodeForUser(requestData.Code)
+	user, err := h.authService.WithContext(r.Context()).ExchangeCodeForUser(requestData.Code)
 	if err != nil {
-		fmt.Printf("Failed to exchange code for user: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to exchange code for user", "error", err)
 		http.Error(w, fmt.Sprintf("Authentication failed: %v", err), http.StatusUnauthorized)
 		return
 	}
 
-	// 生成JWT token
-	token, expiresAt, err := auth.GenerateToken(user.UserID, user.Name)
+	// 创建登录会话并签发令牌
+	pair, err := h.sessions.Login(r.Context(), auth.NewPrincipal(auth.PlatformDingTalk, user))
 	if err != nil {
-		fmt.Printf("Failed to generate JWT token: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to generate JWT token", "error", err)
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
 
-	// 返回JWT token和用户信息
-	authResponse := models.AuthResponse{
-		Token:     token,
-		ExpiresAt: expiresAt,
-		User:      *user,
-	}
+	// 返回令牌和用户信息
+	authResponse := newAuthResponse(pair, user)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(authResponse); err != nil {
-		fmt.Printf("Failed to encode auth response: %v\n", err)
+		slog.ErrorContext(r.Context(), "Failed to encode auth response", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 
-	fmt.Printf("DingTalk authentication successful for user: %s\n", user.Name)
-}
-
-func (h *DingTalkHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// 由于JWT是无状态的，退出登录主要在前端处理（删除本地存储的token）
-	// 这里可以添加token黑名单逻辑，或者其他服务端清理逻辑
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Logged out successfully",
-	})
+	slog.InfoContext(r.Context(), "DingTalk authentication successful", "user", user.Name)
 }
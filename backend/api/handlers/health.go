@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTimeout 单次就绪检查的超时时间
+const readinessTimeout = 5 * time.Second
+
+// HealthCheck 就绪检查项，返回 nil 表示依赖可用
+type HealthCheck func(ctx context.Context) error
+
+// HealthHandler 存活与就绪探针
+type HealthHandler struct {
+	checks   map[string]HealthCheck
+	draining atomic.Bool
+}
+
+// NewHealthHandler 创建探针处理器，checks 按依赖名称索引
+func NewHealthHandler(checks map[string]HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks}
+}
+
+// Drain 标记服务正在关闭，之后的就绪检查一律返回 503，负载均衡据此停止转发新请求
+func (h *HealthHandler) Drain() {
+	h.draining.Store(true)
+}
+
+// Healthz 存活探针，进程能处理请求即返回 200
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Readyz 就绪探针，并发执行全部检查项，任一失败或服务正在关闭时返回 503。
+// 探针无需登录，响应中每项只返回 ok 或 failed，错误详情只写入日志
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.draining.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]string, len(h.checks))
+	ready := true
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := "ok"
+			if err := runCheck(ctx, check); err != nil {
+				slog.WarnContext(r.Context(), "Readiness check failed", "check", name, "error", err)
+				result = "failed"
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			if result != "ok" {
+				ready = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := "ok"
+	if !ready {
+		status = "unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": results,
+	})
+}
+
+// runCheck 执行检查项，不支持 context 的检查在超时后直接返回错误
+func runCheck(ctx context.Context, check HealthCheck) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
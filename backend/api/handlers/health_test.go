@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+)
+
+type readiness struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+func getReadyz(h *HealthHandler) (int, readiness) {
+	rec := httptest.NewRecorder()
+	h.Readyz(rec, httptest.NewRequest("GET", "/readyz", nil))
+	var body readiness
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body
+}
+
+func dingtalkTokenCheck(client *dingtalk.Client) HealthCheck {
+	return func(ctx context.Context) error {
+		_, err := client.WithContext(ctx).GetAccessToken()
+		return err
+	}
+}
+
+func TestReadyzChecksDependencies(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+	badConfig := server.Config()
+	badConfig.AppSecret = "wrong-secret"
+
+	tests := []struct {
+		name       string
+		checks     map[string]HealthCheck
+		wantStatus int
+		failed     string
+	}{
+		{
+			name: "all dependencies ready",
+			checks: map[string]HealthCheck{
+				"storage":  storage.NewMemoryStore().Ping,
+				"dingtalk": dingtalkTokenCheck(dingtalk.NewClient(server.Config())),
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "dingtalk token unavailable",
+			checks: map[string]HealthCheck{
+				"storage":  storage.NewMemoryStore().Ping,
+				"dingtalk": dingtalkTokenCheck(dingtalk.NewClient(badConfig)),
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			failed:     "dingtalk",
+		},
+		{
+			name: "storage unavailable",
+			checks: map[string]HealthCheck{
+				"storage": func(ctx context.Context) error { return errors.New("database is closed") },
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			failed:     "storage",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := getReadyz(NewHealthHandler(tt.checks))
+			if code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d: %+v", tt.wantStatus, code, body)
+			}
+			for name := range tt.checks {
+				want := "ok"
+				if name == tt.failed {
+					want = "failed"
+				}
+				// 失败原因只写入日志，不暴露平台错误信息
+				if body.Checks[name] != want {
+					t.Fatalf("unexpected result for %s: %+v", name, body.Checks)
+				}
+			}
+		})
+	}
+}
+
+func TestReadyzReturnsWhileTokenRefreshIsSlow(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"errcode":0,"access_token":"token","expires_in":7200}`)
+	}))
+	defer server.Close()
+	defer close(release)
+	client := dingtalk.NewClient(&models.DingTalkConfig{BaseURL: server.URL})
+	// 其他请求正在刷新 access_token
+	go client.GetAccessToken()
+	time.Sleep(20 * time.Millisecond)
+
+	h := NewHealthHandler(map[string]HealthCheck{"dingtalk": dingtalkTokenCheck(client)})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.Readyz(rec, httptest.NewRequest("GET", "/readyz", nil).WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable || time.Since(start) > time.Second {
+		t.Fatalf("expected prompt 503, got %d after %v", rec.Code, time.Since(start))
+	}
+}
+
+func TestReadyzReportsDrainingAfterShutdownStarts(t *testing.T) {
+	h := NewHealthHandler(map[string]HealthCheck{"storage": storage.NewMemoryStore().Ping})
+	if code, _ := getReadyz(h); code != http.StatusOK {
+		t.Fatalf("expected ready before drain, got %d", code)
+	}
+
+	h.Drain()
+	if code, body := getReadyz(h); code != http.StatusServiceUnavailable || body.Status != "draining" {
+		t.Fatalf("expected draining, got %d %+v", code, body)
+	}
+
+	// 存活探针不受关闭影响
+	rec := httptest.NewRecorder()
+	h.Healthz(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected healthz 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/wecom"
+)
+
+// WeComHandler 企业微信相关处理器
+type WeComHandler struct {
+	sessions    *auth.SessionManager
+	states      *auth.StateManager
+	authService *wecom.AuthService
+}
+
+// NewWeComHandler 创建新的企业微信处理器
+func NewWeComHandler(wecomClient *wecom.Client, sessions *auth.SessionManager, states *auth.StateManager) *WeComHandler {
+	return &WeComHandler{
+		sessions:    sessions,
+		states:      states,
+		authService: wecom.NewAuthService(wecomClient),
+	}
+}
+
+// Login 企业微信登录处理 - 返回授权URL给前端
+func (h *WeComHandler) Login(w http.ResponseWriter, r *http.Request) {
+	// 签发一次性 state，回调换取 token 时校验
+	state, err := h.states.Issue(r.Context(), auth.PlatformWeCom)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to issue WeCom oauth state", "error", err)
+		http.Error(w, "Failed to generate login URL", http.StatusInternalServerError)
+		return
+	}
+	authURL := h.authService.GenerateAuthURL(state)
+
+	// 返回授权URL和state给前端
+	response := map[string]string{
+		"auth_url": authURL,
+		"state":    state,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode WeCom login response", "error", err)
+		http.Error(w, "Failed to generate login URL", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ExchangeCode 处理前端发送的授权码，返回JWT token
+func (h *WeComHandler) ExchangeCode(w http.ResponseWriter, r *http.Request) {
+	var requestData models.AuthRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to decode request body", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if requestData.Code == "" {
+		http.Error(w, "Missing authorization code", http.StatusBadRequest)
+		return
+	}
+
+	// 校验 state，防止登录 CSRF
+	if !consumeState(w, r, h.states, requestData.State, auth.PlatformWeCom) {
+		return
+	}
+
+	// 用授权码换取用户信息
+	user, err := h.authService.WithContext(r.Context()).ExchangeCodeForUser(requestData.Code)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to exchange WeCom code for user", "error", err)
+		http.Error(w, fmt.Sprintf("Authentication failed: %v", err), http.StatusUnauthorized)
+		return
+	}
+
+	// 创建登录会话并签发令牌，企业微信汇报接口按成员 userid 查询
+	pair, err := h.sessions.Login(r.Context(), auth.NewPrincipal(auth.PlatformWeCom, user))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to generate JWT token", "error", err)
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	authResponse := newAuthResponse(pair, user)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(authResponse); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to encode auth response", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "WeCom authentication successful", "user", user.Name)
+}
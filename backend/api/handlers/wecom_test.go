@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/wecom"
+	"github.com/hellodeveye/report/pkg/wecom/wecomtest"
+)
+
+func newTestWeComHandler(t *testing.T, stateTTL time.Duration) (*WeComHandler, string) {
+	server := wecomtest.NewServer()
+	t.Cleanup(server.Close)
+	code := server.AddUser(wecomtest.User{UserID: "zhangsan", Name: "张三"})
+
+	store := storage.NewMemoryStore()
+	sessions := auth.NewSessionManager(store, &models.AuthConfig{JWTSecret: "test-jwt-secret", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour})
+	states := auth.NewStateManager(store, stateTTL)
+	return NewWeComHandler(wecom.NewClient(server.Config()), sessions, states), code
+}
+
+func loginState(t *testing.T, h *WeComHandler) string {
+	w := httptest.NewRecorder()
+	h.Login(w, httptest.NewRequest("GET", "/api/auth/wecom/login", nil))
+	var resp map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode login response failed: %v", err)
+	}
+	return resp["state"]
+}
+
+func exchange(h *WeComHandler, code, state string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.AuthRequest{Code: code, State: state})
+	return postWithToken(http.HandlerFunc(h.ExchangeCode), "/api/auth/wecom/exchange", "", string(body))
+}
+
+func TestExchangeCodeValidatesState(t *testing.T) {
+	h, code := newTestWeComHandler(t, time.Minute)
+
+	state := loginState(t, h)
+	if w := exchange(h, code, state); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// 同一个 state 不能再次使用
+	if w := exchange(h, code, state); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 on reused state, got %d", w.Code)
+	}
+	// 伪造或缺失的 state
+	for _, forged := range []string{"", "1718000000000000000"} {
+		if w := exchange(h, code, forged); w.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 on forged state %q, got %d", forged, w.Code)
+		}
+	}
+}
+
+func TestExchangeCodeRejectsExpiredState(t *testing.T) {
+	h, code := newTestWeComHandler(t, -time.Second)
+
+	if w := exchange(h, code, loginState(t, h)); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 on expired state, got %d: %s", w.Code, w.Body.String())
+	}
+}
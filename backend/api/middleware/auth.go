@@ -1,61 +1,56 @@
 package middleware
 
 import (
-	"context"
 	"net/http"
 	"strings"
 
 	"github.com/hellodeveye/report/pkg/auth"
 )
 
-// AuthMiddleware JWT认证中间件
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header is required", http.StatusUnauthorized)
-			return
-		}
-
-		// 检查Bearer token格式
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Authorization header format must be Bearer {token}", http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := parts[1]
-		claims, err := auth.ValidateToken(tokenString)
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
-
-		// 将用户信息添加到上下文中
-		ctx := context.WithValue(r.Context(), auth.UserOpenIDKey, claims.OpenID)
-		ctx = context.WithValue(ctx, auth.UserNameKey, claims.Name)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// AuthMiddleware JWT认证中间件，会话已撤销的token同样视为无效
+func AuthMiddleware(sessions *auth.SessionManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+				return
+			}
+
+			// 检查Bearer token格式
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				http.Error(w, "Authorization header format must be Bearer {token}", http.StatusUnauthorized)
+				return
+			}
+
+			principal, err := sessions.Validate(r.Context(), parts[1])
+			if err != nil {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			// 将用户身份添加到上下文中
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
 }
 
 // OptionalAuthMiddleware 可选认证中间件（不强制要求登录）
-func OptionalAuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader != "" {
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				tokenString := parts[1]
-				if claims, err := auth.ValidateToken(tokenString); err == nil {
-					// 将用户信息添加到上下文中
-					ctx := context.WithValue(r.Context(), auth.UserOpenIDKey, claims.OpenID)
-					ctx = context.WithValue(ctx, auth.UserNameKey, claims.Name)
-					r = r.WithContext(ctx)
+func OptionalAuthMiddleware(sessions *auth.SessionManager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader != "" {
+				parts := strings.SplitN(authHeader, " ", 2)
+				if len(parts) == 2 && parts[0] == "Bearer" {
+					if principal, err := sessions.Validate(r.Context(), parts[1]); err == nil {
+						r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+					}
 				}
 			}
-		}
 
-		next.ServeHTTP(w, r)
-	})
+			next.ServeHTTP(w, r)
+		})
+	}
 }
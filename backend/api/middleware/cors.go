@@ -2,22 +2,137 @@ package middleware
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+const (
+	corsAllowMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsAllowHeaders = "Content-Type, Authorization"
 )
 
-// CORS 中间件处理跨域请求
-func CORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// 设置CORS头
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-		// 处理预检请求
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// CORS 跨域中间件：只为白名单中的来源返回跨域响应头，并直接应答预检请求。
+// 需包裹整个路由器，这样路由只声明了 POST 等方法时预检请求同样能被处理。
+// 白名单含 "*" 时忽略 AllowCredentials，否则任意站点都能携带凭据读取响应
+func CORS(config *models.CORSConfig) func(http.Handler) http.Handler {
+	allowlist := newOriginAllowlist(config.AllowedOrigins)
+	allowCredentials := config.AllowCredentials && !allowlist.any
+	exposedHeaders := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 响应随 Origin 变化，避免缓存把某个来源的响应返回给其他来源
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !allowlist.allows(origin) {
+				if preflight {
+					http.Error(w, "Origin not allowed", http.StatusForbidden)
+					return
+				}
+				// 非预检请求照常处理，浏览器因缺少跨域响应头而拒绝读取结果
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if allowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+				if config.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", maxAge)
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			if exposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originPattern 允许的来源，host 以 "*." 开头时匹配任意层级的子域名（不含主域名本身）
+type originPattern struct {
+	scheme string
+	host   string
+	port   string
+	suffix string
+}
+
+type originAllowlist struct {
+	any      bool
+	patterns []originPattern
+}
+
+// newOriginAllowlist 解析来源白名单，"*" 表示允许任意来源，格式错误的条目被忽略
+func newOriginAllowlist(origins []string) *originAllowlist {
+	allowlist := &originAllowlist{}
+	for _, origin := range origins {
+		if origin == "*" {
+			allowlist.any = true
+			continue
+		}
+		scheme, rest, ok := strings.Cut(strings.ToLower(strings.TrimSuffix(origin, "/")), "://")
+		if !ok || rest == "" {
+			continue
+		}
+		host, port := splitHostPort(rest)
+		pattern := originPattern{scheme: scheme, host: host, port: port}
+		if suffix, ok := strings.CutPrefix(host, "*."); ok {
+			pattern.host = ""
+			pattern.suffix = "." + suffix
 		}
+		allowlist.patterns = append(allowlist.patterns, pattern)
+	}
+	return allowlist
+}
+
+func (a *originAllowlist) allows(origin string) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+		return false
+	}
+	if a.any {
+		return true
+	}
+	host, port := splitHostPort(u.Host)
+	for _, p := range a.patterns {
+		if p.scheme != u.Scheme || p.port != port {
+			continue
+		}
+		if p.suffix != "" {
+			if strings.HasSuffix(host, p.suffix) && len(host) > len(p.suffix) {
+				return true
+			}
+		} else if p.host == host {
+			return true
+		}
+	}
+	return false
+}
 
-		next.ServeHTTP(w, r)
-	})
+// splitHostPort 拆分 host:port，不含端口时 port 为空
+func splitHostPort(hostport string) (string, string) {
+	if i := strings.LastIndex(hostport, ":"); i >= 0 && !strings.Contains(hostport[i:], "]") {
+		return hostport[:i], hostport[i+1:]
+	}
+	return hostport, ""
 }
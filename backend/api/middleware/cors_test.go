@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+func newCORSHandler(config *models.CORSConfig) http.Handler {
+	return CORS(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestCORSOrigins(t *testing.T) {
+	h := newCORSHandler(&models.CORSConfig{
+		AllowedOrigins: []string{"http://localhost:5173", "https://*.example.com", "https://app.example.org:8443/"},
+	})
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"http://localhost:5173", true},
+		{"http://localhost:5174", false},
+		{"https://localhost:5173", false},
+		{"https://app.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://APP.Example.com", true},
+		{"https://example.com", false},
+		{"https://evil-example.com", false},
+		{"https://app.example.com.evil.io", false},
+		{"http://app.example.com", false},
+		{"https://app.example.com:8443", false},
+		{"https://app.example.org:8443", true},
+		{"https://app.example.org", false},
+		{"null", false},
+		{"https://app.example.com/path", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/graphql", nil)
+			req.Header.Set("Origin", tt.origin)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("non-preflight request should reach handler, got %d", rec.Code)
+			}
+			got := rec.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Fatalf("expected Allow-Origin %q, got %q", tt.origin, got)
+			}
+			if !tt.allowed && got != "" {
+				t.Fatalf("expected no Allow-Origin, got %q", got)
+			}
+			if rec.Header().Get("Vary") != "Origin" {
+				t.Fatalf("expected Vary: Origin, got %q", rec.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	config := &models.CORSConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		AllowCredentials: true,
+		ExposedHeaders:   []string{"X-Request-ID"},
+		MaxAge:           10 * time.Minute,
+	}
+	h := newCORSHandler(config)
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantStatus int
+	}{
+		{"allowed origin", "https://app.example.com", http.StatusNoContent},
+		{"rejected origin", "https://evil.io", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/auth/refresh", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus != http.StatusNoContent {
+				if rec.Header().Get("Access-Control-Allow-Origin") != "" || rec.Header().Get("Access-Control-Allow-Methods") != "" {
+					t.Fatal("rejected preflight should not carry CORS headers")
+				}
+				return
+			}
+			headers := rec.Header()
+			if headers.Get("Access-Control-Allow-Origin") != tt.origin ||
+				headers.Get("Access-Control-Allow-Credentials") != "true" ||
+				headers.Get("Access-Control-Max-Age") != "600" ||
+				!strings.Contains(headers.Get("Access-Control-Allow-Headers"), "Authorization") ||
+				!strings.Contains(headers.Get("Access-Control-Allow-Methods"), "POST") {
+				t.Fatalf("unexpected preflight headers: %v", headers)
+			}
+			if vary := strings.Join(headers.Values("Vary"), ","); !strings.Contains(vary, "Origin") || !strings.Contains(vary, "Access-Control-Request-Method") {
+				t.Fatalf("unexpected Vary: %q", vary)
+			}
+		})
+	}
+
+	// 实际请求返回可读取的响应头，不重复预检字段
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/user", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" || rec.Header().Get("Access-Control-Max-Age") != "" {
+		t.Fatalf("unexpected response headers: %v", rec.Header())
+	}
+}
+
+func TestCORSWildcardAndSameOrigin(t *testing.T) {
+	h := newCORSHandler(&models.CORSConfig{AllowedOrigins: []string{"*"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/user", nil)
+	req.Header.Set("Origin", "https://anything.io")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	// 回显具体来源而非 *
+	if rec.Header().Get("Access-Control-Allow-Origin") != "https://anything.io" {
+		t.Fatalf("expected origin to be echoed, got %q", rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// 同源或非浏览器请求不带 Origin，不返回跨域响应头；不带预检头的 OPTIONS 交给路由处理
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		req = httptest.NewRequest(method, "/api/auth/user", nil)
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("%s without Origin: got %d %v", method, rec.Code, rec.Header())
+		}
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	tests := []struct {
+		name            string
+		origins         []string
+		wantCredentials string
+	}{
+		{"allowlisted origin", []string{"https://app.example.com"}, "true"},
+		{"wildcard subdomain", []string{"https://*.example.com"}, "true"},
+		{"any origin", []string{"*"}, ""},
+		{"any origin with allowlist", []string{"https://app.example.com", "*"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newCORSHandler(&models.CORSConfig{AllowedOrigins: tt.origins, AllowCredentials: true})
+			for _, method := range []string{http.MethodGet, http.MethodOptions} {
+				req := httptest.NewRequest(method, "/api/auth/user", nil)
+				req.Header.Set("Origin", "https://app.example.com")
+				if method == http.MethodOptions {
+					req.Header.Set("Access-Control-Request-Method", "POST")
+				}
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+
+				if rec.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" {
+					t.Fatalf("%s: expected origin to be allowed, got %v", method, rec.Header())
+				}
+				if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+					t.Fatalf("%s: expected Allow-Credentials %q, got %q", method, tt.wantCredentials, got)
+				}
+			}
+		})
+	}
+}
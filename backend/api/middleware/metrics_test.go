@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func sampleCount(t *testing.T, vec *prometheus.HistogramVec, labels ...string) uint64 {
+	t.Helper()
+	observer, err := vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		t.Fatalf("GetMetricWithLabelValues failed: %v", err)
+	}
+	var m dto.Metric
+	if err := observer.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetricsUsesRouteTemplate(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(Metrics)
+	api := r.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/reports/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}).Methods("POST")
+
+	before := testutil.CollectAndCount(httpRequestDuration)
+	for _, id := range []string{"1", "2", "3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/reports/"+id, nil))
+	}
+	// 同一路由模板只产生一个序列
+	if got := testutil.CollectAndCount(httpRequestDuration); got != before+1 {
+		t.Fatalf("expected one new series, got %d -> %d", before, got)
+	}
+	if count := sampleCount(t, httpRequestDuration, "POST", "/api/reports/{id}", "201"); count != 3 {
+		t.Fatalf("expected 3 observations, got %d", count)
+	}
+}
+
+func TestMetricsKeepsFlusherAndUnwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := mux.NewRouter()
+	r.Use(Metrics)
+	r.HandleFunc("/api/ai/stream", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("wrapped writer should implement http.Flusher")
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok || unwrapper.Unwrap() != rec {
+			t.Fatal("wrapped writer should unwrap to the original writer")
+		}
+		w.Write([]byte("event: delta\n\n"))
+		flusher.Flush()
+	})
+
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/ai/stream", nil))
+	if !rec.Flushed {
+		t.Fatal("expected response to be flushed")
+	}
+	if count := sampleCount(t, httpRequestDuration, "POST", "/api/ai/stream", "200"); count != 1 {
+		t.Fatalf("expected 1 observation, got %d", count)
+	}
+}
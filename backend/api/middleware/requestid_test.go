@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hellodeveye/report/pkg/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		reuse    bool
+	}{
+		{"generated when missing", "", false},
+		{"reuses upstream id", "gw-123_abc.1", true},
+		{"rejects injected characters", "abc\nlevel=ERROR", false},
+		{"rejects overlong id", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/auth/user", nil)
+			if tt.incoming != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(logging.RequestIDHeader)
+			if got == "" || got != seen {
+				t.Fatalf("response id %q should match context id %q", got, seen)
+			}
+			if (got == tt.incoming) != tt.reuse {
+				t.Fatalf("incoming %q, got %q, reuse=%v", tt.incoming, got, tt.reuse)
+			}
+		})
+	}
+}
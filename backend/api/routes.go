@@ -1,45 +1,110 @@
 package api
 
 import (
+	"context"
+	"net/http"
+
 	"github.com/gorilla/mux"
-	"github.com/graphql-go/handler"
 	"github.com/hellodeveye/report/api/handlers"
 	"github.com/hellodeveye/report/api/middleware"
 	"github.com/hellodeveye/report/graphql"
+	"github.com/hellodeveye/report/internal/config"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/authz"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/feishu"
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/platform"
+	"github.com/hellodeveye/report/pkg/profile"
+	"github.com/hellodeveye/report/pkg/wecom"
 )
 
-// SetupRoutes 设置所有API路由
-func SetupRoutes() *mux.Router {
+// SetupRoutes 设置所有API路由，同时返回探针处理器以便服务关闭时标记为不可用
+func SetupRoutes(cfg *config.Config, store storage.Store) (http.Handler, *handlers.HealthHandler) {
 	r := mux.NewRouter()
-
-	// 添加CORS中间件
-	r.Use(middleware.CORS)
+	// 按路由模板记录链路 span，统计请求耗时与状态码
+	r.Use(middleware.Tracing)
+	r.Use(middleware.Metrics)
 
 	// API路由组
 	api := r.PathPrefix("/api").Subrouter()
 
-	// 创建钉钉处理器
-	dingTalkHandler := handlers.NewDingTalkHandler()
+	// 各平台客户端在处理器与GraphQL之间共享，以复用access_token缓存
+	dingtalkClient := dingtalk.NewClient(&cfg.DingTalk)
+	feishuClient := feishu.NewClient(&cfg.Feishu)
+	wecomClient := wecom.NewClient(&cfg.WeCom)
+
+	// 大模型服务由后端统一配置，前端不再持有API Key
+	llmProvider := llm.NewOpenAIProvider(&cfg.LLM)
+
+	// 登录会话：短期访问令牌 + 可轮换的刷新令牌，会话可在服务端撤销
+	authConfig := &cfg.Auth
+	sessions := auth.NewSessionManager(store, authConfig)
+	// OAuth state 服务端保存、限时且一次性使用，防止登录 CSRF
+	states := auth.NewStateManager(store, authConfig.OAuthStateTTL)
+
+	// 创建各平台登录处理器
+	dingTalkHandler := handlers.NewDingTalkHandler(dingtalkClient, sessions, states)
+	feishuHandler := handlers.NewFeishuHandler(feishuClient, sessions, states)
+	wecomHandler := handlers.NewWeComHandler(wecomClient, sessions, states)
+	// 当前用户资料按用户缓存，REST 与 GraphQL 共用
+	profiles := profile.NewService(map[string]platform.ProfileProvider{
+		auth.PlatformDingTalk: dingtalk.NewProfileProvider(dingtalkClient),
+	}, authConfig.ProfileCacheTTL)
+	authHandler := handlers.NewAuthHandler(sessions, profiles)
 
 	// 认证相关路由（无需登录）
 	api.HandleFunc("/auth/dingtalk/login", dingTalkHandler.Login).Methods("GET")
 	api.HandleFunc("/auth/dingtalk/exchange", dingTalkHandler.ExchangeCode).Methods("POST")
-	api.HandleFunc("/auth/logout", dingTalkHandler.Logout).Methods("POST")
+	api.HandleFunc("/auth/feishu/login", feishuHandler.Login).Methods("GET")
+	api.HandleFunc("/auth/feishu/exchange", feishuHandler.ExchangeCode).Methods("POST")
+	api.HandleFunc("/auth/wecom/login", wecomHandler.Login).Methods("GET")
+	api.HandleFunc("/auth/wecom/exchange", wecomHandler.ExchangeCode).Methods("POST")
+	api.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST")
+
+	// 按组织角色限制跨用户、跨部门查询，拒绝记录写入审计日志
+	authorizer := authz.NewAuthorizer(map[string]platform.RoleProvider{
+		auth.PlatformDingTalk: dingtalk.NewRoleProvider(dingtalkClient),
+		auth.PlatformWeCom:    wecom.NewRoleProvider(wecomClient),
+	}, authConfig.AdminSubjects, authConfig.RoleCacheTTL, nil)
 
 	// 创建 GraphQL HTTP 处理器
-	schema := graphql.SetupGraphQLSchema()
-	h := handler.New(&handler.Config{
-		Schema:   schema,
-		Pretty:   true,
-		GraphiQL: true,
-	})
-	r.HandleFunc("/graphql", h.ServeHTTP)
+	schema := graphql.SetupGraphQLSchema(dingtalkClient, feishuClient, wecomClient, llmProvider, store, profiles, authorizer)
+	h := graphql.NewHandler(schema, &cfg.GraphQL)
+	// GraphiQL 调试入口仅在显式开启时注册，匿名访问只能打开页面和执行内省查询
+	if cfg.GraphQL.Playground {
+		r.Handle("/graphql", middleware.OptionalAuthMiddleware(sessions)(graphql.PublicHandler(h)))
+	}
 
 	// 需要认证的路由
 	protected := api.PathPrefix("").Subrouter()
-	protected.Use(middleware.AuthMiddleware)
+	protected.Use(middleware.AuthMiddleware(sessions))
+
+	protected.HandleFunc("/auth/user", authHandler.User).Methods("GET")
+	protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST")
+	protected.HandleFunc("/auth/logout-all", authHandler.LogoutAll).Methods("POST")
+	protected.Handle("/graphql", h)
+
+	// AI流式生成
+	aiHandler := handlers.NewAIHandler(llmProvider, cfg.LLM.MaxTokens, cfg.LLM.MaxConcurrentStreams)
+	protected.HandleFunc("/ai/stream", aiHandler.Stream).Methods("POST")
 
-	protected.HandleFunc("/graphql", h.ServeHTTP)
+	// 存活与就绪探针（无需登录），就绪检查存储连接与钉钉 access_token 获取
+	checks := map[string]handlers.HealthCheck{
+		"storage": store.Ping,
+	}
+	if cfg.DingTalk.AppKey != "" {
+		checks["dingtalk"] = func(ctx context.Context) error {
+			_, err := dingtalkClient.WithContext(ctx).GetAccessToken()
+			return err
+		}
+	}
+	health := handlers.NewHealthHandler(checks)
+	r.HandleFunc("/healthz", health.Healthz).Methods("GET")
+	r.HandleFunc("/readyz", health.Readyz).Methods("GET")
 
-	return r
+	// CORS 包裹整个路由器，未匹配到方法的预检请求同样需要应答；
+	// 请求 ID 在最外层生成，被拒绝的跨域请求同样可追踪
+	return middleware.RequestID(middleware.CORS(&cfg.CORS)(r)), health
 }
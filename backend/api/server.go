@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/hellodeveye/report/api/handlers"
+	"github.com/hellodeveye/report/internal/config"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/metrics"
+)
+
+// Server 带超时配置的 HTTP 服务
+type Server struct {
+	*http.Server
+	health     *handlers.HealthHandler
+	drainDelay time.Duration
+}
+
+// NewServer 创建 HTTP 服务，关闭时先调用 Drain 再调用 Shutdown
+func NewServer(cfg *config.Config, store storage.Store) *Server {
+	handler, health := SetupRoutes(cfg, store)
+	return &Server{
+		Server: &http.Server{
+			Addr:              ":" + cfg.Server.Port,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			ReadTimeout:       cfg.Server.ReadTimeout,
+			WriteTimeout:      cfg.Server.WriteTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		},
+		health:     health,
+		drainDelay: cfg.Server.DrainDelay,
+	}
+}
+
+// NewMetricsServer 创建只提供 /metrics 的独立 HTTP 服务，监听 metrics.addr；未开启指标时返回 nil。
+// 指标接口无需登录，与对外服务分开监听，便于只向内网或 Prometheus 开放
+func NewMetricsServer(cfg *config.Config) *http.Server {
+	if !cfg.Metrics.Enabled {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              cfg.Metrics.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+}
+
+// Drain 让就绪探针返回 503，并在 server.drain_delay 内继续接收新请求，
+// 留给负载均衡发现实例未就绪并停止转发的时间。Shutdown 会先关闭监听，之后探针已无法访问
+func (s *Server) Drain() {
+	s.health.Drain()
+	time.Sleep(s.drainDelay)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/config"
+	"github.com/hellodeveye/report/internal/storage"
+)
+
+func TestReadyzUnavailableWhileDraining(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.DrainDelay = 300 * time.Millisecond
+	srv := NewServer(cfg, storage.NewMemoryStore())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(listener)
+	baseURL := "http://" + listener.Addr().String()
+	get := func(path string) int {
+		resp, err := http.Get(baseURL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Fatalf("expected ready before drain, got %d", code)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		srv.Drain()
+		close(drained)
+	}()
+	// 等待期间监听未关闭，负载均衡仍能探测到 503，存活探针不受影响
+	time.Sleep(50 * time.Millisecond)
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", code)
+	}
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Fatalf("expected healthz 200 while draining, got %d", code)
+	}
+	select {
+	case <-drained:
+		t.Fatal("Drain returned before drain_delay")
+	default:
+	}
+
+	<-drained
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get(baseURL + "/readyz"); err == nil {
+		t.Fatal("expected listener to be closed after shutdown")
+	}
+}
+
+func TestMetricsServedOnSeparateListener(t *testing.T) {
+	cfg := config.Default()
+	get := func(h http.Handler) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return rec.Code
+	}
+
+	// 对外服务的路由不提供 /metrics
+	if code := get(NewServer(cfg, storage.NewMemoryStore()).Handler); code != http.StatusNotFound {
+		t.Fatalf("expected 404 on public router, got %d", code)
+	}
+	metricsSrv := NewMetricsServer(cfg)
+	if metricsSrv.Addr != ":9090" {
+		t.Fatalf("unexpected metrics addr %q", metricsSrv.Addr)
+	}
+	if code := get(metricsSrv.Handler); code != http.StatusOK {
+		t.Fatalf("expected 200 on metrics server, got %d", code)
+	}
+
+	cfg.Metrics.Enabled = false
+	if NewMetricsServer(cfg) != nil {
+		t.Fatal("expected no metrics server when disabled")
+	}
+}
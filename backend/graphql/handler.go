@@ -0,0 +1,166 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/handler"
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+)
+
+// NewHandler 创建 GraphQL HTTP 处理器，按配置开启 GraphiQL 页面与内省查询，并按根字段统计次数与耗时
+func NewHandler(schema *graphql.Schema, config *models.GraphQLConfig) http.Handler {
+	h := withStartTime(handler.New(&handler.Config{
+		Schema:           schema,
+		Pretty:           true,
+		GraphiQL:         config.Playground,
+		ResultCallbackFn: recordOperation,
+	}))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, doc, err := parseRequest(w, r)
+		if err != nil {
+			writeBodyError(w, err)
+			return
+		}
+		if !config.Introspection && doc != nil && hasIntrospection(doc) {
+			writeGraphQLError(w, http.StatusBadRequest, "introspection is disabled")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// PublicHandler 包装未强制登录的 GraphQL 路由：匿名请求只能打开 GraphiQL 页面或执行内省查询，
+// 其他操作要求上下文中已有登录用户
+func PublicHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := auth.GetPrincipal(r.Context()); !ok {
+			query, doc, err := parseRequest(w, r)
+			if err != nil {
+				writeBodyError(w, err)
+				return
+			}
+			if query != "" && (doc == nil || !introspectionOnly(doc)) {
+				http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maxRequestBodyBytes GraphQL 请求体的大小上限，请求体在登录校验前读取
+const maxRequestBodyBytes = 1 << 20
+
+// parseRequest 返回请求中的查询语句及解析后的文档，语法错误时文档为 nil。
+// 请求体会被缓存并还原，后续处理器仍可读取；超过 maxRequestBodyBytes 或读取失败时返回错误
+func parseRequest(w http.ResponseWriter, r *http.Request) (string, *ast.Document, error) {
+	if r.Body != nil {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes))
+		if err != nil {
+			return "", nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		defer func() { r.Body = io.NopCloser(bytes.NewReader(body)) }()
+	}
+
+	// NewRequestOptions 会调用 ParseForm，在副本上解析以免影响原请求
+	clone := r.Clone(r.Context())
+	opts := handler.NewRequestOptions(clone)
+	if opts.Query == "" {
+		return "", nil, nil
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: opts.Query})
+	if err != nil {
+		return opts.Query, nil, nil
+	}
+	return opts.Query, doc, nil
+}
+
+// hasIntrospection 判断文档中是否查询了 __schema 或 __type，包括片段中的字段
+func hasIntrospection(doc *ast.Document) bool {
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			if selectsIntrospection(def.SelectionSet) {
+				return true
+			}
+		case *ast.FragmentDefinition:
+			if selectsIntrospection(def.SelectionSet) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func selectsIntrospection(set *ast.SelectionSet) bool {
+	if set == nil {
+		return false
+	}
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if isIntrospectionField(selection.Name.Value) || selectsIntrospection(selection.SelectionSet) {
+				return true
+			}
+		case *ast.InlineFragment:
+			if selectsIntrospection(selection.SelectionSet) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// introspectionOnly 判断文档是否只包含内省查询，顶层字段全部为 __schema、__type 或 __typename
+func introspectionOnly(doc *ast.Document) bool {
+	operations := 0
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if op.Operation != ast.OperationTypeQuery {
+			return false
+		}
+		operations++
+		for _, selection := range op.SelectionSet.Selections {
+			field, ok := selection.(*ast.Field)
+			if !ok || (!isIntrospectionField(field.Name.Value) && field.Name.Value != "__typename") {
+				return false
+			}
+		}
+	}
+	return operations > 0
+}
+
+func isIntrospectionField(name string) bool {
+	return name == "__schema" || name == "__type"
+}
+
+// writeBodyError 返回读取请求体失败的错误，超过大小上限时为 413
+func writeBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeGraphQLError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		return
+	}
+	writeGraphQLError(w, http.StatusBadRequest, "failed to read request body")
+}
+
+// writeGraphQLError 以 GraphQL 响应格式返回请求级错误
+func writeGraphQLError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"message": message}},
+	})
+}
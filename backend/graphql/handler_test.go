@@ -0,0 +1,129 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+)
+
+func newTestSchema(t *testing.T) *graphql.Schema {
+	t.Helper()
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "RootQuery", Fields: graphql.Fields{
+			"hello": &graphql.Field{
+				Type:    graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) { return "world", nil },
+			},
+		}}),
+	})
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	return &schema
+}
+
+func postQuery(h http.Handler, query string, principal *auth.Principal) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if principal != nil {
+		req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerIntrospection(t *testing.T) {
+	schema := newTestSchema(t)
+	disabled := NewHandler(schema, &models.GraphQLConfig{})
+	enabled := NewHandler(schema, &models.GraphQLConfig{Introspection: true})
+
+	tests := []struct {
+		name    string
+		query   string
+		blocked bool
+	}{
+		{"plain query", `{ hello }`, false},
+		{"typename", `{ __typename hello }`, false},
+		{"schema", `{ __schema { types { name } } }`, true},
+		{"type", `query Q { __type(name: "RootQuery") { name } }`, true},
+		{"nested in fragment", `query { ...F } fragment F on RootQuery { __schema { queryType { name } } }`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postQuery(disabled, tt.query, nil)
+			if tt.blocked {
+				if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "introspection is disabled") {
+					t.Fatalf("expected introspection to be blocked, got %d %s", rec.Code, rec.Body.String())
+				}
+			} else if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "errors") {
+				t.Fatalf("expected query to succeed, got %d %s", rec.Code, rec.Body.String())
+			}
+
+			if rec := postQuery(enabled, tt.query, nil); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "errors") {
+				t.Fatalf("expected query to succeed with introspection enabled, got %d %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestPublicHandlerRequiresLoginForData(t *testing.T) {
+	h := PublicHandler(NewHandler(newTestSchema(t), &models.GraphQLConfig{Playground: true, Introspection: true}))
+	principal := &auth.Principal{Subject: "dingtalk:user-1", Platform: auth.PlatformDingTalk, UserID: "user-1"}
+
+	if rec := postQuery(h, `{ __schema { queryType { name } } }`, nil); rec.Code != http.StatusOK {
+		t.Fatalf("anonymous introspection should be allowed, got %d", rec.Code)
+	}
+	for _, query := range []string{`{ hello }`, `{ __typename hello }`, `mutation { __typename }`, `{ ...F } fragment F on RootQuery { hello }`, `{ hello`} {
+		if rec := postQuery(h, query, nil); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("anonymous %q should be rejected, got %d", query, rec.Code)
+		}
+	}
+	if rec := postQuery(h, `{ hello }`, principal); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "world") {
+		t.Fatalf("logged-in query should succeed, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// 匿名可以打开 GraphiQL 页面，页面本身不携带查询
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set("Accept", "text/html")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "graphiql") {
+		t.Fatalf("expected GraphiQL page, got %d", rec.Code)
+	}
+}
+
+func TestHandlersRejectOversizedBody(t *testing.T) {
+	schema := newTestSchema(t)
+	principal := &auth.Principal{Subject: "dingtalk:user-1", Platform: auth.PlatformDingTalk, UserID: "user-1"}
+	// 查询本身合法，只是带有超长的注释
+	query := "{ hello } #" + strings.Repeat("x", maxRequestBodyBytes)
+
+	tests := []struct {
+		name      string
+		handler   http.Handler
+		principal *auth.Principal
+	}{
+		{"anonymous on public route", PublicHandler(NewHandler(schema, &models.GraphQLConfig{Playground: true})), nil},
+		{"logged in", NewHandler(schema, &models.GraphQLConfig{}), principal},
+		{"logged in with introspection", NewHandler(schema, &models.GraphQLConfig{Introspection: true}), principal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postQuery(tt.handler, query, tt.principal); rec.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("expected 413, got %d", rec.Code)
+			}
+			if rec := postQuery(tt.handler, "{ hello }", principal); rec.Code != http.StatusOK {
+				t.Fatalf("expected small request to succeed, got %d", rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/hellodeveye/report/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	operationsTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "graphql_operations_total",
+		Help:      "GraphQL 操作次数，按根字段与结果（ok/error）统计",
+	}, []string{"operation", "status"})
+	operationDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "graphql_operation_duration_seconds",
+		Help:      "GraphQL 操作耗时，按根字段统计",
+		Buckets:   metrics.DurationBuckets,
+	}, []string{"operation"})
+)
+
+type startTimeKey struct{}
+
+// withStartTime 记录请求开始时间，供 recordOperation 计算耗时
+func withStartTime(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), startTimeKey{}, time.Now())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// maxOperationFields 标签最多包含的根字段数，超出时记为 other，
+// 使标签取值数量只取决于 schema 的根字段数
+const maxOperationFields = 2
+
+// recordOperation 作为 handler.Config.ResultCallbackFn，在操作执行并写出响应后统计次数与耗时
+func recordOperation(ctx context.Context, params *graphql.Params, result *graphql.Result, _ []byte) {
+	operation := operationLabel(params)
+	status := "ok"
+	if result.HasErrors() {
+		status = "error"
+	}
+	operationsTotal.WithLabelValues(operation, status).Inc()
+	// 在 HTTP 请求的 span 上标注根字段，便于按操作检索链路
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("graphql.operation.fields", operation))
+	if start, ok := ctx.Value(startTimeKey{}).(time.Time); ok {
+		operationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+// operationLabel 返回指标使用的操作标签：实际执行的操作选择的根字段名，去重排序后以逗号连接。
+// 客户端声明的操作名可以任意填写，不作为标签；根字段必须是 schema 中的字段或内省字段，
+// 其余情况（包括找不到要执行的操作、根字段过多）记为 other，无法解析时为 invalid
+func operationLabel(params *graphql.Params) string {
+	doc, err := parser.Parse(parser.ParseParams{Source: params.RequestString})
+	if err != nil {
+		return "invalid"
+	}
+
+	var op *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	operations := 0
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.OperationDefinition:
+			operations++
+			if params.OperationName == "" || (def.Name != nil && def.Name.Value == params.OperationName) {
+				op = def
+			}
+		case *ast.FragmentDefinition:
+			fragments[def.Name.Value] = def
+		}
+	}
+	// 与执行时一致：未指定操作名时文档只能包含一个操作
+	if op == nil || (params.OperationName == "" && operations > 1) {
+		return "other"
+	}
+
+	root := rootType(params.Schema, op.Operation)
+	if root == nil {
+		return "other"
+	}
+	fields := make(map[string]bool)
+	if !collectRootFields(op.SelectionSet, fragments, make(map[string]bool), fields) {
+		return "other"
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		if _, ok := root.Fields()[name]; !ok && !isIntrospectionField(name) {
+			return "other"
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 || len(names) > maxOperationFields {
+		return "other"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// rootType 返回操作类型对应的根类型，schema 未定义时为 nil
+func rootType(schema graphql.Schema, operation string) *graphql.Object {
+	switch operation {
+	case ast.OperationTypeQuery:
+		return schema.QueryType()
+	case ast.OperationTypeMutation:
+		return schema.MutationType()
+	case ast.OperationTypeSubscription:
+		return schema.SubscriptionType()
+	}
+	return nil
+}
+
+// collectRootFields 收集选择集中的根字段名（展开片段，每个片段只展开一次，忽略 __typename），
+// 引用了不存在的片段时返回 false
+func collectRootFields(set *ast.SelectionSet, fragments map[string]*ast.FragmentDefinition, visited, fields map[string]bool) bool {
+	if set == nil {
+		return true
+	}
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name.Value != "__typename" {
+				fields[selection.Name.Value] = true
+			}
+		case *ast.InlineFragment:
+			if !collectRootFields(selection.SelectionSet, fragments, visited, fields) {
+				return false
+			}
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			if visited[name] {
+				continue
+			}
+			fragment, ok := fragments[name]
+			if !ok {
+				return false
+			}
+			visited[name] = true
+			if !collectRootFields(fragment.SelectionSet, fragments, visited, fields) {
+				return false
+			}
+		}
+	}
+	return true
+}
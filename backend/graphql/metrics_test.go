@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOperationLabel(t *testing.T) {
+	field := &graphql.Field{Type: graphql.String}
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{Name: "RootQuery", Fields: graphql.Fields{
+			"hello": field, "world": field, "extra": field,
+		}}),
+		Mutation: graphql.NewObject(graphql.ObjectConfig{Name: "RootMutation", Fields: graphql.Fields{
+			"save": field,
+		}}),
+	})
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+
+	tests := []struct {
+		query         string
+		operationName string
+		want          string
+	}{
+		{"query Hello { hello }", "", "hello"},
+		// 客户端声明的操作名与别名不影响标签
+		{"query A1 { hello }", "", "hello"},
+		{"query A2 { renamed: hello }", "", "hello"},
+		{"{ world hello __typename }", "", "hello,world"},
+		{"query A { hello } query B { world }", "B", "world"},
+		{"mutation Save { save }", "", "save"},
+		{"query { ...F } fragment F on RootQuery { hello ... on RootQuery { world } }", "", "hello,world"},
+		{"{ __schema { types { name } } }", "", "__schema"},
+		{"{ hello world extra }", "", "other"},
+		{"{ missing }", "", "other"},
+		{"{ save }", "", "other"},
+		{"subscription { hello }", "", "other"},
+		{"{ ...Missing }", "", "other"},
+		{"query A { hello }", "Injected", "other"},
+		{"query A { hello } query B { world }", "", "other"},
+		{"query {", "", "invalid"},
+	}
+	for _, tt := range tests {
+		params := &graphql.Params{Schema: schema, RequestString: tt.query, OperationName: tt.operationName}
+		if got := operationLabel(params); got != tt.want {
+			t.Errorf("operationLabel(%q, %q) = %q, want %q", tt.query, tt.operationName, got, tt.want)
+		}
+	}
+}
+
+func TestHandlerRecordsOperations(t *testing.T) {
+	h := NewHandler(newTestSchema(t), &models.GraphQLConfig{})
+	ok := operationsTotal.WithLabelValues("hello", "ok")
+	failed := operationsTotal.WithLabelValues("other", "error")
+	okBefore, failedBefore := testutil.ToFloat64(ok), testutil.ToFloat64(failed)
+
+	postQuery(h, "query Hello { hello }", nil)
+	postQuery(h, "query Broken { missing }", nil)
+
+	if got := testutil.ToFloat64(ok) - okBefore; got != 1 {
+		t.Fatalf("expected 1 successful hello operation, got %v", got)
+	}
+	if got := testutil.ToFloat64(failed) - failedBefore; got != 1 {
+		t.Fatalf("expected 1 failed other operation, got %v", got)
+	}
+}
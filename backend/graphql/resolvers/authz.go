@@ -0,0 +1,64 @@
+package resolvers
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/authz"
+)
+
+var authorizer *authz.Authorizer
+
+func InitAuthzResolvers(a *authz.Authorizer) {
+	authorizer = a
+}
+
+// RequireUserScope 包装 resolver：要求当前用户来自 userPlatform，
+// 参数 arg 指定了其他用户时，要求当前用户的角色覆盖该用户
+func RequireUserScope(userPlatform, arg string, next graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		principal, ok := auth.GetPrincipal(p.Context)
+		if !ok {
+			return nil, fmt.Errorf("unauthorized")
+		}
+		if _, err := currentUserID(p, userPlatform); err != nil {
+			return nil, err
+		}
+		if userID, _ := p.Args[arg].(string); userID != "" {
+			if err := authorizer.AuthorizeUser(p.Context, principal, p.Info.FieldName, userPlatform, userID); err != nil {
+				return nil, wrapError(err)
+			}
+		}
+		return next(p)
+	}
+}
+
+// RequireDepartmentScope 包装 resolver：要求当前用户的角色覆盖参数 arg 指定的部门
+func RequireDepartmentScope(arg string, next graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		principal, ok := auth.GetPrincipal(p.Context)
+		if !ok {
+			return nil, fmt.Errorf("unauthorized")
+		}
+		deptID, _ := p.Args[arg].(string)
+		if err := authorizer.AuthorizeDepartment(p.Context, principal, p.Info.FieldName, deptID); err != nil {
+			return nil, wrapError(err)
+		}
+		return next(p)
+	}
+}
+
+// RequireOrganizationScope 包装 resolver：要求当前用户为管理员，用于返回全企业数据的字段
+func RequireOrganizationScope(next graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		principal, ok := auth.GetPrincipal(p.Context)
+		if !ok {
+			return nil, fmt.Errorf("unauthorized")
+		}
+		if err := authorizer.AuthorizeOrganization(p.Context, principal, p.Info.FieldName); err != nil {
+			return nil, wrapError(err)
+		}
+		return next(p)
+	}
+}
@@ -0,0 +1,68 @@
+package resolvers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/auth"
+)
+
+var draftStore storage.DraftStore
+
+func InitDraftResolvers(store storage.DraftStore) {
+	draftStore = store
+}
+
+// GetDraftsResolver 返回当前用户的草稿，指定 template_id 时只返回该模板的草稿
+func GetDraftsResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, ok := auth.GetSubject(p.Context)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	if templateID, ok := p.Args["template_id"].(string); ok && templateID != "" {
+		draft, err := draftStore.GetDraft(p.Context, userID, templateID)
+		if errors.Is(err, storage.ErrNotFound) {
+			return []storage.Draft{}, nil
+		}
+		if err != nil {
+			return nil, wrapStorageError(p, err)
+		}
+		return []storage.Draft{*draft}, nil
+	}
+	drafts, err := draftStore.ListDrafts(p.Context, userID)
+	if err != nil {
+		return nil, wrapStorageError(p, err)
+	}
+	return drafts, nil
+}
+
+// SaveDraftResolver 保存草稿，version 为客户端当前持有的版本号（新建时为0）
+func SaveDraftResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, ok := auth.GetSubject(p.Context)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	templateID, _ := p.Args["template_id"].(string)
+	content, _ := p.Args["content"].(string)
+	version, _ := p.Args["version"].(int)
+	draft, err := draftStore.SaveDraft(p.Context, userID, templateID, content, version)
+	if err != nil {
+		return nil, wrapStorageError(p, err)
+	}
+	return draft, nil
+}
+
+// DeleteDraftResolver 删除草稿
+func DeleteDraftResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, ok := auth.GetSubject(p.Context)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	templateID, _ := p.Args["template_id"].(string)
+	if err := draftStore.DeleteDraft(p.Context, userID, templateID); err != nil {
+		return nil, wrapStorageError(p, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,172 @@
+package resolvers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/authz"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/feishu"
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/platform"
+	"github.com/hellodeveye/report/pkg/summary"
+	"github.com/hellodeveye/report/pkg/wecom"
+)
+
+// GraphQL 错误 extensions 中的 code 取值
+const (
+	errorCodeRateLimited       = "RATE_LIMITED"
+	errorCodeTokenExpired      = "TOKEN_EXPIRED"
+	errorCodePermissionDenied  = "PERMISSION_DENIED"
+	errorCodeDingTalkAPI       = "DINGTALK_API_ERROR"
+	errorCodeFeishuAPI         = "FEISHU_API_ERROR"
+	errorCodeWeComAPI          = "WECOM_API_ERROR"
+	errorCodePlatformMismatch  = "PLATFORM_MISMATCH"
+	errorCodeNotSupported      = "NOT_SUPPORTED"
+	errorCodeInvalidRecipients = "INVALID_RECIPIENTS"
+	errorCodeLLMNotConfigured  = "LLM_NOT_CONFIGURED"
+	errorCodeNoSourceReports   = "NO_SOURCE_REPORTS"
+	errorCodeVersionConflict   = "VERSION_CONFLICT"
+	errorCodeNotFound          = "NOT_FOUND"
+	errorCodeForbidden         = "FORBIDDEN"
+	errorCodeInternal          = "INTERNAL_ERROR"
+	errorCodeInvalidArgument   = "INVALID_ARGUMENT"
+)
+
+// extendedError 携带 extensions 的 GraphQL 错误，实现 gqlerrors.ExtendedError
+type extendedError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e *extendedError) Error() string {
+	return e.message
+}
+
+func (e *extendedError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// wrapError 将钉钉接口错误等已知错误转换为带结构化 extensions 的 GraphQL 错误，其他错误原样返回
+func wrapError(err error) error {
+	switch {
+	case errors.Is(err, llm.ErrNotConfigured):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeLLMNotConfigured}}
+	case errors.Is(err, summary.ErrNoSourceReports):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeNoSourceReports}}
+	case errors.Is(err, storage.ErrVersionConflict):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeVersionConflict}}
+	case errors.Is(err, storage.ErrNotFound):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeNotFound}}
+	case errors.Is(err, authz.ErrForbidden):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeForbidden}}
+	case errors.Is(err, platform.ErrNotSupported):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeNotSupported}}
+	case errors.Is(err, platform.ErrInvalidRequest):
+		return &extendedError{message: err.Error(), extensions: map[string]interface{}{"code": errorCodeInvalidArgument}}
+	}
+
+	var recipientsErr *platform.InvalidRecipientsError
+	if errors.As(err, &recipientsErr) {
+		return &extendedError{
+			message: err.Error(),
+			extensions: map[string]interface{}{
+				"code":            errorCodeInvalidRecipients,
+				"invalid_userids": recipientsErr.UserIDs,
+			},
+		}
+	}
+
+	var feishuErr *feishu.APIError
+	if errors.As(err, &feishuErr) {
+		return wrapFeishuError(err, feishuErr)
+	}
+
+	var wecomErr *wecom.APIError
+	if errors.As(err, &wecomErr) {
+		return wrapWeComError(err, wecomErr)
+	}
+
+	var apiErr *dingtalk.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	code := errorCodeDingTalkAPI
+	switch {
+	case dingtalk.IsRateLimited(err):
+		code = errorCodeRateLimited
+	case dingtalk.IsTokenExpired(err):
+		code = errorCodeTokenExpired
+	case dingtalk.IsPermissionDenied(err):
+		code = errorCodePermissionDenied
+	}
+
+	return &extendedError{
+		message: err.Error(),
+		extensions: map[string]interface{}{
+			"code":       code,
+			"errcode":    apiErr.ErrCode,
+			"errmsg":     apiErr.ErrMsg,
+			"request_id": apiErr.RequestID,
+			"endpoint":   apiErr.Endpoint,
+		},
+	}
+}
+
+// wrapStorageError 转换存储错误：ErrNotFound 等已知错误同 wrapError，
+// 其余错误只写入日志，不把数据库的错误信息返回给客户端
+func wrapStorageError(p graphql.ResolveParams, err error) error {
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrVersionConflict) {
+		return wrapError(err)
+	}
+	slog.ErrorContext(p.Context, "Storage operation failed", "field", p.Info.FieldName, "error", err)
+	return &extendedError{message: "storage is unavailable", extensions: map[string]interface{}{"code": errorCodeInternal}}
+}
+
+// wrapFeishuError 将飞书接口错误转换为带结构化 extensions 的 GraphQL 错误
+func wrapFeishuError(err error, apiErr *feishu.APIError) error {
+	code := errorCodeFeishuAPI
+	switch {
+	case feishu.IsRateLimited(err):
+		code = errorCodeRateLimited
+	case feishu.IsTokenExpired(err):
+		code = errorCodeTokenExpired
+	}
+
+	return &extendedError{
+		message: err.Error(),
+		extensions: map[string]interface{}{
+			"code":     code,
+			"errcode":  apiErr.Code,
+			"errmsg":   apiErr.Msg,
+			"log_id":   apiErr.LogID,
+			"endpoint": apiErr.Endpoint,
+		},
+	}
+}
+
+// wrapWeComError 将企业微信接口错误转换为带结构化 extensions 的 GraphQL 错误
+func wrapWeComError(err error, apiErr *wecom.APIError) error {
+	code := errorCodeWeComAPI
+	switch {
+	case wecom.IsRateLimited(err):
+		code = errorCodeRateLimited
+	case wecom.IsTokenExpired(err):
+		code = errorCodeTokenExpired
+	case wecom.IsPermissionDenied(err):
+		code = errorCodePermissionDenied
+	}
+
+	return &extendedError{
+		message: err.Error(),
+		extensions: map[string]interface{}{
+			"code":     code,
+			"errcode":  apiErr.ErrCode,
+			"errmsg":   apiErr.ErrMsg,
+			"endpoint": apiErr.Endpoint,
+		},
+	}
+}
@@ -0,0 +1,29 @@
+package resolvers
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/feishu"
+)
+
+var feishuReportService *feishu.ReportService
+
+func InitFeishuResolvers(service *feishu.ReportService) {
+	feishuReportService = service
+}
+
+// GetFeishuReportsResolver 返回飞书用户在时间范围内提交的全部汇报
+func GetFeishuReportsResolver(p graphql.ResolveParams) (interface{}, error) {
+	openID, err := currentUserID(p, auth.PlatformFeishu)
+	if err != nil {
+		return nil, err
+	}
+	ruleName, _ := p.Args["rule_name"].(string)
+	startTime, _ := p.Args["start_time"].(int)
+	endTime, _ := p.Args["end_time"].(int)
+	tasks, err := feishuReportService.WithContext(p.Context).ListAllTasks(ruleName, openID, int64(startTime), int64(endTime))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return tasks, nil
+}
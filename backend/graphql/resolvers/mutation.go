@@ -1,59 +1,79 @@
 package resolvers
 
 import (
-	"fmt"
-
 	"github.com/graphql-go/graphql"
 	"github.com/hellodeveye/report/pkg/auth"
-	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/summary"
 )
 
+// CreateDingTalkReportResolver 创建并发送钉钉日志。
+// 接收人为显式指定的 to_userids/to_cids，use_template_defaults 为 true 时合并模板默认的接收人与接收群。
 func CreateDingTalkReportResolver(p graphql.ResolveParams) (interface{}, error) {
-	userID, ok := auth.GetUserOpenID(p.Context)
-	if !ok {
-		return nil, fmt.Errorf("unauthorized")
+	userID, err := currentUserID(p, auth.PlatformDingTalk)
+	if err != nil {
+		return nil, err
 	}
-	templateName, _ := p.Args["template_name"].(string)
-	templateID, _ := p.Args["template_id"].(string)
-	contents, _ := p.Args["contents"].([]interface{})
-
-	templateDetail, err := dingtalkReportService.GetTemplateDetail(userID, templateName)
+	report, err := reportProviders[auth.PlatformDingTalk].CreateReport(p.Context, userID, createReportArgs(p.Args))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get template details: %v", err)
-	}
-	fieldMap := make(map[string]dingtalk.Field)
-	for _, field := range templateDetail.Result.Fields {
-		fieldMap[field.FieldName] = field
-	}
-	var reportContents []dingtalk.ContentItem
-	for _, c := range contents {
-		contentMap := c.(map[string]interface{})
-		key := contentMap["key"].(string)
-		value := contentMap["value"].(string)
-		if field, exists := fieldMap[key]; exists {
-			reportContents = append(reportContents, dingtalk.ContentItem{
-				Key: field.FieldName, Sort: field.Sort, Type: field.Type, Content: value, ContentType: "markdown",
-			})
-		}
-	}
-	createReq := dingtalk.CreateReportRequest{
-		CreateReportParam: struct {
-			Contents   []dingtalk.ContentItem `json:"contents"`
-			DDFrom     string                 `json:"dd_from"`
-			TemplateID string                 `json:"template_id"`
-			UserID     string                 `json:"userid"`
-			ToChat     bool                   `json:"to_chat"`
-			ToCIDs     []string               `json:"to_cids"`
-			ToUserIDs  []string               `json:"to_userids"`
-		}{
-			TemplateID: templateID, UserID: userID, Contents: reportContents,
-		},
-	}
-	createResp, err := dingtalkReportService.Create(userID, &createReq)
+		return nil, wrapError(err)
+	}
+
+	// 按 ReceiverType、ConversationType 的字段名返回接收人与接收群
+	receivers := make([]map[string]interface{}, 0, len(report.Receivers))
+	for _, receiver := range report.Receivers {
+		receivers = append(receivers, map[string]interface{}{"userId": receiver.ID, "userName": receiver.Name})
+	}
+	convs := make([]map[string]interface{}, 0, len(report.Chats))
+	for _, chat := range report.Chats {
+		convs = append(convs, map[string]interface{}{"conversationId": chat.ID, "title": chat.Name})
+	}
+	return map[string]interface{}{
+		"report_id":      report.ID,
+		"template_name":  report.TemplateName,
+		"creator_id":     report.CreatorID,
+		"to_chat":        report.ToChat,
+		"receivers":      receivers,
+		"received_convs": convs,
+	}, nil
+}
+
+// SaveDingTalkDraftResolver 将内容保存为钉钉日志草稿，用户可在钉钉客户端中确认后再发送
+func SaveDingTalkDraftResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := currentUserID(p, auth.PlatformDingTalk)
 	if err != nil {
 		return nil, err
 	}
+	req := createReportArgs(p.Args)
+	draftID, err := reportProviders[auth.PlatformDingTalk].SaveDraft(p.Context, userID, req)
+	if err != nil {
+		return nil, wrapError(err)
+	}
 	return map[string]interface{}{
-		"report_id": createResp.Result,
+		"draft_id":      draftID,
+		"template_id":   req.TemplateID,
+		"template_name": req.TemplateName,
 	}, nil
 }
+
+var summaryGenerator *summary.Generator
+
+func InitSummaryResolvers(generator *summary.Generator) {
+	summaryGenerator = generator
+}
+
+// GenerateSummaryResolver 基于时间范围内的日报生成目标模板的汇总草稿，日报与模板取自用户所在平台
+func GenerateSummaryResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, provider, err := currentProvider(p)
+	if err != nil {
+		return nil, err
+	}
+	templateName, _ := p.Args["template_name"].(string)
+	startTime, _ := p.Args["start_time"].(int)
+	endTime, _ := p.Args["end_time"].(int)
+	targetTemplate, _ := p.Args["target_template"].(string)
+	draft, err := summaryGenerator.Generate(p.Context, provider, userID, templateName, int64(startTime), int64(endTime), targetTemplate)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return draft, nil
+}
@@ -0,0 +1,27 @@
+package resolvers
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/auth"
+)
+
+// currentUserID 返回当前登录用户的ID，用户不是通过 platform 登录时返回 PLATFORM_MISMATCH 错误，
+// 避免把钉钉的 userid 拿去调用飞书接口（反之亦然）
+func currentUserID(p graphql.ResolveParams, platform string) (string, error) {
+	userID, ok := auth.GetPlatformUserID(p.Context)
+	if !ok {
+		return "", fmt.Errorf("unauthorized")
+	}
+	if userPlatform := auth.GetUserPlatform(p.Context); userPlatform != platform {
+		return "", &extendedError{
+			message: fmt.Sprintf("%s is not available for %s users", p.Info.FieldName, userPlatform),
+			extensions: map[string]interface{}{
+				"code":     errorCodePlatformMismatch,
+				"platform": userPlatform,
+			},
+		}
+	}
+	return userID, nil
+}
@@ -2,6 +2,7 @@ package resolvers
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/graphql-go/graphql"
 	"github.com/hellodeveye/report/graphql/types"
@@ -9,12 +10,20 @@ import (
 	"github.com/hellodeveye/report/pkg/dingtalk"
 )
 
+// GetDingTalkTemplatesResolver 返回用户可用的钉钉模板，未指定 userId 时为当前用户
 func GetDingTalkTemplatesResolver(p graphql.ResolveParams) (interface{}, error) {
-	userId, _ := p.Args["userId"].(string)
-	templates, err := dingtalkReportService.GetTemplates(userId)
+	currentID, err := currentUserID(p, auth.PlatformDingTalk)
 	if err != nil {
 		return nil, err
 	}
+	userId, _ := p.Args["userId"].(string)
+	if userId == "" {
+		userId = currentID
+	}
+	templates, err := dingtalkReportService.WithContext(p.Context).GetTemplates(userId)
+	if err != nil {
+		return nil, wrapError(err)
+	}
 	if name, ok := p.Args["name"].(string); ok && name != "" {
 		for _, template := range templates.Result.TemplateList {
 			if template.Name == name {
@@ -27,26 +36,64 @@ func GetDingTalkTemplatesResolver(p graphql.ResolveParams) (interface{}, error)
 }
 
 func GetDingTalkReportsResolver(p graphql.ResolveParams) (interface{}, error) {
-	userID, ok := auth.GetUserOpenID(p.Context)
-	if !ok {
-		return nil, fmt.Errorf("unauthorized")
+	userID, err := currentUserID(p, auth.PlatformDingTalk)
+	if err != nil {
+		return nil, err
 	}
 	templateName, _ := p.Args["template_name"].(string)
 	startTime, _ := p.Args["start_time"].(int)
 	endTime, _ := p.Args["end_time"].(int)
 	cursor, _ := p.Args["cursor"].(int)
 	size, _ := p.Args["size"].(int)
-	reports, err := dingtalkReportService.GetReports(userID, templateName, int64(startTime), int64(endTime), cursor, size)
+	reports, err := dingtalkReportService.WithContext(p.Context).GetReports(userID, templateName, int64(startTime), int64(endTime), cursor, size)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 	return reports.Result, nil
 }
 
+// GetAllDingTalkReportsResolver 返回时间范围内的全部日志，由服务端完成翻页
+func GetAllDingTalkReportsResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, err := currentUserID(p, auth.PlatformDingTalk)
+	if err != nil {
+		return nil, err
+	}
+	templateName, _ := p.Args["template_name"].(string)
+	startTime, _ := p.Args["start_time"].(int)
+	endTime, _ := p.Args["end_time"].(int)
+	reports, err := dingtalkReportService.WithContext(p.Context).ListAllReports(userID, templateName, int64(startTime), int64(endTime))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return reports, nil
+}
+
+// GetTeamReportsResolver 返回部门成员在时间范围内的日志及未提交成员
+func GetTeamReportsResolver(p graphql.ResolveParams) (interface{}, error) {
+	if _, err := currentUserID(p, auth.PlatformDingTalk); err != nil {
+		return nil, err
+	}
+	deptIDArg, _ := p.Args["dept_id"].(string)
+	deptID, err := strconv.ParseInt(deptIDArg, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dept_id: %s", deptIDArg)
+	}
+	templateName, _ := p.Args["template_name"].(string)
+	startTime, _ := p.Args["start_time"].(int)
+	endTime, _ := p.Args["end_time"].(int)
+	team, err := dingtalkTeamService.WithContext(p.Context).GetTeamReports(deptID, templateName, int64(startTime), int64(endTime))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return team, nil
+}
+
 var dingtalkReportService *dingtalk.ReportService
+var dingtalkTeamService *dingtalk.TeamService
 
-func InitDingTalkResolvers(service *dingtalk.ReportService) {
+func InitDingTalkResolvers(service *dingtalk.ReportService, teamService *dingtalk.TeamService) {
 	dingtalkReportService = service
+	dingtalkTeamService = teamService
 	types.TemplateType.AddFieldConfig("detail", &graphql.Field{
 		Type: types.TemplateDetailType,
 		Args: graphql.FieldConfigArgument{
@@ -54,16 +101,20 @@ func InitDingTalkResolvers(service *dingtalk.ReportService) {
 				Type: graphql.NewNonNull(graphql.String),
 			},
 		},
-		Resolve: GetTemplateDetailResolver,
+		Resolve: RequireUserScope(auth.PlatformDingTalk, "userId", GetTemplateDetailResolver),
 	})
 }
 
+// GetTemplateDetailResolver 返回 userId 可见的模板详情，只允许钉钉用户查询
 func GetTemplateDetailResolver(p graphql.ResolveParams) (interface{}, error) {
+	if _, err := currentUserID(p, auth.PlatformDingTalk); err != nil {
+		return nil, err
+	}
 	template, _ := p.Source.(dingtalk.TemplateItem)
 	userId, _ := p.Args["userId"].(string)
-	templateDetail, err := dingtalkReportService.GetTemplateDetail(userId, template.Name)
+	templateDetail, err := dingtalkReportService.WithContext(p.Context).GetTemplateDetail(userId, template.Name)
 	if err != nil {
-		return nil, err
+		return nil, wrapError(err)
 	}
 	return templateDetail.Result, nil
 }
@@ -0,0 +1,138 @@
+package resolvers
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// reportProviders 按平台名称（auth.PlatformDingTalk 等）索引的日志服务
+var reportProviders map[string]platform.ReportProvider
+
+func InitReportResolvers(providers map[string]platform.ReportProvider) {
+	reportProviders = providers
+}
+
+// currentProvider 返回当前登录用户的ID及其所在平台的日志服务
+func currentProvider(p graphql.ResolveParams) (string, platform.ReportProvider, error) {
+	userID, ok := auth.GetPlatformUserID(p.Context)
+	if !ok {
+		return "", nil, fmt.Errorf("unauthorized")
+	}
+	userPlatform := auth.GetUserPlatform(p.Context)
+	provider, ok := reportProviders[userPlatform]
+	if !ok {
+		return "", nil, &extendedError{
+			message: fmt.Sprintf("platform %s is not supported", userPlatform),
+			extensions: map[string]interface{}{
+				"code":     errorCodeNotSupported,
+				"platform": userPlatform,
+			},
+		}
+	}
+	return userID, provider, nil
+}
+
+// GetTemplatesResolver 返回当前用户可用的日志模板
+func GetTemplatesResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, provider, err := currentProvider(p)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := provider.ListTemplates(p.Context, userID)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return templates, nil
+}
+
+// GetTemplateResolver 按名称返回模板详情
+func GetTemplateResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, provider, err := currentProvider(p)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := p.Args["name"].(string)
+	template, err := provider.GetTemplate(p.Context, userID, name)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return template, nil
+}
+
+// GetReportsResolver 返回当前用户在时间范围内提交的全部日志
+func GetReportsResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, provider, err := currentProvider(p)
+	if err != nil {
+		return nil, err
+	}
+	templateName, _ := p.Args["template_name"].(string)
+	startTime, _ := p.Args["start_time"].(int)
+	endTime, _ := p.Args["end_time"].(int)
+	reports, err := provider.ListReports(p.Context, userID, templateName, int64(startTime), int64(endTime))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return reports, nil
+}
+
+// CreateReportResolver 在当前用户所在平台创建并发送日志
+func CreateReportResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, provider, err := currentProvider(p)
+	if err != nil {
+		return nil, err
+	}
+	report, err := provider.CreateReport(p.Context, userID, createReportArgs(p.Args))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return report, nil
+}
+
+// SaveReportDraftResolver 在当前用户所在平台保存日志草稿，返回草稿ID
+func SaveReportDraftResolver(p graphql.ResolveParams) (interface{}, error) {
+	userID, provider, err := currentProvider(p)
+	if err != nil {
+		return nil, err
+	}
+	draftID, err := provider.SaveDraft(p.Context, userID, createReportArgs(p.Args))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return draftID, nil
+}
+
+// createReportArgs 将 GraphQL 参数转换为创建日志的请求
+func createReportArgs(args map[string]interface{}) platform.CreateReportRequest {
+	req := platform.CreateReportRequest{
+		ToUserIDs: stringArgs(args["to_userids"]),
+		ToChatIDs: stringArgs(args["to_cids"]),
+	}
+	req.TemplateID, _ = args["template_id"].(string)
+	req.TemplateName, _ = args["template_name"].(string)
+	req.ToChat, _ = args["to_chat"].(bool)
+	req.UseTemplateDefaults, _ = args["use_template_defaults"].(bool)
+
+	contents, _ := args["contents"].([]interface{})
+	for _, c := range contents {
+		contentMap, _ := c.(map[string]interface{})
+		key, _ := contentMap["key"].(string)
+		value, _ := contentMap["value"].(string)
+		req.Contents = append(req.Contents, platform.Content{Key: key, Value: value})
+	}
+	return req
+}
+
+// stringArgs 将 GraphQL 列表参数转换为字符串切片
+func stringArgs(arg interface{}) []string {
+	list, _ := arg.([]interface{})
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if value, ok := item.(string); ok {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,407 @@
+package resolvers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	reportgraphql "github.com/hellodeveye/report/graphql"
+	"github.com/hellodeveye/report/graphql/resolvers"
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/authz"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+	"github.com/hellodeveye/report/pkg/feishu"
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/platform"
+	"github.com/hellodeveye/report/pkg/profile"
+	"github.com/hellodeveye/report/pkg/wecom"
+)
+
+// testEnv 基于模拟钉钉服务搭建的完整 GraphQL schema
+type testEnv struct {
+	dingtalk *dingtalktest.Server
+	schema   *graphql.Schema
+	denials  []authz.Denial
+}
+
+// wecomAdmin 白名单中的企业微信管理员，userid 与钉钉用户 outsider 相同
+var wecomAdmin = auth.NewPrincipal(auth.PlatformWeCom, &models.User{UserID: "outsider", CorpID: "wecom-corp"})
+
+// newTestEnv 启动模拟钉钉服务并注册以下组织关系：
+// admin 为管理员；lead 主管部门 100，同时在部门 300；member 在部门 100；outsider 在部门 200；
+// 另有白名单中的企业微信管理员 wecomAdmin
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	server := dingtalktest.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddUser(dingtalktest.User{UserID: "admin", Name: "管理员", DeptIDs: []int64{1}, Admin: true})
+	server.AddUser(dingtalktest.User{UserID: "lead", Name: "主管", DeptIDs: []int64{100, 300}, LeaderDeptIDs: []int64{100}})
+	server.AddUser(dingtalktest.User{UserID: "member", Name: "成员", DeptIDs: []int64{100}, ManagerUserID: "lead"})
+	server.AddUser(dingtalktest.User{UserID: "outsider", Name: "外部", DeptIDs: []int64{200}})
+
+	env := &testEnv{dingtalk: server}
+	dingtalkClient := dingtalk.NewClient(server.Config())
+	authorizer := authz.NewAuthorizer(map[string]platform.RoleProvider{
+		auth.PlatformDingTalk: dingtalk.NewRoleProvider(dingtalkClient),
+	}, []string{wecomAdmin.Subject}, time.Minute, func(ctx context.Context, denial authz.Denial) {
+		env.denials = append(env.denials, denial)
+	})
+	env.schema = reportgraphql.SetupGraphQLSchema(
+		dingtalkClient,
+		feishu.NewClient(&models.FeishuConfig{}),
+		wecom.NewClient(&models.WeComConfig{}),
+		llm.NewOpenAIProvider(&models.LLMConfig{}),
+		storage.NewMemoryStore(),
+		profile.NewService(nil, time.Minute),
+		authorizer,
+	)
+	return env
+}
+
+// execute 以 principal 的身份执行查询，principal 为 nil 时不携带登录信息
+func (e *testEnv) execute(principal *auth.Principal, query string) *graphql.Result {
+	ctx := context.Background()
+	if principal != nil {
+		ctx = auth.WithPrincipal(ctx, principal)
+	}
+	return graphql.Do(graphql.Params{Schema: *e.schema, RequestString: query, Context: ctx})
+}
+
+func dingtalkUser(userID string) *auth.Principal {
+	return auth.NewPrincipal(auth.PlatformDingTalk, &models.User{UserID: userID, CorpID: dingtalktest.CorpID})
+}
+
+// errorCode 返回第一个错误 extensions 中的 code，没有错误时返回空字符串
+func errorCode(t *testing.T, result *graphql.Result) string {
+	t.Helper()
+	if len(result.Errors) == 0 {
+		return ""
+	}
+	code, _ := result.Errors[0].Extensions["code"].(string)
+	if code == "" {
+		t.Fatalf("error without code: %v", result.Errors[0])
+	}
+	return code
+}
+
+func TestTeamReportsRequiresDepartmentScope(t *testing.T) {
+	env := newTestEnv(t)
+	now := time.Now()
+	env.dingtalk.AddReport(dingtalk.ReportData{ReportID: "r1", CreatorID: "member", TemplateName: "日报", CreateTime: now.Add(-time.Hour).UnixMilli()})
+
+	query := func(deptID string) string {
+		return fmt.Sprintf(`{ teamReports(dept_id: %q, template_name: "日报", start_time: %d, end_time: %d) { dept_id members { user_id } missing { userid } } }`,
+			deptID, now.Add(-24*time.Hour).Unix(), now.Unix())
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		deptID  string
+		allowed bool
+	}{
+		{"lead reads led department", "lead", "100", true},
+		{"admin reads any department", "admin", "200", true},
+		{"lead reads department they only belong to", "lead", "300", false},
+		{"member reads own department", "member", "100", false},
+		{"outsider reads other department", "outsider", "100", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(env.denials)
+			result := env.execute(dingtalkUser(tt.userID), query(tt.deptID))
+			if tt.allowed {
+				if len(result.Errors) > 0 {
+					t.Fatalf("expected allowed, got %v", result.Errors)
+				}
+				return
+			}
+			if code := errorCode(t, result); code != "FORBIDDEN" {
+				t.Fatalf("expected FORBIDDEN, got %q", code)
+			}
+			if team := result.Data.(map[string]interface{})["teamReports"]; team != nil {
+				t.Fatalf("forbidden query returned data: %v", team)
+			}
+			if len(env.denials) != before+1 || env.denials[before].Target != "dept:"+tt.deptID {
+				t.Fatalf("expected denial to be audited, got %+v", env.denials[before:])
+			}
+		})
+	}
+
+	result := env.execute(dingtalkUser("lead"), query("100"))
+	team := result.Data.(map[string]interface{})["teamReports"].(map[string]interface{})
+	members := team["members"].([]interface{})
+	if len(members) != 1 || members[0].(map[string]interface{})["user_id"] != "member" {
+		t.Fatalf("unexpected members: %v", members)
+	}
+}
+
+func TestRequireUserScope(t *testing.T) {
+	env := newTestEnv(t)
+	env.dingtalk.AddTemplate(dingtalk.TemplateDetailResult{ID: "tpl-1", Name: "日报", Fields: []dingtalk.Field{{FieldName: "今日工作"}}})
+
+	tests := []struct {
+		name    string
+		userID  string
+		query   string
+		allowed bool
+	}{
+		{"current user by default", "member", `{ dingtalkTemplates { name } }`, true},
+		{"self access", "member", `{ dingtalkTemplates(userId: "member") { name } }`, true},
+		{"member reads lead", "member", `{ dingtalkTemplates(userId: "lead") { name } }`, false},
+		{"lead reads led department member", "lead", `{ dingtalkTemplates(userId: "member") { name } }`, true},
+		{"lead reads user outside led departments", "lead", `{ dingtalkTemplates(userId: "outsider") { name } }`, false},
+		{"admin reads anyone", "admin", `{ dingtalkTemplates(userId: "outsider") { name } }`, true},
+		{"nested detail for self", "member", `{ dingtalkTemplates { detail(userId: "member") { id fields { fieldName } } } }`, true},
+		{"nested detail outside led departments", "lead", `{ dingtalkTemplates { detail(userId: "outsider") { id } } }`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := env.execute(dingtalkUser(tt.userID), tt.query)
+			if tt.allowed {
+				if len(result.Errors) > 0 {
+					t.Fatalf("expected allowed, got %v", result.Errors)
+				}
+				return
+			}
+			if code := errorCode(t, result); code != "FORBIDDEN" {
+				t.Fatalf("expected FORBIDDEN, got %q", code)
+			}
+		})
+	}
+}
+
+func TestScopeRequiresPrincipal(t *testing.T) {
+	env := newTestEnv(t)
+
+	for _, query := range []string{
+		`{ dingtalkTemplates(userId: "member") { name } }`,
+		`{ teamReports(dept_id: "100", template_name: "日报", start_time: 0, end_time: 1) { dept_id } }`,
+		`{ wecomJournalStats(template_id: "tpl-1", start_time: 0, end_time: 1) { template_id } }`,
+	} {
+		result := env.execute(nil, query)
+		if len(result.Errors) != 1 || result.Errors[0].Message != "unauthorized" {
+			t.Fatalf("%s: expected unauthorized, got %v", query, result.Errors)
+		}
+	}
+	if len(env.denials) != 0 {
+		t.Fatalf("missing principal should not reach the authorizer, got %+v", env.denials)
+	}
+}
+
+func TestPlatformMismatch(t *testing.T) {
+	env := newTestEnv(t)
+	feishuUser := auth.NewPrincipal(auth.PlatformFeishu, &models.User{OpenID: "ou_1"})
+
+	result := env.execute(feishuUser, `{ allDingtalkReports(template_name: "日报", start_time: 0, end_time: 1) { report_id } }`)
+	if code := errorCode(t, result); code != "PLATFORM_MISMATCH" {
+		t.Fatalf("expected PLATFORM_MISMATCH, got %q", code)
+	}
+	if platform := result.Errors[0].Extensions["platform"]; platform != auth.PlatformFeishu {
+		t.Fatalf("unexpected platform extension: %v", platform)
+	}
+}
+
+func TestDingTalkTemplatesRejectOtherPlatforms(t *testing.T) {
+	env := newTestEnv(t)
+	env.dingtalk.AddTemplate(dingtalk.TemplateDetailResult{ID: "tpl-1", Name: "日报"})
+	wecomUser := auth.NewPrincipal(auth.PlatformWeCom, &models.User{UserID: "member", CorpID: "wecom-corp"})
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		query     string
+	}{
+		{"wecom admin reads dingtalk user", wecomAdmin, `{ dingtalkTemplates(userId: "member") { name } }`},
+		{"wecom admin reads same userid", wecomAdmin, `{ dingtalkTemplates(userId: "outsider") { name } }`},
+		{"wecom user reads same userid", wecomUser, `{ dingtalkTemplates(userId: "member") { name } }`},
+		{"wecom user reads own templates", wecomUser, `{ dingtalkTemplates { name detail(userId: "member") { id } } }`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := env.execute(tt.principal, tt.query)
+			if code := errorCode(t, result); code != "PLATFORM_MISMATCH" {
+				t.Fatalf("expected PLATFORM_MISMATCH, got %q", code)
+			}
+			if templates := result.Data.(map[string]interface{})["dingtalkTemplates"]; templates != nil {
+				t.Fatalf("rejected query returned data: %v", templates)
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	now := time.Now()
+	reportsQuery := fmt.Sprintf(`{ allDingtalkReports(template_name: "日报", start_time: %d, end_time: %d) { report_id } }`,
+		now.Add(-24*time.Hour).UnixMilli(), now.UnixMilli())
+	summaryMutation := fmt.Sprintf(`mutation { generateSummary(template_name: "日报", start_time: %d, end_time: %d, target_template: "周报") { template_name } }`,
+		now.Add(-24*time.Hour).Unix(), now.Unix())
+	wecomUser := auth.NewPrincipal(auth.PlatformWeCom, &models.User{UserID: "zhangsan", CorpID: "wecom-corp"})
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		setup     func(env *testEnv)
+		query     string
+		code      string
+	}{
+		{
+			name:      "rate limited",
+			principal: dingtalkUser("member"),
+			setup: func(env *testEnv) {
+				env.dingtalk.InjectError("/topapi/report/list", 90018, "too many requests")
+			},
+			query: reportsQuery,
+			code:  "RATE_LIMITED",
+		},
+		{
+			name:      "permission denied",
+			principal: dingtalkUser("member"),
+			setup: func(env *testEnv) {
+				env.dingtalk.InjectError("/topapi/report/list", 88, "no permission")
+			},
+			query: reportsQuery,
+			code:  "PERMISSION_DENIED",
+		},
+		{
+			name:      "other dingtalk error",
+			principal: dingtalkUser("member"),
+			setup: func(env *testEnv) {
+				env.dingtalk.InjectError("/topapi/report/list", 400002, "invalid param")
+			},
+			query: reportsQuery,
+			code:  "DINGTALK_API_ERROR",
+		},
+		{
+			name:      "not supported",
+			principal: wecomUser,
+			query:     `{ templates { name } }`,
+			code:      "NOT_SUPPORTED",
+		},
+		{
+			name:      "no source reports",
+			principal: dingtalkUser("member"),
+			query:     summaryMutation,
+			code:      "NO_SOURCE_REPORTS",
+		},
+		{
+			name:      "llm not configured",
+			principal: dingtalkUser("member"),
+			setup: func(env *testEnv) {
+				env.dingtalk.AddTemplate(dingtalk.TemplateDetailResult{ID: "tpl-2", Name: "周报"})
+				env.dingtalk.AddReport(dingtalk.ReportData{ReportID: "r1", CreatorID: "member", TemplateName: "日报", CreateTime: now.Add(-time.Hour).UnixMilli()})
+			},
+			query: summaryMutation,
+			code:  "LLM_NOT_CONFIGURED",
+		},
+		{
+			name:      "draft not found",
+			principal: dingtalkUser("member"),
+			query:     `mutation { deleteDraft(template_id: "tpl-1") }`,
+			code:      "NOT_FOUND",
+		},
+		{
+			name:      "draft version conflict",
+			principal: dingtalkUser("member"),
+			query:     `mutation { saveDraft(template_id: "tpl-1", content: "{}", version: 3) { version } }`,
+			code:      "VERSION_CONFLICT",
+		},
+		{
+			name:      "invalid argument",
+			principal: dingtalkUser("member"),
+			setup: func(env *testEnv) {
+				env.dingtalk.AddTemplate(dingtalk.TemplateDetailResult{ID: "tpl-1", Name: "日报"})
+			},
+			query: `mutation { createReport(template_name: "日报", contents: [], to_cids: [""]) { id } }`,
+			code:  "INVALID_ARGUMENT",
+		},
+		{
+			name:      "organization scope",
+			principal: dingtalkUser("lead"),
+			query:     `{ wecomJournalStats(template_id: "tpl-1", start_time: 0, end_time: 1) { template_id } }`,
+			code:      "FORBIDDEN",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t)
+			if tt.setup != nil {
+				tt.setup(env)
+			}
+			result := env.execute(tt.principal, tt.query)
+			if code := errorCode(t, result); code != tt.code {
+				t.Fatalf("expected %s, got %q: %v", tt.code, code, result.Errors)
+			}
+		})
+	}
+
+	// 无效接收人在 extensions 中列出，便于前端提示
+	env := newTestEnv(t)
+	env.dingtalk.AddTemplate(dingtalk.TemplateDetailResult{ID: "tpl-1", Name: "日报"})
+	result := env.execute(dingtalkUser("member"), `mutation { createReport(template_name: "日报", template_id: "tpl-1", contents: [], to_userids: ["lead", "ghost"]) { id } }`)
+	if code := errorCode(t, result); code != "INVALID_RECIPIENTS" {
+		t.Fatalf("expected INVALID_RECIPIENTS, got %q: %v", code, result.Errors)
+	}
+	if invalid := fmt.Sprint(result.Errors[0].Extensions["invalid_userids"]); invalid != "[ghost]" {
+		t.Fatalf("unexpected invalid_userids: %s", invalid)
+	}
+
+	// 钉钉接口错误携带 errcode 与 request_id 便于排查
+	env = newTestEnv(t)
+	env.dingtalk.InjectError("/topapi/report/list", 90018, "too many requests")
+	extensions := env.execute(dingtalkUser("member"), reportsQuery).Errors[0].Extensions
+	if extensions["errcode"] != 90018 || extensions["endpoint"] != "/topapi/report/list" || extensions["request_id"] == "" {
+		t.Fatalf("unexpected extensions: %v", extensions)
+	}
+}
+
+// failingDraftStore 所有操作都返回数据库错误
+type failingDraftStore struct {
+	storage.DraftStore
+}
+
+var errDatabase = errors.New("sqlite: database is locked (SQLITE_BUSY) at /data/report.db")
+
+func (failingDraftStore) ListDrafts(context.Context, string) ([]storage.Draft, error) {
+	return nil, errDatabase
+}
+
+func (failingDraftStore) GetDraft(context.Context, string, string) (*storage.Draft, error) {
+	return nil, errDatabase
+}
+
+func (failingDraftStore) SaveDraft(context.Context, string, string, string, int) (*storage.Draft, error) {
+	return nil, errDatabase
+}
+
+func (failingDraftStore) DeleteDraft(context.Context, string, string) error {
+	return errDatabase
+}
+
+func TestDraftStorageErrorsAreNotExposed(t *testing.T) {
+	env := newTestEnv(t)
+	resolvers.InitDraftResolvers(failingDraftStore{})
+
+	for _, query := range []string{
+		`{ drafts { template_id } }`,
+		`{ drafts(template_id: "tpl-1") { template_id } }`,
+		`mutation { saveDraft(template_id: "tpl-1", content: "{}") { version } }`,
+		`mutation { deleteDraft(template_id: "tpl-1") }`,
+	} {
+		result := env.execute(dingtalkUser("member"), query)
+		if code := errorCode(t, result); code != "INTERNAL_ERROR" {
+			t.Fatalf("%s: expected INTERNAL_ERROR, got %q", query, code)
+		}
+		if message := result.Errors[0].Message; strings.Contains(message, "sqlite") {
+			t.Fatalf("%s: storage error leaked: %s", query, message)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package resolvers
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/profile"
+)
+
+var profileService *profile.Service
+
+func InitUserResolvers(service *profile.Service) {
+	profileService = service
+}
+
+// GetMeResolver 返回当前登录用户的资料
+func GetMeResolver(p graphql.ResolveParams) (interface{}, error) {
+	principal, ok := auth.GetPrincipal(p.Context)
+	if !ok {
+		return nil, fmt.Errorf("unauthorized")
+	}
+	me, err := profileService.Get(p.Context, principal)
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return me, nil
+}
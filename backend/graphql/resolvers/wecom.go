@@ -0,0 +1,29 @@
+package resolvers
+
+import (
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/wecom"
+)
+
+var wecomJournalService *wecom.JournalService
+
+func InitWeComResolvers(service *wecom.JournalService) {
+	wecomJournalService = service
+}
+
+// GetWeComJournalStatsResolver 返回企业微信汇报模板在时间范围内各统计周期的提交统计，
+// 统计覆盖全企业成员，需经 RequireOrganizationScope 限制为管理员
+func GetWeComJournalStatsResolver(p graphql.ResolveParams) (interface{}, error) {
+	if _, err := currentUserID(p, auth.PlatformWeCom); err != nil {
+		return nil, err
+	}
+	templateID, _ := p.Args["template_id"].(string)
+	startTime, _ := p.Args["start_time"].(int)
+	endTime, _ := p.Args["end_time"].(int)
+	stats, err := wecomJournalService.WithContext(p.Context).GetStatList(templateID, int64(startTime), int64(endTime))
+	if err != nil {
+		return nil, wrapError(err)
+	}
+	return stats.StatList, nil
+}
@@ -6,27 +6,68 @@ import (
 	"github.com/graphql-go/graphql"
 	"github.com/hellodeveye/report/graphql/resolvers"
 	"github.com/hellodeveye/report/graphql/types"
-	"github.com/hellodeveye/report/internal/config"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/authz"
 	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/feishu"
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/platform"
+	"github.com/hellodeveye/report/pkg/profile"
+	"github.com/hellodeveye/report/pkg/summary"
+	"github.com/hellodeveye/report/pkg/wecom"
 )
 
-func SetupGraphQLSchema() *graphql.Schema {
+func SetupGraphQLSchema(dingtalkClient *dingtalk.Client, feishuClient *feishu.Client, wecomClient *wecom.Client, llmProvider llm.Provider, draftStore storage.DraftStore, profiles *profile.Service, authorizer *authz.Authorizer) *graphql.Schema {
 	// DingTalk Services
-	dingTalkConfig := config.GetDingTalkConfig()
-	dingtalkClient := dingtalk.NewClient(dingTalkConfig)
 	dingtalkReportService := dingtalk.NewReportService(dingtalkClient)
 
 	// Initialize resolvers
-	resolvers.InitDingTalkResolvers(dingtalkReportService)
+	resolvers.InitDingTalkResolvers(dingtalkReportService, dingtalk.NewTeamService(dingtalkClient))
+	resolvers.InitReportResolvers(map[string]platform.ReportProvider{
+		auth.PlatformDingTalk: dingtalk.NewReportProvider(dingtalkClient),
+		auth.PlatformFeishu:   feishu.NewReportProvider(feishuClient),
+		auth.PlatformWeCom:    wecom.NewReportProvider(wecomClient),
+	})
+	resolvers.InitWeComResolvers(wecom.NewJournalService(wecomClient))
+	resolvers.InitFeishuResolvers(feishu.NewReportService(feishuClient))
+	resolvers.InitSummaryResolvers(summary.NewGenerator(llmProvider))
+	resolvers.InitDraftResolvers(draftStore)
+	resolvers.InitUserResolvers(profiles)
+	resolvers.InitAuthzResolvers(authorizer)
 
 	rootQuery := graphql.ObjectConfig{Name: "RootQuery", Fields: graphql.Fields{
+		"me": &graphql.Field{
+			Type:    types.UserProfileType,
+			Resolve: resolvers.GetMeResolver,
+		},
+		"templates": &graphql.Field{
+			Type:    graphql.NewList(types.ReportTemplateType),
+			Resolve: resolvers.GetTemplatesResolver,
+		},
+		"template": &graphql.Field{
+			Type: types.ReportTemplateType,
+			Args: graphql.FieldConfigArgument{
+				"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+			},
+			Resolve: resolvers.GetTemplateResolver,
+		},
+		"reports": &graphql.Field{
+			Type: graphql.NewList(types.PlatformReportType),
+			Args: graphql.FieldConfigArgument{
+				"template_name": &graphql.ArgumentConfig{Type: graphql.String},
+				"start_time":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"end_time":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: resolvers.GetReportsResolver,
+		},
 		"dingtalkTemplates": &graphql.Field{
 			Type: graphql.NewList(types.TemplateType),
 			Args: graphql.FieldConfigArgument{
 				"userId": &graphql.ArgumentConfig{Type: graphql.String},
 				"name":   &graphql.ArgumentConfig{Type: graphql.String},
 			},
-			Resolve: resolvers.GetDingTalkTemplatesResolver,
+			Resolve: resolvers.RequireUserScope(auth.PlatformDingTalk, "userId", resolvers.GetDingTalkTemplatesResolver),
 		},
 		"dingtalkReports": &graphql.Field{
 			Type: types.ReportListType,
@@ -39,19 +80,124 @@ func SetupGraphQLSchema() *graphql.Schema {
 			},
 			Resolve: resolvers.GetDingTalkReportsResolver,
 		},
+		"allDingtalkReports": &graphql.Field{
+			Type: graphql.NewList(types.ReportType),
+			Args: graphql.FieldConfigArgument{
+				"template_name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"start_time":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"end_time":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: resolvers.GetAllDingTalkReportsResolver,
+		},
+		"teamReports": &graphql.Field{
+			Type: types.TeamReportsType,
+			Args: graphql.FieldConfigArgument{
+				"dept_id":       &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"template_name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"start_time":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"end_time":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: resolvers.RequireDepartmentScope("dept_id", resolvers.GetTeamReportsResolver),
+		},
+		"feishuReports": &graphql.Field{
+			Type: graphql.NewList(types.FeishuReportType),
+			Args: graphql.FieldConfigArgument{
+				"rule_name":  &graphql.ArgumentConfig{Type: graphql.String},
+				"start_time": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"end_time":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: resolvers.GetFeishuReportsResolver,
+		},
+		"wecomJournalStats": &graphql.Field{
+			Type: graphql.NewList(types.JournalStatType),
+			Args: graphql.FieldConfigArgument{
+				"template_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				"start_time":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				"end_time":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+			},
+			Resolve: resolvers.RequireOrganizationScope(resolvers.GetWeComJournalStatsResolver),
+		},
+		"drafts": &graphql.Field{
+			Type: graphql.NewList(types.DraftType),
+			Args: graphql.FieldConfigArgument{
+				"template_id": &graphql.ArgumentConfig{Type: graphql.String},
+			},
+			Resolve: resolvers.GetDraftsResolver,
+		},
 	}}
 
 	rootMutation := graphql.NewObject(graphql.ObjectConfig{
 		Name: "RootMutation",
 		Fields: graphql.Fields{
+			"createReport": &graphql.Field{
+				Type: types.PlatformReportType,
+				Args: graphql.FieldConfigArgument{
+					"template_name":         &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"template_id":           &graphql.ArgumentConfig{Type: graphql.String},
+					"contents":              &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(types.ReportContentInputType))},
+					"to_userids":            &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					"to_cids":               &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					"to_chat":               &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+					"use_template_defaults": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true},
+				},
+				Resolve: resolvers.CreateReportResolver,
+			},
+			"saveReportDraft": &graphql.Field{
+				Type: graphql.String,
+				Args: graphql.FieldConfigArgument{
+					"template_name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"template_id":   &graphql.ArgumentConfig{Type: graphql.String},
+					"contents":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(types.ReportContentInputType))},
+				},
+				Resolve: resolvers.SaveReportDraftResolver,
+			},
 			"createDingtalkReport": &graphql.Field{
 				Type: types.ReportType,
+				Args: graphql.FieldConfigArgument{
+					"template_name":         &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"template_id":           &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"contents":              &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(types.ReportContentInputType))},
+					"to_userids":            &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					"to_cids":               &graphql.ArgumentConfig{Type: graphql.NewList(graphql.String)},
+					"to_chat":               &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+					"use_template_defaults": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: true},
+				},
+				Resolve: resolvers.CreateDingTalkReportResolver,
+			},
+			"saveDingtalkDraft": &graphql.Field{
+				Type: types.DingTalkDraftType,
 				Args: graphql.FieldConfigArgument{
 					"template_name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
 					"template_id":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
 					"contents":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(types.ReportContentInputType))},
 				},
-				Resolve: resolvers.CreateDingTalkReportResolver,
+				Resolve: resolvers.SaveDingTalkDraftResolver,
+			},
+			"generateSummary": &graphql.Field{
+				Type: types.SummaryDraftType,
+				Args: graphql.FieldConfigArgument{
+					"template_name":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"start_time":      &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"end_time":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"target_template": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolvers.GenerateSummaryResolver,
+			},
+			"saveDraft": &graphql.Field{
+				Type: types.DraftType,
+				Args: graphql.FieldConfigArgument{
+					"template_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"content":     &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"version":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: resolvers.SaveDraftResolver,
+			},
+			"deleteDraft": &graphql.Field{
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"template_id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: resolvers.DeleteDraftResolver,
 			},
 		},
 	})
@@ -64,5 +210,6 @@ func SetupGraphQLSchema() *graphql.Schema {
 	if err != nil {
 		log.Fatalf("failed to create new schema, error: %v", err)
 	}
+	traceResolvers(&schema)
 	return &schema
 }
@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/hellodeveye/report/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceResolvers 为 schema 中自定义了 Resolve 的字段创建 span（如 Query.reports），
+// span 挂在 HTTP 请求的 span 之下，解析器内调用钉钉等接口的 span 又挂在它之下。
+// 使用默认解析器的普通字段与内省类型不创建 span
+func traceResolvers(schema *graphql.Schema) {
+	for typeName, t := range schema.TypeMap() {
+		object, ok := t.(*graphql.Object)
+		if !ok || strings.HasPrefix(typeName, "__") {
+			continue
+		}
+		for fieldName, field := range object.Fields() {
+			if field.Resolve == nil {
+				continue
+			}
+			field.Resolve = traceResolve(typeName+"."+fieldName, field.Resolve)
+		}
+	}
+}
+
+func traceResolve(name string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		ctx, span := tracing.Tracer().Start(p.Context, "graphql "+name,
+			trace.WithAttributes(attribute.String("graphql.field", name)))
+		defer span.End()
+
+		p.Context = ctx
+		result, err := resolve(p)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return result, err
+	}
+}
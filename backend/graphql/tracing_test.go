@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceResolversCreatesSpanPerResolver(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	var resolverSpan trace.SpanContext
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "Query",
+			Fields: graphql.Fields{
+				"hello": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						resolverSpan = trace.SpanContextFromContext(p.Context)
+						return "world", nil
+					},
+				},
+				"broken": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return nil, errors.New("boom")
+					},
+				},
+			},
+		}),
+	})
+	if err != nil {
+		t.Fatalf("NewSchema failed: %v", err)
+	}
+	traceResolvers(&schema)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "POST /api/graphql")
+	graphql.Do(graphql.Params{Schema: schema, RequestString: "{ hello broken __typename }", Context: ctx})
+	parent.End()
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected parent and two resolver spans, got %v", spans)
+	}
+	hello, ok := spans["graphql Query.hello"]
+	if !ok || hello.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("resolver span should be a child of the request span")
+	}
+	if resolverSpan.SpanID() != hello.SpanContext().SpanID() {
+		t.Fatal("resolver context should carry its own span")
+	}
+	if broken := spans["graphql Query.broken"]; broken == nil || broken.Status().Code != codes.Error {
+		t.Fatal("failed resolver span should have error status")
+	}
+}
@@ -68,6 +68,19 @@ var ReportType = graphql.NewObject(graphql.ObjectConfig{
 		"create_time":    &graphql.Field{Type: graphql.String},
 		"contents":       &graphql.Field{Type: graphql.NewList(ReportContentType)},
 		"read_user_list": &graphql.Field{Type: graphql.NewList(graphql.String)},
+		"to_chat":        &graphql.Field{Type: graphql.Boolean},
+		"receivers":      &graphql.Field{Type: graphql.NewList(ReceiverType)},
+		"received_convs": &graphql.Field{Type: graphql.NewList(ConversationType)},
+	},
+})
+
+// DingTalkDraftType 定义了钉钉日志草稿的GraphQL类型
+var DingTalkDraftType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "DingTalkDraft",
+	Fields: graphql.Fields{
+		"draft_id":      &graphql.Field{Type: graphql.String},
+		"template_id":   &graphql.Field{Type: graphql.String},
+		"template_name": &graphql.Field{Type: graphql.String},
 	},
 })
 
@@ -82,6 +95,37 @@ var ReportListType = graphql.NewObject(graphql.ObjectConfig{
 	},
 })
 
+// DeptMemberType 定义了钉钉部门成员的GraphQL类型
+var DeptMemberType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "DeptMember",
+	Fields: graphql.Fields{
+		"userid": &graphql.Field{Type: graphql.String},
+		"name":   &graphql.Field{Type: graphql.String},
+	},
+})
+
+// MemberReportsType 定义了单个成员日志的GraphQL类型
+var MemberReportsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "MemberReports",
+	Fields: graphql.Fields{
+		"user_id": &graphql.Field{Type: graphql.String},
+		"name":    &graphql.Field{Type: graphql.String},
+		"reports": &graphql.Field{Type: graphql.NewList(ReportType)},
+		// error 拉取该成员日志失败时的原因，成功时为空
+		"error": &graphql.Field{Type: graphql.String},
+	},
+})
+
+// TeamReportsType 定义了部门日志汇总的GraphQL类型
+var TeamReportsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TeamReports",
+	Fields: graphql.Fields{
+		"dept_id": &graphql.Field{Type: graphql.String},
+		"members": &graphql.Field{Type: graphql.NewList(MemberReportsType)},
+		"missing": &graphql.Field{Type: graphql.NewList(DeptMemberType)},
+	},
+})
+
 // TemplateType a a
 var TemplateType = graphql.NewObject(graphql.ObjectConfig{
 	Name: "Template",
@@ -0,0 +1,15 @@
+package types
+
+import "github.com/graphql-go/graphql"
+
+// DraftType 定义了服务端草稿的GraphQL类型，content 为前端表单值的JSON
+var DraftType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Draft",
+	Fields: graphql.Fields{
+		"template_id": &graphql.Field{Type: graphql.String},
+		"content":     &graphql.Field{Type: graphql.String},
+		"version":     &graphql.Field{Type: graphql.Int},
+		"created_at":  &graphql.Field{Type: graphql.DateTime},
+		"updated_at":  &graphql.Field{Type: graphql.DateTime},
+	},
+})
@@ -0,0 +1,27 @@
+package types
+
+import "github.com/graphql-go/graphql"
+
+// FeishuFormContentType 定义了飞书汇报表单内容的GraphQL类型
+var FeishuFormContentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "FeishuFormContent",
+	Fields: graphql.Fields{
+		"field_id":    &graphql.Field{Type: graphql.String},
+		"field_name":  &graphql.Field{Type: graphql.String},
+		"field_value": &graphql.Field{Type: graphql.String},
+	},
+})
+
+// FeishuReportType 定义了飞书汇报的GraphQL类型
+var FeishuReportType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "FeishuReport",
+	Fields: graphql.Fields{
+		"task_id":         &graphql.Field{Type: graphql.String},
+		"rule_name":       &graphql.Field{Type: graphql.String},
+		"from_user_id":    &graphql.Field{Type: graphql.String},
+		"from_user_name":  &graphql.Field{Type: graphql.String},
+		"department_name": &graphql.Field{Type: graphql.String},
+		"commit_time":     &graphql.Field{Type: graphql.Float},
+		"form_contents":   &graphql.Field{Type: graphql.NewList(FeishuFormContentType)},
+	},
+})
@@ -0,0 +1,52 @@
+package types
+
+import "github.com/graphql-go/graphql"
+
+// ReportFieldType 定义了平台无关的模板字段GraphQL类型
+var ReportFieldType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ReportField",
+	Fields: graphql.Fields{
+		"name": &graphql.Field{Type: graphql.String},
+		"sort": &graphql.Field{Type: graphql.Int},
+		"type": &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// RecipientType 定义了日志接收人或接收群的GraphQL类型
+var RecipientType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Recipient",
+	Fields: graphql.Fields{
+		"id":   &graphql.Field{Type: graphql.String},
+		"name": &graphql.Field{Type: graphql.String},
+	},
+})
+
+// ReportTemplateType 定义了平台无关的日志模板GraphQL类型
+var ReportTemplateType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ReportTemplate",
+	Fields: graphql.Fields{
+		"id":        &graphql.Field{Type: graphql.String},
+		"name":      &graphql.Field{Type: graphql.String},
+		"icon_url":  &graphql.Field{Type: graphql.String},
+		"fields":    &graphql.Field{Type: graphql.NewList(ReportFieldType)},
+		"receivers": &graphql.Field{Type: graphql.NewList(RecipientType)},
+		"chats":     &graphql.Field{Type: graphql.NewList(RecipientType)},
+	},
+})
+
+// PlatformReportType 定义了平台无关的日志GraphQL类型，create_time 为毫秒级时间戳
+var PlatformReportType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PlatformReport",
+	Fields: graphql.Fields{
+		"id":            &graphql.Field{Type: graphql.String},
+		"template_name": &graphql.Field{Type: graphql.String},
+		"creator_id":    &graphql.Field{Type: graphql.String},
+		"creator_name":  &graphql.Field{Type: graphql.String},
+		"dept_name":     &graphql.Field{Type: graphql.String},
+		"create_time":   &graphql.Field{Type: graphql.String},
+		"contents":      &graphql.Field{Type: graphql.NewList(ReportContentType)},
+		"to_chat":       &graphql.Field{Type: graphql.Boolean},
+		"receivers":     &graphql.Field{Type: graphql.NewList(RecipientType)},
+		"chats":         &graphql.Field{Type: graphql.NewList(RecipientType)},
+	},
+})
@@ -0,0 +1,25 @@
+package types
+
+import "github.com/graphql-go/graphql"
+
+// TokenUsageType 定义了大模型token用量的GraphQL类型
+var TokenUsageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TokenUsage",
+	Fields: graphql.Fields{
+		"prompt_tokens":     &graphql.Field{Type: graphql.Int},
+		"completion_tokens": &graphql.Field{Type: graphql.Int},
+		"total_tokens":      &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// SummaryDraftType 定义了AI汇总草稿的GraphQL类型，contents 与目标模板字段一一对应
+var SummaryDraftType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SummaryDraft",
+	Fields: graphql.Fields{
+		"template_id":   &graphql.Field{Type: graphql.String},
+		"template_name": &graphql.Field{Type: graphql.String},
+		"contents":      &graphql.Field{Type: graphql.NewList(ReportContentType)},
+		"source_count":  &graphql.Field{Type: graphql.Int},
+		"usage":         &graphql.Field{Type: TokenUsageType},
+	},
+})
@@ -0,0 +1,31 @@
+package types
+
+import "github.com/graphql-go/graphql"
+
+// DepartmentType 定义了部门的GraphQL类型
+var DepartmentType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Department",
+	Fields: graphql.Fields{
+		"id":   &graphql.Field{Type: graphql.String},
+		"name": &graphql.Field{Type: graphql.String},
+	},
+})
+
+// UserProfileType 定义了当前登录用户资料的GraphQL类型
+var UserProfileType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "UserProfile",
+	Fields: graphql.Fields{
+		"platform":    &graphql.Field{Type: graphql.String},
+		"userid":      &graphql.Field{Type: graphql.String},
+		"open_id":     &graphql.Field{Type: graphql.String},
+		"union_id":    &graphql.Field{Type: graphql.String},
+		"corp_id":     &graphql.Field{Type: graphql.String},
+		"name":        &graphql.Field{Type: graphql.String},
+		"avatar_url":  &graphql.Field{Type: graphql.String},
+		"email":       &graphql.Field{Type: graphql.String},
+		"mobile":      &graphql.Field{Type: graphql.String},
+		"title":       &graphql.Field{Type: graphql.String},
+		"departments": &graphql.Field{Type: graphql.NewList(DepartmentType)},
+		"manager":     &graphql.Field{Type: RecipientType},
+	},
+})
@@ -0,0 +1,44 @@
+package types
+
+import "github.com/graphql-go/graphql"
+
+// JournalUserType 定义了企业微信汇报成员的GraphQL类型
+var JournalUserType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "JournalUser",
+	Fields: graphql.Fields{
+		"userid": &graphql.Field{Type: graphql.String},
+	},
+})
+
+// JournalStatItemType 定义了企业微信统计周期内单条汇报的GraphQL类型
+var JournalStatItemType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "JournalStatItem",
+	Fields: graphql.Fields{
+		"journaluuid": &graphql.Field{Type: graphql.String},
+		"reporttime":  &graphql.Field{Type: graphql.Int},
+		"flag":        &graphql.Field{Type: graphql.Int},
+	},
+})
+
+// JournalStatUserType 定义了成员在统计周期内汇报情况的GraphQL类型
+var JournalStatUserType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "JournalStatUser",
+	Fields: graphql.Fields{
+		"user":     &graphql.Field{Type: JournalUserType},
+		"itemlist": &graphql.Field{Type: graphql.NewList(JournalStatItemType)},
+	},
+})
+
+// JournalStatType 定义了企业微信汇报模板统计的GraphQL类型
+var JournalStatType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "JournalStat",
+	Fields: graphql.Fields{
+		"template_id":      &graphql.Field{Type: graphql.String},
+		"template_name":    &graphql.Field{Type: graphql.String},
+		"report_type":      &graphql.Field{Type: graphql.Int},
+		"cycle_begin_time": &graphql.Field{Type: graphql.Int},
+		"cycle_end_time":   &graphql.Field{Type: graphql.Int},
+		"report_list":      &graphql.Field{Type: graphql.NewList(JournalStatUserType)},
+		"unreport_list":    &graphql.Field{Type: graphql.NewList(JournalStatUserType)},
+	},
+})
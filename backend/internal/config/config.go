@@ -1,31 +1,306 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/hellodeveye/report/internal/models"
+	"gopkg.in/yaml.v3"
 )
 
-// GetJWTSecret 获取JWT密钥
-func GetJWTSecret() string {
-	return getEnv("JWT_SECRET", "default-jwt-secret-change-in-production")
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// DefaultJWTSecret 未配置 JWT_SECRET 时使用的开发密钥，生产环境禁止使用
+const DefaultJWTSecret = "default-jwt-secret-change-in-production"
+
+// Config 服务完整配置，依次由默认值、配置文件、环境变量加载，后者覆盖前者
+type Config struct {
+	Server   models.ServerConfig   `yaml:"server" toml:"server"`
+	Log      models.LogConfig      `yaml:"log" toml:"log"`
+	Metrics  models.MetricsConfig  `yaml:"metrics" toml:"metrics"`
+	Tracing  models.TracingConfig  `yaml:"tracing" toml:"tracing"`
+	Auth     models.AuthConfig     `yaml:"auth" toml:"auth"`
+	CORS     models.CORSConfig     `yaml:"cors" toml:"cors"`
+	GraphQL  models.GraphQLConfig  `yaml:"graphql" toml:"graphql"`
+	DingTalk models.DingTalkConfig `yaml:"dingtalk" toml:"dingtalk"`
+	Feishu   models.FeishuConfig   `yaml:"feishu" toml:"feishu"`
+	WeCom    models.WeComConfig    `yaml:"wecom" toml:"wecom"`
+	LLM      models.LLMConfig      `yaml:"llm" toml:"llm"`
+	Storage  models.StorageConfig  `yaml:"storage" toml:"storage"`
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Server: models.ServerConfig{
+			Environment:       EnvDevelopment,
+			Port:              "8080",
+			FrontendURL:       "http://localhost:5173",
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      180 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			DrainDelay:        5 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Log: models.LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Metrics: models.MetricsConfig{
+			Enabled: true,
+			Addr:    ":9090",
+		},
+		Tracing: models.TracingConfig{
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "report-backend",
+			SampleRatio: 1,
+		},
+		Auth: models.AuthConfig{
+			JWTSecret:       DefaultJWTSecret,
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+			OAuthStateTTL:   10 * time.Minute,
+			ProfileCacheTTL: 10 * time.Minute,
+			RoleCacheTTL:    time.Minute,
+		},
+		CORS: models.CORSConfig{
+			MaxAge: 10 * time.Minute,
+		},
+		DingTalk: models.DingTalkConfig{
+			BaseURL:    "https://oapi.dingtalk.com",
+			APIBaseURL: "https://api.dingtalk.com",
+		},
+		Feishu: models.FeishuConfig{
+			BaseURL: "https://open.feishu.cn",
+		},
+		WeCom: models.WeComConfig{
+			BaseURL: "https://qyapi.weixin.qq.com",
+		},
+		LLM: models.LLMConfig{
+			BaseURL:              "https://api.deepseek.com",
+			Model:                "deepseek-chat",
+			Temperature:          0.7,
+			MaxTokens:            2000,
+			Timeout:              120,
+			MaxConcurrentStreams: 2,
+		},
+		Storage: models.StorageConfig{
+			Driver:          "sqlite",
+			DSN:             "data/report.db",
+			DraftMaxAge:     30 * 24 * time.Hour,
+			CleanupInterval: time.Hour,
+		},
+	}
+}
+
+// Load 加载配置。path 为空时只读取环境变量，否则按扩展名解析 YAML（.yaml/.yml）或 TOML（.toml）文件；
+// 配置文件中的未知字段、格式错误的环境变量都会返回错误。Load 不做业务校验，见 Validate
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	// 未配置跨域白名单时只允许前端地址
+	if len(cfg.CORS.AllowedOrigins) == 0 && cfg.Server.FrontendURL != "" {
+		cfg.CORS.AllowedOrigins = []string{cfg.Server.FrontendURL}
+	}
+	return cfg, nil
 }
 
-// GetDingTalkConfig 获取钉钉配置
-func GetDingTalkConfig() *models.DingTalkConfig {
-	return &models.DingTalkConfig{
-		CorpId:      getEnv("DINGTALK_CORP_ID", ""),
-		AppKey:      getEnv("DINGTALK_APP_KEY", ""),
-		AppSecret:   getEnv("DINGTALK_APP_SECRET", ""),
-		RedirectURI: getEnv("DINGTALK_REDIRECT_URI", ""),
-		BaseURL:     getEnv("DINGTALK_BASE_URL", "https://oapi.dingtalk.com"),
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file failed: %v", err)
 	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("parse config file %s failed: %v", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("parse config file %s failed: %v", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("parse config file %s failed: unknown fields %v", path, undecoded)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format: %s", path)
+	}
+	return nil
+}
+
+// loadEnv 用环境变量覆盖配置，未设置的变量保留原值
+func (c *Config) loadEnv() error {
+	env := &envLoader{}
+
+	env.str(&c.Server.Environment, "ENVIRONMENT")
+	env.str(&c.Server.Port, "PORT")
+	env.str(&c.Server.FrontendURL, "FRONTEND_URL")
+	env.duration(&c.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT")
+	env.duration(&c.Server.ReadTimeout, "SERVER_READ_TIMEOUT")
+	env.duration(&c.Server.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	env.duration(&c.Server.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	env.duration(&c.Server.DrainDelay, "SHUTDOWN_DRAIN_DELAY")
+	env.duration(&c.Server.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+
+	env.str(&c.Log.Level, "LOG_LEVEL")
+	env.str(&c.Log.Format, "LOG_FORMAT")
+	env.bool(&c.Log.HTTPDebug, "LOG_HTTP_DEBUG")
+	env.bool(&c.Metrics.Enabled, "METRICS_ENABLED")
+	env.str(&c.Metrics.Addr, "METRICS_ADDR")
+	env.bool(&c.Tracing.Enabled, "TRACING_ENABLED")
+	env.str(&c.Tracing.Endpoint, "TRACING_OTLP_ENDPOINT")
+	env.str(&c.Tracing.ServiceName, "TRACING_SERVICE_NAME")
+	env.float(&c.Tracing.SampleRatio, "TRACING_SAMPLE_RATIO")
+
+	env.str(&c.Auth.JWTSecret, "JWT_SECRET")
+	env.duration(&c.Auth.AccessTokenTTL, "ACCESS_TOKEN_TTL")
+	env.duration(&c.Auth.RefreshTokenTTL, "REFRESH_TOKEN_TTL")
+	env.duration(&c.Auth.OAuthStateTTL, "OAUTH_STATE_TTL")
+	env.duration(&c.Auth.ProfileCacheTTL, "PROFILE_CACHE_TTL")
+	env.duration(&c.Auth.RoleCacheTTL, "ROLE_CACHE_TTL")
+	env.list(&c.Auth.AdminSubjects, "ADMIN_SUBJECTS")
+	env.list(&c.Auth.LegacyDraftCorps, "LEGACY_DRAFT_CORPS")
+
+	env.list(&c.CORS.AllowedOrigins, "CORS_ALLOWED_ORIGINS")
+	env.bool(&c.CORS.AllowCredentials, "CORS_ALLOW_CREDENTIALS")
+	env.list(&c.CORS.ExposedHeaders, "CORS_EXPOSED_HEADERS")
+	env.duration(&c.CORS.MaxAge, "CORS_MAX_AGE")
+
+	env.bool(&c.GraphQL.Playground, "GRAPHQL_PLAYGROUND")
+	env.bool(&c.GraphQL.Introspection, "GRAPHQL_INTROSPECTION")
+
+	env.str(&c.DingTalk.CorpId, "DINGTALK_CORP_ID")
+	env.str(&c.DingTalk.AppKey, "DINGTALK_APP_KEY")
+	env.str(&c.DingTalk.AppSecret, "DINGTALK_APP_SECRET")
+	env.str(&c.DingTalk.RedirectURI, "DINGTALK_REDIRECT_URI")
+	env.str(&c.DingTalk.BaseURL, "DINGTALK_BASE_URL")
+	env.str(&c.DingTalk.APIBaseURL, "DINGTALK_API_BASE_URL")
+
+	env.str(&c.Feishu.AppID, "FEISHU_APP_ID")
+	env.str(&c.Feishu.AppSecret, "FEISHU_APP_SECRET")
+	env.str(&c.Feishu.RedirectURI, "FEISHU_REDIRECT_URI")
+	env.str(&c.Feishu.BaseURL, "FEISHU_BASE_URL")
+
+	env.str(&c.WeCom.CorpID, "WECOM_CORP_ID")
+	env.str(&c.WeCom.CorpSecret, "WECOM_CORP_SECRET")
+	env.str(&c.WeCom.AgentID, "WECOM_AGENT_ID")
+	env.str(&c.WeCom.RedirectURI, "WECOM_REDIRECT_URI")
+	env.str(&c.WeCom.BaseURL, "WECOM_BASE_URL")
+
+	env.str(&c.LLM.BaseURL, "LLM_BASE_URL")
+	env.str(&c.LLM.APIKey, "LLM_API_KEY")
+	env.str(&c.LLM.Model, "LLM_MODEL")
+	env.float(&c.LLM.Temperature, "LLM_TEMPERATURE")
+	env.int(&c.LLM.MaxTokens, "LLM_MAX_TOKENS")
+	env.int(&c.LLM.Timeout, "LLM_TIMEOUT")
+	env.int(&c.LLM.MaxConcurrentStreams, "LLM_MAX_CONCURRENT_STREAMS")
+
+	env.str(&c.Storage.Driver, "STORAGE_DRIVER")
+	env.str(&c.Storage.DSN, "STORAGE_DSN")
+	env.duration(&c.Storage.DraftMaxAge, "DRAFT_MAX_AGE")
+	env.duration(&c.Storage.CleanupInterval, "DRAFT_CLEANUP_INTERVAL")
+
+	return errors.Join(env.errs...)
+}
+
+// envLoader 读取环境变量并记录格式错误，未设置或为空的变量不覆盖原值
+type envLoader struct {
+	errs []error
 }
 
-// getEnv 获取环境变量，如果不存在则返回默认值
-func getEnv(key, defaultValue string) string {
+func (e *envLoader) str(target *string, key string) {
 	if value := os.Getenv(key); value != "" {
-		return value
+		*target = value
+	}
+}
+
+func (e *envLoader) int(target *int, key string) {
+	e.parse(key, func(value string) error {
+		v, err := strconv.Atoi(value)
+		if err == nil {
+			*target = v
+		}
+		return err
+	})
+}
+
+func (e *envLoader) float(target *float64, key string) {
+	e.parse(key, func(value string) error {
+		v, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			*target = v
+		}
+		return err
+	})
+}
+
+// bool 接受 true/false/1/0
+func (e *envLoader) bool(target *bool, key string) {
+	e.parse(key, func(value string) error {
+		v, err := strconv.ParseBool(value)
+		if err == nil {
+			*target = v
+		}
+		return err
+	})
+}
+
+// duration 接受 time.ParseDuration 格式，如 720h
+func (e *envLoader) duration(target *time.Duration, key string) {
+	e.parse(key, func(value string) error {
+		v, err := time.ParseDuration(value)
+		if err == nil {
+			*target = v
+		}
+		return err
+	})
+}
+
+// list 逗号分隔，忽略空白项
+func (e *envLoader) list(target *[]string, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	*target = values
+}
+
+func (e *envLoader) parse(key string, set func(value string) error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	if err := set(value); err != nil {
+		e.errs = append(e.errs, fmt.Errorf("invalid %s=%q: %v", key, value, err))
 	}
-	return defaultValue
 }
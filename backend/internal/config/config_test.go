@@ -0,0 +1,259 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write config file failed: %v", err)
+	}
+	return path
+}
+
+func TestLoadFileWithEnvOverrides(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+server:
+  port: "9090"
+auth:
+  access_token_ttl: 5m
+  admin_subjects: ["dingtalk:corp:boss"]
+dingtalk:
+  app_key: file-key
+  app_secret: file-secret
+storage:
+  driver: memory
+`,
+		"config.toml": `
+[server]
+port = "9090"
+
+[auth]
+access_token_ttl = "5m"
+admin_subjects = ["dingtalk:corp:boss"]
+
+[dingtalk]
+app_key = "file-key"
+app_secret = "file-secret"
+
+[storage]
+driver = "memory"
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("DINGTALK_APP_SECRET", "env-secret")
+			t.Setenv("REFRESH_TOKEN_TTL", "48h")
+			t.Setenv("ROLE_CACHE_TTL", "30s")
+
+			cfg, err := Load(writeConfigFile(t, name, content))
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if cfg.Server.Port != "9090" || cfg.Auth.AccessTokenTTL != 5*time.Minute || cfg.Storage.Driver != "memory" {
+				t.Fatalf("file values not applied: %+v", cfg)
+			}
+			if len(cfg.Auth.AdminSubjects) != 1 || cfg.Auth.AdminSubjects[0] != "dingtalk:corp:boss" {
+				t.Fatalf("unexpected admin subjects: %v", cfg.Auth.AdminSubjects)
+			}
+			// 环境变量覆盖文件，文件未设置的字段保留默认值
+			if cfg.DingTalk.AppKey != "file-key" || cfg.DingTalk.AppSecret != "env-secret" || cfg.Auth.RefreshTokenTTL != 48*time.Hour ||
+				cfg.Auth.RoleCacheTTL != 30*time.Second {
+				t.Fatalf("env overrides not applied: %+v", cfg.DingTalk)
+			}
+			if cfg.DingTalk.BaseURL != "https://oapi.dingtalk.com" || cfg.LLM.MaxConcurrentStreams != 2 {
+				t.Fatal("defaults should be kept for unset fields")
+			}
+			if len(cfg.CORS.AllowedOrigins) != 1 || cfg.CORS.AllowedOrigins[0] != "http://localhost:5173" {
+				t.Fatalf("CORS origins should default to frontend url: %v", cfg.CORS.AllowedOrigins)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		wantErr string
+	}{
+		{"unknown yaml field", "config.yaml", "server:\n  prot: 1\n", nil, "prot"},
+		{"unknown toml field", "config.toml", "[server]\nprot = 1\n", nil, "prot"},
+		{"unsupported format", "config.json", "{}", nil, "unsupported config file format"},
+		{"invalid env duration", "", "", map[string]string{"ACCESS_TOKEN_TTL": "15 minutes"}, "ACCESS_TOKEN_TTL"},
+		{"invalid env bool", "", "", map[string]string{"GRAPHQL_PLAYGROUND": "yes"}, "GRAPHQL_PLAYGROUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeConfigFile(t, tt.file, tt.content)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func productionConfig() *Config {
+	cfg := Default()
+	cfg.Server.Environment = EnvProduction
+	cfg.Auth.JWTSecret = strings.Repeat("s", minJWTSecretLength)
+	cfg.DingTalk.AppKey = "key"
+	cfg.DingTalk.AppSecret = "secret"
+	cfg.DingTalk.RedirectURI = "https://report.example.com/auth/callback"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(cfg *Config)
+		wantErr string
+	}{
+		{"valid production", func(cfg *Config) {}, ""},
+		{"development allows default secret", func(cfg *Config) {
+			*cfg = *Default()
+		}, ""},
+		{"default secret in production", func(cfg *Config) {
+			cfg.Auth.JWTSecret = DefaultJWTSecret
+		}, "default secret"},
+		{"short secret in production", func(cfg *Config) {
+			cfg.Auth.JWTSecret = "short"
+		}, "at least 32 characters"},
+		{"no platform in production", func(cfg *Config) {
+			cfg.DingTalk.AppKey, cfg.DingTalk.AppSecret = "", ""
+		}, "at least one of dingtalk"},
+		{"missing app secret in production", func(cfg *Config) {
+			cfg.DingTalk.AppSecret = ""
+		}, "dingtalk.app_key, dingtalk.app_secret"},
+		{"incomplete wecom in production", func(cfg *Config) {
+			cfg.WeCom.CorpID = "corp"
+		}, "wecom.corp_id"},
+		{"legacy draft corp without platform", func(cfg *Config) {
+			cfg.Auth.LegacyDraftCorps = []string{"corp"}
+		}, "auth.legacy_draft_corps entries"},
+		{"legacy draft corps for the same platform", func(cfg *Config) {
+			cfg.Auth.LegacyDraftCorps = []string{"dingtalk:corp-1", "dingtalk:corp-2"}
+		}, "more than one corp"},
+		{"unknown environment", func(cfg *Config) {
+			cfg.Server.Environment = "prod"
+		}, "server.environment"},
+		{"invalid port", func(cfg *Config) {
+			cfg.Server.Port = "http"
+		}, "server.port"},
+		{"negative drain delay", func(cfg *Config) {
+			cfg.Server.DrainDelay = -time.Second
+		}, "server.drain_delay"},
+		{"write timeout shorter than llm timeout", func(cfg *Config) {
+			cfg.Server.WriteTimeout = 30 * time.Second
+		}, "server.write_timeout"},
+		{"metrics on server port", func(cfg *Config) {
+			cfg.Metrics.Addr = ":8080"
+		}, "metrics.addr must not use server.port"},
+		{"metrics addr without port", func(cfg *Config) {
+			cfg.Metrics.Addr = "localhost"
+		}, "metrics.addr must be host:port"},
+		{"metrics disabled ignores addr", func(cfg *Config) {
+			cfg.Metrics.Enabled = false
+			cfg.Metrics.Addr = ""
+		}, ""},
+		{"unknown log level", func(cfg *Config) {
+			cfg.Log.Level = "trace"
+		}, "log.level"},
+		{"tracing sample ratio out of range", func(cfg *Config) {
+			cfg.Tracing.SampleRatio = 1.5
+		}, "tracing.sample_ratio"},
+		{"tracing endpoint without scheme", func(cfg *Config) {
+			cfg.Tracing.Enabled = true
+			cfg.Tracing.Endpoint = "localhost:4318"
+		}, "tracing.endpoint"},
+		{"negative role cache ttl", func(cfg *Config) {
+			cfg.Auth.RoleCacheTTL = -time.Minute
+		}, "auth.role_cache_ttl"},
+		{"credentials with any origin", func(cfg *Config) {
+			cfg.CORS.AllowedOrigins = []string{"https://app.example.com", "*"}
+			cfg.CORS.AllowCredentials = true
+		}, "cors.allow_credentials"},
+		{"credentials with allowlisted origins", func(cfg *Config) {
+			cfg.CORS.AllowedOrigins = []string{"https://*.example.com"}
+			cfg.CORS.AllowCredentials = true
+		}, ""},
+		{"playground in production", func(cfg *Config) {
+			cfg.GraphQL.Playground = true
+		}, "graphql.playground"},
+		{"introspection in production", func(cfg *Config) {
+			cfg.GraphQL.Introspection = true
+		}, "graphql.introspection"},
+		{"development allows playground and introspection", func(cfg *Config) {
+			*cfg = *Default()
+			cfg.GraphQL.Playground = true
+			cfg.GraphQL.Introspection = true
+		}, ""},
+		{"zero llm max tokens", func(cfg *Config) {
+			cfg.LLM.MaxTokens = 0
+		}, "llm.max_tokens"},
+		{"unknown storage driver", func(cfg *Config) {
+			cfg.Storage.Driver = "postgres"
+		}, "storage.driver"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := productionConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected valid config, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWriteRedacted(t *testing.T) {
+	cfg := productionConfig()
+	cfg.LLM.APIKey = "sk-secret"
+
+	var buf bytes.Buffer
+	if err := cfg.WriteRedacted(&buf); err != nil {
+		t.Fatalf("WriteRedacted failed: %v", err)
+	}
+	out := buf.String()
+	for _, secret := range []string{cfg.Auth.JWTSecret, "sk-secret", "app_secret: secret"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("secret %q leaked:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "app_key: key") || !strings.Contains(out, "access_token_ttl: 15m0s") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+	if cfg.LLM.APIKey != "sk-secret" {
+		t.Fatal("Redacted should not modify the original config")
+	}
+
+	// 输出可以重新作为配置文件加载
+	path := writeConfigFile(t, "printed.yaml", out)
+	if _, err := Load(path); err != nil {
+		t.Fatalf("printed config should load: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// minJWTSecretLength 生产环境 JWT 密钥的最小长度
+const minJWTSecretLength = 32
+
+const redacted = "******"
+
+// Validate 校验配置，返回全部问题。生产环境额外要求：
+// 修改默认 JWT 密钥，关闭 GraphiQL 与内省查询，至少配置一个登录平台，且已配置的平台凭据完整
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Environment == EnvDevelopment || c.Server.Environment == EnvProduction,
+		"server.environment must be %q or %q, got %q", EnvDevelopment, EnvProduction, c.Server.Environment)
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "server.port must be a valid port, got %q", c.Server.Port)
+	check(c.Server.DrainDelay >= 0, "server.drain_delay must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	// 总结生成等 GraphQL 请求会同步等待大模型返回
+	check(c.Server.WriteTimeout == 0 || c.Server.WriteTimeout > time.Duration(c.LLM.Timeout)*time.Second,
+		"server.write_timeout must be 0 or longer than llm.timeout (%ds)", c.LLM.Timeout)
+
+	if c.Metrics.Enabled {
+		_, metricsPort, err := net.SplitHostPort(c.Metrics.Addr)
+		check(err == nil, "metrics.addr must be host:port, got %q", c.Metrics.Addr)
+		check(err != nil || metricsPort != c.Server.Port, "metrics.addr must not use server.port %s", c.Server.Port)
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	check(c.Log.Format == "text" || c.Log.Format == "json", "log.format must be text or json, got %q", c.Log.Format)
+
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+	check(!c.Tracing.Enabled || isHTTPURL(c.Tracing.Endpoint), "tracing.endpoint must be an http(s) URL when tracing is enabled, got %q", c.Tracing.Endpoint)
+
+	check(c.Auth.JWTSecret != "", "auth.jwt_secret is required")
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.RefreshTokenTTL >= c.Auth.AccessTokenTTL, "auth.refresh_token_ttl must not be shorter than auth.access_token_ttl")
+	check(c.Auth.OAuthStateTTL > 0, "auth.oauth_state_ttl must be positive")
+	check(c.Auth.ProfileCacheTTL >= 0, "auth.profile_cache_ttl must not be negative")
+	check(c.Auth.RoleCacheTTL >= 0, "auth.role_cache_ttl must not be negative")
+	legacyPlatforms := make(map[string]bool)
+	for _, entry := range c.Auth.LegacyDraftCorps {
+		platform, corpID, _ := strings.Cut(entry, ":")
+		check(platform != "" && corpID != "", "auth.legacy_draft_corps entries must be platform:corp_id, got %q", entry)
+		check(!legacyPlatforms[platform], "auth.legacy_draft_corps has more than one corp for %q", platform)
+		legacyPlatforms[platform] = true
+	}
+
+	// 允许任意来源时携带凭据，等于允许任意站点以用户身份读取接口
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"),
+		"cors.allow_credentials must be false when cors.allowed_origins contains *")
+
+	check(c.Storage.Driver == "sqlite" || c.Storage.Driver == "memory", "storage.driver must be sqlite or memory, got %q", c.Storage.Driver)
+	check(c.Storage.Driver != "sqlite" || c.Storage.DSN != "", "storage.dsn is required for sqlite")
+
+	check(c.LLM.Timeout > 0, "llm.timeout must be positive")
+	check(c.LLM.MaxTokens > 0, "llm.max_tokens must be positive")
+	check(c.LLM.MaxConcurrentStreams > 0, "llm.max_concurrent_streams must be positive")
+
+	if c.Server.Environment == EnvProduction {
+		check(c.Auth.JWTSecret != DefaultJWTSecret, "auth.jwt_secret must not be the default secret in production")
+		check(len(c.Auth.JWTSecret) >= minJWTSecretLength, "auth.jwt_secret must be at least %d characters in production", minJWTSecretLength)
+		check(!c.GraphQL.Playground, "graphql.playground must be disabled in production")
+		check(!c.GraphQL.Introspection, "graphql.introspection must be disabled in production")
+
+		platforms := 0
+		if c.DingTalk.AppKey != "" || c.DingTalk.AppSecret != "" {
+			platforms++
+			check(c.DingTalk.AppKey != "" && c.DingTalk.AppSecret != "" && c.DingTalk.RedirectURI != "",
+				"dingtalk.app_key, dingtalk.app_secret and dingtalk.redirect_uri are all required")
+		}
+		if c.Feishu.AppID != "" || c.Feishu.AppSecret != "" {
+			platforms++
+			check(c.Feishu.AppID != "" && c.Feishu.AppSecret != "" && c.Feishu.RedirectURI != "",
+				"feishu.app_id, feishu.app_secret and feishu.redirect_uri are all required")
+		}
+		if c.WeCom.CorpID != "" || c.WeCom.CorpSecret != "" {
+			platforms++
+			check(c.WeCom.CorpID != "" && c.WeCom.CorpSecret != "" && c.WeCom.AgentID != "" && c.WeCom.RedirectURI != "",
+				"wecom.corp_id, wecom.corp_secret, wecom.agent_id and wecom.redirect_uri are all required")
+		}
+		check(platforms > 0, "at least one of dingtalk, feishu or wecom must be configured in production")
+	}
+
+	return errors.Join(errs...)
+}
+
+// isHTTPURL 判断是否为带主机名的 http(s) 地址
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Redacted 返回隐藏了密钥的配置副本，用于打印
+func (c *Config) Redacted() *Config {
+	copied := *c
+	mask := func(value *string) {
+		if *value != "" {
+			*value = redacted
+		}
+	}
+	mask(&copied.Auth.JWTSecret)
+	mask(&copied.DingTalk.AppSecret)
+	mask(&copied.Feishu.AppSecret)
+	mask(&copied.WeCom.CorpSecret)
+	mask(&copied.LLM.APIKey)
+	return &copied
+}
+
+// WriteRedacted 以 YAML 格式输出隐藏了密钥的配置，可直接作为配置文件使用
+func (c *Config) WriteRedacted(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
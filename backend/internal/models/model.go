@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // User 用户信息
 type User struct {
 	OpenID  string `json:"open_id"`
@@ -9,6 +11,8 @@ type User struct {
 	Avatar  string `json:"avatar_url"`
 	Email   string `json:"email"`
 	Mobile  string `json:"mobile"`
+	// CorpID 用户所在企业（钉钉 corpId、飞书 tenant_key、企业微信 corpid）
+	CorpID string `json:"corp_id,omitempty"`
 }
 
 // AuthToken JWT认证token
@@ -18,13 +22,154 @@ type AuthToken struct {
 	User      User   `json:"user"`
 }
 
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	// Environment 运行环境：development 或 production，生产环境启动时会严格校验配置
+	Environment string `yaml:"environment" toml:"environment"`
+	Port        string `yaml:"port" toml:"port"`
+	// FrontendURL 前端地址，未配置跨域白名单时作为唯一允许的来源
+	FrontendURL string `yaml:"frontend_url" toml:"frontend_url"`
+	// ReadHeaderTimeout 读取请求头的超时时间
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout"`
+	// ReadTimeout 读取完整请求的超时时间
+	ReadTimeout time.Duration `yaml:"read_timeout" toml:"read_timeout"`
+	// WriteTimeout 写出响应的超时时间，需大于大模型请求超时；流式生成接口不受此限制
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout"`
+	// IdleTimeout keep-alive 连接的空闲超时时间
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout"`
+	// DrainDelay 收到退出信号后就绪探针返回 503、但仍继续接收新请求的时长，应大于负载均衡的探测间隔
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+	// ShutdownTimeout 停止接收新连接后等待处理中请求完成的最长时间
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	// Level 日志级别：debug、info、warn 或 error
+	Level string `yaml:"level" toml:"level"`
+	// Format 输出格式：text 或 json
+	Format string `yaml:"format" toml:"format"`
+	// HTTPDebug 以 debug 级别输出调用钉钉、飞书、企业微信及大模型接口的请求与响应，令牌和密钥会被隐藏
+	HTTPDebug bool `yaml:"http_debug" toml:"http_debug"`
+}
+
+// MetricsConfig Prometheus 指标配置
+type MetricsConfig struct {
+	// Enabled 是否开放 /metrics
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Addr /metrics 的独立监听地址，不经过对外服务的端口；该接口无需登录，不应对公网开放
+	Addr string `yaml:"addr" toml:"addr"`
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置
+type TracingConfig struct {
+	// Enabled 是否通过 OTLP/HTTP 导出链路数据，关闭时仍会透传 traceparent 请求头
+	Enabled bool `yaml:"enabled" toml:"enabled"`
+	// Endpoint OTLP/HTTP 接收地址，如本地 collector 的 http://localhost:4318
+	Endpoint string `yaml:"endpoint" toml:"endpoint"`
+	// ServiceName 上报的服务名
+	ServiceName string `yaml:"service_name" toml:"service_name"`
+	// SampleRatio 根 span 的采样比例，0 到 1；上游已带采样决定时沿用上游
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
 // DingTalkConfig 钉钉配置
 type DingTalkConfig struct {
-	CorpId      string
-	AppKey      string
-	AppSecret   string
-	RedirectURI string
-	BaseURL     string
+	CorpId      string `yaml:"corp_id" toml:"corp_id"`
+	AppKey      string `yaml:"app_key" toml:"app_key"`
+	AppSecret   string `yaml:"app_secret" toml:"app_secret"`
+	RedirectURI string `yaml:"redirect_uri" toml:"redirect_uri"`
+	// BaseURL 旧版服务端接口地址（oapi.dingtalk.com）
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+	// APIBaseURL 新版服务端接口地址（api.dingtalk.com）
+	APIBaseURL string `yaml:"api_base_url" toml:"api_base_url"`
+}
+
+// FeishuConfig 飞书配置
+type FeishuConfig struct {
+	AppID       string `yaml:"app_id" toml:"app_id"`
+	AppSecret   string `yaml:"app_secret" toml:"app_secret"`
+	RedirectURI string `yaml:"redirect_uri" toml:"redirect_uri"`
+	// BaseURL 开放平台接口地址（open.feishu.cn）
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+}
+
+// WeComConfig 企业微信配置
+type WeComConfig struct {
+	CorpID      string `yaml:"corp_id" toml:"corp_id"`
+	CorpSecret  string `yaml:"corp_secret" toml:"corp_secret"`
+	AgentID     string `yaml:"agent_id" toml:"agent_id"`
+	RedirectURI string `yaml:"redirect_uri" toml:"redirect_uri"`
+	// BaseURL 企业微信接口地址（qyapi.weixin.qq.com）
+	BaseURL string `yaml:"base_url" toml:"base_url"`
+}
+
+// LLMConfig 大模型服务配置（OpenAI兼容接口）
+type LLMConfig struct {
+	BaseURL     string  `yaml:"base_url" toml:"base_url"`
+	APIKey      string  `yaml:"api_key" toml:"api_key"`
+	Model       string  `yaml:"model" toml:"model"`
+	Temperature float64 `yaml:"temperature" toml:"temperature"`
+	// MaxTokens 单次生成的 token 上限，流式生成接口请求的 max_tokens 不能超过此值
+	MaxTokens int `yaml:"max_tokens" toml:"max_tokens"`
+	// Timeout 单次请求超时时间（秒），流式生成耗时较长
+	Timeout int `yaml:"timeout" toml:"timeout"`
+	// MaxConcurrentStreams 每个用户同时进行的流式生成数量上限
+	MaxConcurrentStreams int `yaml:"max_concurrent_streams" toml:"max_concurrent_streams"`
+}
+
+// StorageConfig 服务端存储配置
+type StorageConfig struct {
+	// Driver 存储驱动：sqlite 或 memory
+	Driver string `yaml:"driver" toml:"driver"`
+	// DSN SQLite 数据库文件路径
+	DSN string `yaml:"dsn" toml:"dsn"`
+	// DraftMaxAge 草稿超过该时长未更新将被清理，0 表示不清理
+	DraftMaxAge time.Duration `yaml:"draft_max_age" toml:"draft_max_age"`
+	// CleanupInterval 过期草稿与会话的清理间隔
+	CleanupInterval time.Duration `yaml:"cleanup_interval" toml:"cleanup_interval"`
+}
+
+// AuthConfig 登录会话配置
+type AuthConfig struct {
+	// JWTSecret 访问令牌的签名密钥
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret"`
+	// AccessTokenTTL 访问令牌有效期，过期后需用刷新令牌换取新令牌
+	AccessTokenTTL time.Duration `yaml:"access_token_ttl" toml:"access_token_ttl"`
+	// RefreshTokenTTL 刷新令牌有效期，每次刷新后重新计算
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl"`
+	// OAuthStateTTL 第三方登录 state 的有效期，超时未回调需重新发起登录
+	OAuthStateTTL time.Duration `yaml:"oauth_state_ttl" toml:"oauth_state_ttl"`
+	// ProfileCacheTTL 当前用户资料（部门、主管等）的缓存时长，0 表示不缓存
+	ProfileCacheTTL time.Duration `yaml:"profile_cache_ttl" toml:"profile_cache_ttl"`
+	// RoleCacheTTL 授权使用的组织角色与部门关系的缓存时长，撤销管理员、调整主管最迟在此时长后生效，0 表示不缓存
+	RoleCacheTTL time.Duration `yaml:"role_cache_ttl" toml:"role_cache_ttl"`
+	// AdminSubjects 管理员白名单，元素为用户的 Subject（如 dingtalk:corpid:userid），可访问全企业的数据
+	AdminSubjects []string `yaml:"admin_subjects" toml:"admin_subjects"`
+	// LegacyDraftCorps 按 Subject 归属之前各平台所属的企业（如 dingtalk:corpid）。
+	// 旧草稿没有记录企业ID，只有这里配置的企业中的用户登录时才会认领，未配置的平台不认领
+	LegacyDraftCorps []string `yaml:"legacy_draft_corps" toml:"legacy_draft_corps"`
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	// AllowedOrigins 允许跨域访问的来源，如 https://app.example.com；
+	// https://*.example.com 匹配任意子域名，* 匹配任意来源
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	// AllowCredentials 是否允许跨域请求携带 Cookie 等凭据，AllowedOrigins 含 * 时不生效
+	AllowCredentials bool `yaml:"allow_credentials" toml:"allow_credentials"`
+	// ExposedHeaders 允许前端读取的响应头
+	ExposedHeaders []string `yaml:"exposed_headers" toml:"exposed_headers"`
+	// MaxAge 浏览器缓存预检结果的时长，0 表示不缓存
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age"`
+}
+
+// GraphQLConfig GraphQL 接口配置，生产环境必须全部关闭
+type GraphQLConfig struct {
+	// Playground 开启 GraphiQL 页面及 /graphql 路由，该路由匿名时只允许内省查询
+	Playground bool `yaml:"playground" toml:"playground"`
+	// Introspection 允许 __schema、__type 内省查询，GraphiQL 依赖内省加载文档
+	Introspection bool `yaml:"introspection" toml:"introspection"`
 }
 
 // DingTalkOAuthTokenResponse 钉钉OAuth token响应
@@ -53,11 +198,23 @@ type AuthRequest struct {
 	State string `json:"state"`
 }
 
+// TokenResponse 访问令牌与刷新令牌
+type TokenResponse struct {
+	Token            string `json:"token"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+}
+
 // AuthResponse 通用认证响应
 type AuthResponse struct {
-	Token     string `json:"token"`
-	ExpiresAt int64  `json:"expires_at"`
-	User      User   `json:"user"`
+	TokenResponse
+	User User `json:"user"`
+}
+
+// RefreshRequest 刷新令牌请求
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
 }
 
 type DingTalkAccessTokenResponse struct {
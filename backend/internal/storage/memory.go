@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore 基于内存的存储，进程重启后数据丢失，适用于开发与测试
+type MemoryStore struct {
+	mu       sync.RWMutex
+	drafts   map[draftKey]Draft
+	sessions map[string]Session
+	states   map[string]OAuthState
+}
+
+type draftKey struct {
+	userID     string
+	templateID string
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		drafts:   make(map[draftKey]Draft),
+		sessions: make(map[string]Session),
+		states:   make(map[string]OAuthState),
+	}
+}
+
+func (s *MemoryStore) ListDrafts(ctx context.Context, userID string) ([]Draft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	drafts := []Draft{}
+	for key, draft := range s.drafts {
+		if key.userID == userID {
+			drafts = append(drafts, draft)
+		}
+	}
+	sort.Slice(drafts, func(i, j int) bool { return drafts[i].UpdatedAt.After(drafts[j].UpdatedAt) })
+	return drafts, nil
+}
+
+func (s *MemoryStore) GetDraft(ctx context.Context, userID, templateID string) (*Draft, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	draft, ok := s.drafts[draftKey{userID, templateID}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &draft, nil
+}
+
+func (s *MemoryStore) SaveDraft(ctx context.Context, userID, templateID, content string, expectedVersion int) (*Draft, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := draftKey{userID, templateID}
+	now := time.Now()
+	draft, exists := s.drafts[key]
+	if !exists {
+		if expectedVersion != 0 {
+			return nil, ErrVersionConflict
+		}
+		draft = Draft{UserID: userID, TemplateID: templateID, CreatedAt: now}
+	} else if draft.Version != expectedVersion {
+		return nil, ErrVersionConflict
+	}
+
+	draft.Content = content
+	draft.Version++
+	draft.UpdatedAt = now
+	s.drafts[key] = draft
+	return &draft, nil
+}
+
+func (s *MemoryStore) DeleteDraft(ctx context.Context, userID, templateID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := draftKey{userID, templateID}
+	if _, ok := s.drafts[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.drafts, key)
+	return nil
+}
+
+func (s *MemoryStore) DeleteDraftsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int64
+	for key, draft := range s.drafts {
+		if draft.UpdatedAt.Before(cutoff) {
+			delete(s.drafts, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *MemoryStore) ClaimLegacyDrafts(ctx context.Context, legacyOwner, subject string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var claimed int64
+	for key, draft := range s.drafts {
+		if key.userID != legacyOwner {
+			continue
+		}
+		target := draftKey{subject, key.templateID}
+		if _, exists := s.drafts[target]; exists {
+			continue
+		}
+		delete(s.drafts, key)
+		draft.UserID = subject
+		s.drafts[target] = draft
+		claimed++
+	}
+	return claimed, nil
+}
+
+func (s *MemoryStore) CreateSession(ctx context.Context, session *Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *MemoryStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+func (s *MemoryStore) RotateRefreshToken(ctx context.Context, id, oldHash, newHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if !session.RevokedAt.IsZero() || session.RefreshTokenHash != oldHash {
+		return ErrRefreshTokenMismatch
+	}
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	s.sessions[id] = session
+	return nil
+}
+
+func (s *MemoryStore) RevokeSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if session.RevokedAt.IsZero() {
+		session.RevokedAt = time.Now()
+		s.sessions[id] = session
+	}
+	return nil
+}
+
+func (s *MemoryStore) RevokeUserSessions(ctx context.Context, subject string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var revoked int64
+	for id, session := range s.sessions {
+		if session.Subject == subject && session.RevokedAt.IsZero() {
+			session.RevokedAt = now
+			s.sessions[id] = session
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (s *MemoryStore) DeleteSessionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int64
+	for id, session := range s.sessions {
+		if session.ExpiresAt.Before(cutoff) {
+			delete(s.sessions, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *MemoryStore) SaveOAuthState(ctx context.Context, state *OAuthState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[state.State] = *state
+	return nil
+}
+
+func (s *MemoryStore) ConsumeOAuthState(ctx context.Context, state string) (*OAuthState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.states[state]
+	if !ok {
+		return nil, ErrOAuthStateNotFound
+	}
+	delete(s.states, state)
+	return &stored, nil
+}
+
+func (s *MemoryStore) DeleteOAuthStatesBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var removed int64
+	for key, state := range s.states {
+		if state.ExpiresAt.Before(cutoff) {
+			delete(s.states, key)
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+func (s *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
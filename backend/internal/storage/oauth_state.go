@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrOAuthStateNotFound state 不存在：伪造、已被使用或已被清理
+var ErrOAuthStateNotFound = errors.New("oauth state not found")
+
+// OAuthState 发起第三方登录时签发的一次性 state
+type OAuthState struct {
+	State     string    `json:"state"`
+	Platform  string    `json:"platform"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// OAuthStateStore OAuth state 存储
+type OAuthStateStore interface {
+	// SaveOAuthState 保存新签发的 state
+	SaveOAuthState(ctx context.Context, state *OAuthState) error
+	// ConsumeOAuthState 原子地取出并删除 state，保证只能使用一次；不存在时返回 ErrOAuthStateNotFound
+	ConsumeOAuthState(ctx context.Context, state string) (*OAuthState, error)
+	// DeleteOAuthStatesBefore 删除过期时间早于 cutoff 的 state，返回删除数量
+	DeleteOAuthStatesBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
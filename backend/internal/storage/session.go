@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+var (
+	// ErrSessionNotFound 会话不存在
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrRefreshTokenMismatch 刷新令牌与会话当前持有的不一致（已轮换或被撤销）
+	ErrRefreshTokenMismatch = errors.New("refresh token mismatch")
+)
+
+// Session 一次登录产生的服务端会话，访问令牌与刷新令牌都绑定到会话ID。
+// 会话保存登录时的用户身份，刷新令牌时据此重新签发访问令牌。
+type Session struct {
+	ID string `json:"id"`
+	// Subject 全局唯一的用户标识（平台:企业ID:平台内用户ID）
+	Subject  string `json:"subject"`
+	Platform string `json:"platform"`
+	UserID   string `json:"userid"`
+	UnionID  string `json:"unionid"`
+	OpenID   string `json:"openid"`
+	CorpID   string `json:"corp_id"`
+	Name     string `json:"name"`
+	// RefreshTokenHash 当前有效刷新令牌的 SHA-256，每次刷新后轮换
+	RefreshTokenHash string    `json:"-"`
+	CreatedAt        time.Time `json:"created_at"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	// RevokedAt 撤销时间，未撤销时为零值
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+// Active 会话在 now 时刻是否仍然有效
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
+
+// SessionStore 登录会话存储
+type SessionStore interface {
+	// CreateSession 保存新会话
+	CreateSession(ctx context.Context, session *Session) error
+	// GetSession 获取会话，不存在时返回 ErrSessionNotFound
+	GetSession(ctx context.Context, id string) (*Session, error)
+	// RotateRefreshToken 当会话未撤销且刷新令牌哈希等于 oldHash 时替换为 newHash 并延长有效期，
+	// 否则返回 ErrRefreshTokenMismatch
+	RotateRefreshToken(ctx context.Context, id, oldHash, newHash string, expiresAt time.Time) error
+	// RevokeSession 撤销单个会话，不存在时返回 ErrSessionNotFound
+	RevokeSession(ctx context.Context, id string) error
+	// RevokeUserSessions 撤销用户（按 Subject）的全部会话，返回撤销数量
+	RevokeUserSessions(ctx context.Context, subject string) (int64, error)
+	// DeleteSessionsBefore 删除过期时间早于 cutoff 的会话，返回删除数量
+	DeleteSessionsBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// StartSessionCleanup 定期清理已过期的会话与 OAuth state，ctx 取消时停止
+func StartSessionCleanup(ctx context.Context, store Store, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			removed, err := store.DeleteSessionsBefore(ctx, now)
+			if err != nil {
+				slog.Error("Failed to clean up expired sessions", "error", err)
+			} else if removed > 0 {
+				slog.Info("Cleaned up expired sessions", "count", removed)
+			}
+			if _, err := store.DeleteOAuthStatesBefore(ctx, now); err != nil {
+				slog.Error("Failed to clean up expired oauth states", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
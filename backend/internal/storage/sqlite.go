@@ -0,0 +1,407 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations 按顺序执行的表结构迁移，执行完第 i 个后 PRAGMA user_version 记为 i+1。
+// 已发布的迁移不能修改，表结构变更只能追加新的迁移
+var sqliteMigrations = []string{
+	// 1: 草稿与会话按各平台内的用户ID归属
+	`
+CREATE TABLE IF NOT EXISTS drafts (
+	user_id     TEXT    NOT NULL,
+	template_id TEXT    NOT NULL,
+	content     TEXT    NOT NULL,
+	version     INTEGER NOT NULL,
+	created_at  INTEGER NOT NULL,
+	updated_at  INTEGER NOT NULL,
+	PRIMARY KEY (user_id, template_id)
+);
+CREATE INDEX IF NOT EXISTS idx_drafts_updated_at ON drafts (updated_at);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id                 TEXT    PRIMARY KEY,
+	user_id            TEXT    NOT NULL,
+	platform           TEXT    NOT NULL,
+	name               TEXT    NOT NULL,
+	refresh_token_hash TEXT    NOT NULL,
+	created_at         INTEGER NOT NULL,
+	expires_at         INTEGER NOT NULL,
+	revoked_at         INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id, platform);
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
+
+CREATE TABLE IF NOT EXISTS oauth_states (
+	state      TEXT    PRIMARY KEY,
+	platform   TEXT    NOT NULL,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_oauth_states_expires_at ON oauth_states (expires_at);
+`,
+	// 2: 草稿与会话改按 Subject 归属。
+	// 旧草稿的归属改为 LegacyDraftOwner，平台取该用户最近一次会话的平台，没有会话时为钉钉，由用户下次登录时认领；
+	// 旧会话缺少企业ID，无法补全 Subject，直接删除，用户需重新登录
+	`
+UPDATE drafts SET user_id = 'legacy:' || COALESCE(
+	(SELECT platform FROM sessions WHERE sessions.user_id = drafts.user_id ORDER BY created_at DESC LIMIT 1),
+	'dingtalk') || ':' || user_id;
+
+DROP TABLE sessions;
+CREATE TABLE sessions (
+	id                 TEXT    PRIMARY KEY,
+	subject            TEXT    NOT NULL,
+	platform           TEXT    NOT NULL,
+	user_id            TEXT    NOT NULL,
+	union_id           TEXT    NOT NULL,
+	open_id            TEXT    NOT NULL,
+	corp_id            TEXT    NOT NULL,
+	name               TEXT    NOT NULL,
+	refresh_token_hash TEXT    NOT NULL,
+	created_at         INTEGER NOT NULL,
+	expires_at         INTEGER NOT NULL,
+	revoked_at         INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_sessions_subject ON sessions (subject);
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
+`,
+}
+
+// subjectSchemaVersion 引入 Subject 的迁移版本。此前的版本尚未记录 user_version
+const subjectSchemaVersion = 2
+
+// SQLiteStore 基于 SQLite 的存储
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore 打开（必要时创建）SQLite 数据库文件并初始化表结构
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	if dir := filepath.Dir(path); dir != "" && path != ":memory:" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create storage directory failed: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite failed: %v", err)
+	}
+	// SQLite 单写者，限制连接数避免 database is locked
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate sqlite schema failed: %v", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrateSQLite 执行尚未执行的迁移，每个迁移与版本号在同一事务中提交
+func migrateSQLite(db *sql.DB) error {
+	version, err := sqliteSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(sqliteMigrations))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %v", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %v", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %d failed: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// sqliteSchemaVersion 返回数据库的表结构版本。
+// 引入版本号之前创建的数据库 user_version 为0，sessions 表已有 subject 列时表结构与迁移2一致，补记版本号
+func sqliteSchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, err
+	}
+	if version > 0 {
+		return version, nil
+	}
+
+	var subjectColumns int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('sessions') WHERE name = 'subject'`).Scan(&subjectColumns); err != nil {
+		return 0, err
+	}
+	if subjectColumns > 0 {
+		if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", subjectSchemaVersion)); err != nil {
+			return 0, err
+		}
+		return subjectSchemaVersion, nil
+	}
+	return 0, nil
+}
+
+func (s *SQLiteStore) ListDrafts(ctx context.Context, userID string) ([]Draft, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT user_id, template_id, content, version, created_at, updated_at
+		 FROM drafts WHERE user_id = ? ORDER BY updated_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []Draft{}
+	for rows.Next() {
+		draft, err := scanDraft(rows)
+		if err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, *draft)
+	}
+	return drafts, rows.Err()
+}
+
+func (s *SQLiteStore) GetDraft(ctx context.Context, userID, templateID string) (*Draft, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT user_id, template_id, content, version, created_at, updated_at
+		 FROM drafts WHERE user_id = ? AND template_id = ?`, userID, templateID)
+	draft, err := scanDraft(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return draft, err
+}
+
+func (s *SQLiteStore) SaveDraft(ctx context.Context, userID, templateID, content string, expectedVersion int) (*Draft, error) {
+	now := time.Now().UnixMilli()
+
+	var result sql.Result
+	var err error
+	if expectedVersion == 0 {
+		result, err = s.db.ExecContext(ctx,
+			`INSERT INTO drafts (user_id, template_id, content, version, created_at, updated_at)
+			 VALUES (?, ?, ?, 1, ?, ?) ON CONFLICT (user_id, template_id) DO NOTHING`,
+			userID, templateID, content, now, now)
+	} else {
+		result, err = s.db.ExecContext(ctx,
+			`UPDATE drafts SET content = ?, version = version + 1, updated_at = ?
+			 WHERE user_id = ? AND template_id = ? AND version = ?`,
+			content, now, userID, templateID, expectedVersion)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, ErrVersionConflict
+	}
+	return s.GetDraft(ctx, userID, templateID)
+}
+
+func (s *SQLiteStore) DeleteDraft(ctx context.Context, userID, templateID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM drafts WHERE user_id = ? AND template_id = ?`, userID, templateID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteDraftsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM drafts WHERE updated_at < ?`, cutoff.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) ClaimLegacyDrafts(ctx context.Context, legacyOwner, subject string) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE drafts SET user_id = ?
+		 WHERE user_id = ? AND template_id NOT IN (SELECT template_id FROM drafts WHERE user_id = ?)`,
+		subject, legacyOwner, subject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) CreateSession(ctx context.Context, session *Session) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO sessions (id, subject, platform, user_id, union_id, open_id, corp_id, name,
+		                       refresh_token_hash, created_at, expires_at, revoked_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0)`,
+		session.ID, session.Subject, session.Platform, session.UserID, session.UnionID, session.OpenID,
+		session.CorpID, session.Name, session.RefreshTokenHash,
+		session.CreatedAt.UnixMilli(), session.ExpiresAt.UnixMilli())
+	return err
+}
+
+func (s *SQLiteStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	var createdAt, expiresAt, revokedAt int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, subject, platform, user_id, union_id, open_id, corp_id, name,
+		        refresh_token_hash, created_at, expires_at, revoked_at
+		 FROM sessions WHERE id = ?`, id).
+		Scan(&session.ID, &session.Subject, &session.Platform, &session.UserID, &session.UnionID,
+			&session.OpenID, &session.CorpID, &session.Name, &session.RefreshTokenHash,
+			&createdAt, &expiresAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	session.CreatedAt = time.UnixMilli(createdAt)
+	session.ExpiresAt = time.UnixMilli(expiresAt)
+	if revokedAt != 0 {
+		session.RevokedAt = time.UnixMilli(revokedAt)
+	}
+	return &session, nil
+}
+
+func (s *SQLiteStore) RotateRefreshToken(ctx context.Context, id, oldHash, newHash string, expiresAt time.Time) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET refresh_token_hash = ?, expires_at = ?
+		 WHERE id = ? AND refresh_token_hash = ? AND revoked_at = 0`,
+		newHash, expiresAt.UnixMilli(), id, oldHash)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if _, err := s.GetSession(ctx, id); err != nil {
+			return err
+		}
+		return ErrRefreshTokenMismatch
+	}
+	return nil
+}
+
+func (s *SQLiteStore) RevokeSession(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at = 0`, time.Now().UnixMilli(), id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// 已撤销的会话视为成功，只有不存在时报错
+		_, err := s.GetSession(ctx, id)
+		return err
+	}
+	return nil
+}
+
+func (s *SQLiteStore) RevokeUserSessions(ctx context.Context, subject string) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE sessions SET revoked_at = ? WHERE subject = ? AND revoked_at = 0`,
+		time.Now().UnixMilli(), subject)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) DeleteSessionsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < ?`, cutoff.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) SaveOAuthState(ctx context.Context, state *OAuthState) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO oauth_states (state, platform, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		state.State, state.Platform, state.CreatedAt.UnixMilli(), state.ExpiresAt.UnixMilli())
+	return err
+}
+
+func (s *SQLiteStore) ConsumeOAuthState(ctx context.Context, state string) (*OAuthState, error) {
+	var stored OAuthState
+	var createdAt, expiresAt int64
+	// DELETE ... RETURNING 保证并发请求中只有一个能取到 state
+	err := s.db.QueryRowContext(ctx,
+		`DELETE FROM oauth_states WHERE state = ? RETURNING state, platform, created_at, expires_at`, state).
+		Scan(&stored.State, &stored.Platform, &createdAt, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOAuthStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	stored.CreatedAt = time.UnixMilli(createdAt)
+	stored.ExpiresAt = time.UnixMilli(expiresAt)
+	return &stored, nil
+}
+
+func (s *SQLiteStore) DeleteOAuthStatesBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM oauth_states WHERE expires_at < ?`, cutoff.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDraft(row rowScanner) (*Draft, error) {
+	var draft Draft
+	var createdAt, updatedAt int64
+	if err := row.Scan(&draft.UserID, &draft.TemplateID, &draft.Content, &draft.Version, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	draft.CreatedAt = time.UnixMilli(createdAt)
+	draft.UpdatedAt = time.UnixMilli(updatedAt)
+	return &draft, nil
+}
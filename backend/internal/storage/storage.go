@@ -0,0 +1,108 @@
+// Package storage 提供草稿、登录会话、OAuth state 等服务端数据的持久化
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+var (
+	// ErrNotFound 草稿不存在
+	ErrNotFound = errors.New("draft not found")
+	// ErrVersionConflict 草稿已被其他设备修改，客户端持有的版本号已过期
+	ErrVersionConflict = errors.New("draft version conflict")
+)
+
+// Draft 用户在某个模板下的草稿，Content 为前端表单值的 JSON
+type Draft struct {
+	UserID     string    `json:"user_id"`
+	TemplateID string    `json:"template_id"`
+	Content    string    `json:"content"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// DraftStore 草稿存储，草稿以 (用户Subject, 模板ID) 唯一标识
+type DraftStore interface {
+	// ListDrafts 返回用户的全部草稿，按更新时间倒序
+	ListDrafts(ctx context.Context, userID string) ([]Draft, error)
+	// GetDraft 获取单个草稿，不存在时返回 ErrNotFound
+	GetDraft(ctx context.Context, userID, templateID string) (*Draft, error)
+	// SaveDraft 保存草稿。expectedVersion 为客户端持有的版本号，新建草稿时为0；
+	// 与存储中的版本不一致时返回 ErrVersionConflict。成功后版本号加一。
+	SaveDraft(ctx context.Context, userID, templateID, content string, expectedVersion int) (*Draft, error)
+	// DeleteDraft 删除草稿，不存在时返回 ErrNotFound
+	DeleteDraft(ctx context.Context, userID, templateID string) error
+	// DeleteDraftsBefore 删除最后更新时间早于 cutoff 的草稿，返回删除数量
+	DeleteDraftsBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	// ClaimLegacyDrafts 将按 Subject 归属之前保存的草稿转到 subject 名下，legacyOwner 见 LegacyDraftOwner。
+	// subject 已有同模板的草稿时保留后者，返回转移的数量
+	ClaimLegacyDrafts(ctx context.Context, legacyOwner, subject string) (int64, error)
+	// Close 释放存储资源
+	Close() error
+}
+
+// LegacyDraftOwner 按 Subject 归属之前保存的草稿在迁移后的归属，userID 为用户在平台内的ID。
+// 旧草稿没有记录企业ID，认领前需由调用方确认用户属于升级前的企业
+func LegacyDraftOwner(platform, userID string) string {
+	return "legacy:" + platform + ":" + userID
+}
+
+// Store 服务端存储，同一个驱动同时承载草稿、会话与 OAuth state
+type Store interface {
+	DraftStore
+	SessionStore
+	OAuthStateStore
+	// Ping 检查存储是否可用，用于就绪检查
+	Ping(ctx context.Context) error
+}
+
+// 存储驱动
+const (
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+// Open 根据配置创建存储
+func Open(config *models.StorageConfig) (Store, error) {
+	switch config.Driver {
+	case DriverSQLite:
+		return NewSQLiteStore(config.DSN)
+	case DriverMemory:
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", config.Driver)
+	}
+}
+
+// StartDraftCleanup 定期清理超过 maxAge 未更新的草稿，ctx 取消时停止
+func StartDraftCleanup(ctx context.Context, store DraftStore, maxAge, interval time.Duration) {
+	if maxAge <= 0 || interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			removed, err := store.DeleteDraftsBefore(ctx, time.Now().Add(-maxAge))
+			if err != nil {
+				slog.Error("Failed to clean up expired drafts", "error", err)
+			} else if removed > 0 {
+				slog.Info("Cleaned up expired drafts", "count", removed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
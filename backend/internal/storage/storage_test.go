@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	testDraftStore(t, store)
+	testSessionStore(t, store)
+	testOAuthStateStore(t, store)
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "drafts.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+	testDraftStore(t, store)
+	testSessionStore(t, store)
+	testOAuthStateStore(t, store)
+}
+
+// legacySQLiteSchema 引入版本号之前、引入 Subject 之前的表结构
+const legacySQLiteSchema = `
+CREATE TABLE drafts (
+	user_id     TEXT    NOT NULL,
+	template_id TEXT    NOT NULL,
+	content     TEXT    NOT NULL,
+	version     INTEGER NOT NULL,
+	created_at  INTEGER NOT NULL,
+	updated_at  INTEGER NOT NULL,
+	PRIMARY KEY (user_id, template_id)
+);
+CREATE TABLE sessions (
+	id                 TEXT    PRIMARY KEY,
+	user_id            TEXT    NOT NULL,
+	platform           TEXT    NOT NULL,
+	name               TEXT    NOT NULL,
+	refresh_token_hash TEXT    NOT NULL,
+	created_at         INTEGER NOT NULL,
+	expires_at         INTEGER NOT NULL,
+	revoked_at         INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX idx_sessions_user ON sessions (user_id, platform);
+INSERT INTO drafts VALUES ('user-1', 'tpl-1', '{"a":"1"}', 3, 1, 2);
+INSERT INTO drafts VALUES ('ou-1', 'tpl-1', '{"b":"1"}', 1, 1, 2);
+INSERT INTO sessions VALUES ('s1', 'ou-1', 'feishu', '张三', 'h1', 1, 2, 0);
+`
+
+// createSQLiteDB 用 statements 创建数据库文件，模拟旧版本留下的数据库
+func createSQLiteDB(t *testing.T, statements ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "report.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open sqlite failed: %v", err)
+	}
+	defer db.Close()
+	for _, statement := range statements {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("exec failed: %v", err)
+		}
+	}
+	return path
+}
+
+func schemaVersion(t *testing.T, store *SQLiteStore) int {
+	t.Helper()
+	var version int
+	if err := store.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("read user_version failed: %v", err)
+	}
+	return version
+}
+
+func TestSQLiteMigratesLegacySchema(t *testing.T) {
+	ctx := context.Background()
+	path := createSQLiteDB(t, legacySQLiteSchema)
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	if version := schemaVersion(t, store); version != len(sqliteMigrations) {
+		t.Fatalf("expected schema version %d, got %d", len(sqliteMigrations), version)
+	}
+
+	// 旧会话无法补全 Subject，迁移后需要重新登录
+	if _, err := store.GetSession(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected legacy session to be dropped, got %v", err)
+	}
+	now := time.Now()
+	if err := store.CreateSession(ctx, &Session{ID: "s2", Subject: "dingtalk:corp:user-1", Platform: "dingtalk", UserID: "user-1", RefreshTokenHash: "h2", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("CreateSession after migration failed: %v", err)
+	}
+
+	// 旧草稿按会话记录的平台归属，没有会话的视为钉钉用户
+	claimed, err := store.ClaimLegacyDrafts(ctx, LegacyDraftOwner("dingtalk", "user-1"), "dingtalk:corp:user-1")
+	if err != nil || claimed != 1 {
+		t.Fatalf("ClaimLegacyDrafts = %d, %v", claimed, err)
+	}
+	draft, err := store.GetDraft(ctx, "dingtalk:corp:user-1", "tpl-1")
+	if err != nil || draft.Content != `{"a":"1"}` || draft.Version != 3 {
+		t.Fatalf("unexpected claimed draft: %+v %v", draft, err)
+	}
+	if _, err := store.GetDraft(ctx, LegacyDraftOwner("feishu", "ou-1"), "tpl-1"); err != nil {
+		t.Fatalf("expected feishu legacy draft, got %v", err)
+	}
+	store.Close()
+
+	// 重新打开时不再重复迁移
+	store, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer store.Close()
+	if _, err := store.GetSession(ctx, "s2"); err != nil {
+		t.Fatalf("session lost after reopen: %v", err)
+	}
+}
+
+func TestSQLiteKeepsUnversionedCurrentSchema(t *testing.T) {
+	// 引入版本号之前、已按 Subject 归属的数据库
+	path := createSQLiteDB(t, append(append([]string{}, sqliteMigrations...),
+		"PRAGMA user_version = 0",
+		`INSERT INTO drafts VALUES ('dingtalk:corp:user-1', 'tpl-1', '{}', 1, 1, 2)`,
+	)...)
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer store.Close()
+	if version := schemaVersion(t, store); version != len(sqliteMigrations) {
+		t.Fatalf("expected schema version %d, got %d", len(sqliteMigrations), version)
+	}
+	if _, err := store.GetDraft(context.Background(), "dingtalk:corp:user-1", "tpl-1"); err != nil {
+		t.Fatalf("current draft should be kept: %v", err)
+	}
+}
+
+func TestSQLiteRejectsNewerSchema(t *testing.T) {
+	path := createSQLiteDB(t, fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations)+1))
+	if _, err := NewSQLiteStore(path); err == nil {
+		t.Fatal("expected error for newer schema version")
+	}
+}
+
+func testDraftStore(t *testing.T, store DraftStore) {
+	ctx := context.Background()
+
+	if _, err := store.GetDraft(ctx, "user-1", "tpl-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	draft, err := store.SaveDraft(ctx, "user-1", "tpl-1", `{"a":"1"}`, 0)
+	if err != nil {
+		t.Fatalf("create draft failed: %v", err)
+	}
+	if draft.Version != 1 || draft.Content != `{"a":"1"}` {
+		t.Fatalf("unexpected draft: %+v", draft)
+	}
+
+	// 另一台设备基于旧版本保存会冲突
+	if _, err := store.SaveDraft(ctx, "user-1", "tpl-1", `{"a":"x"}`, 0); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected conflict on duplicate create, got %v", err)
+	}
+	draft, err = store.SaveDraft(ctx, "user-1", "tpl-1", `{"a":"2"}`, 1)
+	if err != nil {
+		t.Fatalf("update draft failed: %v", err)
+	}
+	if draft.Version != 2 {
+		t.Fatalf("expected version 2, got %d", draft.Version)
+	}
+	if _, err := store.SaveDraft(ctx, "user-1", "tpl-1", `{"a":"3"}`, 1); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected conflict on stale version, got %v", err)
+	}
+
+	if _, err := store.SaveDraft(ctx, "user-1", "tpl-2", `{}`, 0); err != nil {
+		t.Fatalf("create second draft failed: %v", err)
+	}
+	if _, err := store.SaveDraft(ctx, "user-2", "tpl-1", `{}`, 0); err != nil {
+		t.Fatalf("create other user's draft failed: %v", err)
+	}
+	drafts, err := store.ListDrafts(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("ListDrafts failed: %v", err)
+	}
+	if len(drafts) != 2 {
+		t.Fatalf("expected 2 drafts for user-1, got %d", len(drafts))
+	}
+
+	if err := store.DeleteDraft(ctx, "user-1", "tpl-2"); err != nil {
+		t.Fatalf("DeleteDraft failed: %v", err)
+	}
+	if err := store.DeleteDraft(ctx, "user-1", "tpl-2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound on second delete, got %v", err)
+	}
+
+	removed, err := store.DeleteDraftsBefore(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("DeleteDraftsBefore failed: %v", err)
+	}
+	if removed != 2 {
+		t.Fatalf("expected 2 drafts removed, got %d", removed)
+	}
+
+	// 认领旧草稿时保留新归属下已有的同模板草稿
+	legacy := LegacyDraftOwner("dingtalk", "user-3")
+	for _, templateID := range []string{"tpl-1", "tpl-2"} {
+		if _, err := store.SaveDraft(ctx, legacy, templateID, `{"legacy":true}`, 0); err != nil {
+			t.Fatalf("create legacy draft failed: %v", err)
+		}
+	}
+	if _, err := store.SaveDraft(ctx, "dingtalk:corp:user-3", "tpl-1", `{}`, 0); err != nil {
+		t.Fatalf("create draft failed: %v", err)
+	}
+	claimed, err := store.ClaimLegacyDrafts(ctx, legacy, "dingtalk:corp:user-3")
+	if err != nil || claimed != 1 {
+		t.Fatalf("ClaimLegacyDrafts = %d, %v", claimed, err)
+	}
+	if draft, err := store.GetDraft(ctx, "dingtalk:corp:user-3", "tpl-1"); err != nil || draft.Content != `{}` {
+		t.Fatalf("existing draft should be kept: %+v %v", draft, err)
+	}
+	if draft, err := store.GetDraft(ctx, "dingtalk:corp:user-3", "tpl-2"); err != nil || draft.UserID != "dingtalk:corp:user-3" {
+		t.Fatalf("legacy draft should be claimed: %+v %v", draft, err)
+	}
+}
+
+func testSessionStore(t *testing.T, store SessionStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := store.GetSession(ctx, "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	for _, s := range []Session{
+		{ID: "s1", Subject: "dingtalk:corp:user-1", Platform: "dingtalk", UserID: "user-1", CorpID: "corp", Name: "张三", RefreshTokenHash: "h1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "s2", Subject: "dingtalk:corp:user-1", Platform: "dingtalk", UserID: "user-1", CorpID: "corp", Name: "张三", RefreshTokenHash: "h2", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "s3", Subject: "feishu:tenant:ou-1", Platform: "feishu", OpenID: "ou-1", CorpID: "tenant", Name: "张三", RefreshTokenHash: "h3", CreatedAt: now, ExpiresAt: now.Add(time.Hour)},
+		{ID: "s4", Subject: "dingtalk:corp:user-2", Platform: "dingtalk", UserID: "user-2", CorpID: "corp", Name: "李四", RefreshTokenHash: "h4", CreatedAt: now, ExpiresAt: now.Add(-time.Minute)},
+	} {
+		s := s
+		if err := store.CreateSession(ctx, &s); err != nil {
+			t.Fatalf("CreateSession %s failed: %v", s.ID, err)
+		}
+	}
+
+	session, err := store.GetSession(ctx, "s1")
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if session.Subject != "dingtalk:corp:user-1" || session.UserID != "user-1" || session.CorpID != "corp" ||
+		session.RefreshTokenHash != "h1" || !session.Active(time.Now()) {
+		t.Fatalf("unexpected session: %+v", session)
+	}
+
+	// 轮换后旧哈希失效
+	if err := store.RotateRefreshToken(ctx, "s1", "h1", "h1b", now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("RotateRefreshToken failed: %v", err)
+	}
+	if err := store.RotateRefreshToken(ctx, "s1", "h1", "h1c", now.Add(2*time.Hour)); !errors.Is(err, ErrRefreshTokenMismatch) {
+		t.Fatalf("expected mismatch on stale hash, got %v", err)
+	}
+	if err := store.RotateRefreshToken(ctx, "missing", "h", "h", now); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	if err := store.RevokeSession(ctx, "s1"); err != nil {
+		t.Fatalf("RevokeSession failed: %v", err)
+	}
+	if err := store.RevokeSession(ctx, "s1"); err != nil {
+		t.Fatalf("RevokeSession should be idempotent, got %v", err)
+	}
+	if err := store.RevokeSession(ctx, "missing"); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+	session, _ = store.GetSession(ctx, "s1")
+	if session.Active(time.Now()) {
+		t.Fatal("revoked session should not be active")
+	}
+	if err := store.RotateRefreshToken(ctx, "s1", "h1b", "h1c", now.Add(2*time.Hour)); !errors.Is(err, ErrRefreshTokenMismatch) {
+		t.Fatalf("expected mismatch on revoked session, got %v", err)
+	}
+
+	// 只撤销同一用户的其余会话，其他平台上的身份不受影响
+	revoked, err := store.RevokeUserSessions(ctx, "dingtalk:corp:user-1")
+	if err != nil {
+		t.Fatalf("RevokeUserSessions failed: %v", err)
+	}
+	if revoked != 1 {
+		t.Fatalf("expected 1 session revoked, got %d", revoked)
+	}
+	if session, _ := store.GetSession(ctx, "s3"); !session.Active(time.Now()) {
+		t.Fatal("session on another platform should stay active")
+	}
+
+	removed, err := store.DeleteSessionsBefore(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("DeleteSessionsBefore failed: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected 1 expired session removed, got %d", removed)
+	}
+}
+
+func testOAuthStateStore(t *testing.T, store OAuthStateStore) {
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, s := range []OAuthState{
+		{State: "fresh", Platform: "dingtalk", CreatedAt: now, ExpiresAt: now.Add(time.Minute)},
+		{State: "stale", Platform: "feishu", CreatedAt: now, ExpiresAt: now.Add(-time.Minute)},
+	} {
+		s := s
+		if err := store.SaveOAuthState(ctx, &s); err != nil {
+			t.Fatalf("SaveOAuthState %s failed: %v", s.State, err)
+		}
+	}
+
+	state, err := store.ConsumeOAuthState(ctx, "fresh")
+	if err != nil {
+		t.Fatalf("ConsumeOAuthState failed: %v", err)
+	}
+	if state.Platform != "dingtalk" {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+	if _, err := store.ConsumeOAuthState(ctx, "fresh"); !errors.Is(err, ErrOAuthStateNotFound) {
+		t.Fatalf("expected ErrOAuthStateNotFound on reuse, got %v", err)
+	}
+
+	removed, err := store.DeleteOAuthStatesBefore(ctx, now)
+	if err != nil {
+		t.Fatalf("DeleteOAuthStatesBefore failed: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("expected 1 expired state removed, got %d", removed)
+	}
+}
@@ -0,0 +1,122 @@
+// Package tokencache 缓存钉钉、飞书、企业微信的应用级 access_token：
+// 过期前自动刷新，并发请求同时触发刷新时只向平台发起一次获取
+package tokencache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// RefreshMargin token 提前刷新的时间，避免请求途中过期
+const RefreshMargin = 5 * time.Minute
+
+// errFetchAborted 获取过程中 panic 时返回给等待中的请求
+var errFetchAborted = errors.New("token fetch aborted")
+
+// Entry 一次获取到的 token
+type Entry[T any] struct {
+	Token T
+	// Value token 字符串，Invalidate 按此比对
+	Value string
+	// ExpiresIn 平台返回的有效期
+	ExpiresIn time.Duration
+}
+
+// Fetcher 向平台获取新的 token
+type Fetcher[T any] func(ctx context.Context) (Entry[T], error)
+
+// Cache 单个应用的 token 缓存
+type Cache[T any] struct {
+	fetch Fetcher[T]
+	now   func() time.Time
+	// OnLookup 每次 Get 时以是否命中缓存调用，用于统计命中率，需在使用前设置
+	OnLookup func(hit bool)
+
+	mu        sync.Mutex
+	entry     *Entry[T]
+	expiresAt time.Time
+	call      *fetchCall[T]
+}
+
+// fetchCall 一次正在进行中的获取
+type fetchCall[T any] struct {
+	done  chan struct{}
+	entry Entry[T]
+	err   error
+}
+
+// New 创建 token 缓存
+func New[T any](fetch Fetcher[T]) *Cache[T] {
+	return &Cache[T]{fetch: fetch, now: time.Now}
+}
+
+// Get 返回缓存的 token，缓存缺失或即将过期时刷新。
+// 刷新由多个请求共享，不随触发刷新的请求取消，只沿用 ctx 中的链路与请求 ID；
+// 等待其他请求刷新时随 ctx 取消返回
+func (c *Cache[T]) Get(ctx context.Context) (T, error) {
+	c.mu.Lock()
+	if c.entry != nil && c.now().Before(c.expiresAt) {
+		token := c.entry.Token
+		c.mu.Unlock()
+		c.observe(true)
+		return token, nil
+	}
+	// 等待其他请求刷新同样计为未命中
+	c.observe(false)
+	if call := c.call; call != nil {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.entry.Token, call.err
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+	call := &fetchCall[T]{done: make(chan struct{})}
+	c.call = call
+	c.mu.Unlock()
+
+	// fetch panic 时同样结束本次获取，等待中的请求得到 errFetchAborted，之后的 Get 重新获取
+	call.err = errFetchAborted
+	defer func() {
+		c.mu.Lock()
+		if call.err == nil {
+			entry := call.entry
+			c.entry = &entry
+			c.expiresAt = c.now().Add(lifetime(entry.ExpiresIn))
+		}
+		c.call = nil
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.entry, call.err = c.fetch(context.WithoutCancel(ctx))
+
+	return call.entry.Token, call.err
+}
+
+// Invalidate 丢弃失效的 token。只有缓存的仍是该 token 时才丢弃，
+// 避免覆盖其他请求刚刷新得到的新 token
+func (c *Cache[T]) Invalidate(value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entry != nil && c.entry.Value == value {
+		c.entry = nil
+	}
+}
+
+func (c *Cache[T]) observe(hit bool) {
+	if c.OnLookup != nil {
+		c.OnLookup(hit)
+	}
+}
+
+// lifetime 计算 token 在缓存中的有效时长
+func lifetime(expiresIn time.Duration) time.Duration {
+	if expiresIn > 2*RefreshMargin {
+		return expiresIn - RefreshMargin
+	}
+	return expiresIn / 2
+}
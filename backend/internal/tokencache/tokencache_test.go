@@ -0,0 +1,183 @@
+package tokencache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheCachesUntilExpiry(t *testing.T) {
+	var calls int32
+	c := New(func(context.Context) (Entry[string], error) {
+		token := fmt.Sprintf("token-%d", atomic.AddInt32(&calls, 1))
+		return Entry[string]{Token: token, Value: token, ExpiresIn: 2 * time.Hour}, nil
+	})
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		token, err := c.Get(context.Background())
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		if token != "token-1" {
+			t.Fatalf("expected cached token-1, got %s", token)
+		}
+	}
+
+	// 进入提前刷新窗口后应重新获取
+	now = now.Add(2*time.Hour - RefreshMargin)
+	token, err := c.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if token != "token-2" {
+		t.Fatalf("expected refreshed token-2, got %s", token)
+	}
+}
+
+func TestCacheSingleFlight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	c := New(func(context.Context) (Entry[string], error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return Entry[string]{Token: "token", Value: "token", ExpiresIn: 2 * time.Hour}, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Get(context.Background()); err != nil {
+				t.Errorf("Get failed: %v", err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected 1 fetch, got %d", calls)
+	}
+}
+
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	var calls int32
+	c := New(func(context.Context) (Entry[string], error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return Entry[string]{}, fmt.Errorf("boom")
+		}
+		return Entry[string]{Token: "token", Value: "token", ExpiresIn: 2 * time.Hour}, nil
+	})
+
+	if _, err := c.Get(context.Background()); err == nil {
+		t.Fatal("expected error on first fetch")
+	}
+	if _, err := c.Get(context.Background()); err != nil {
+		t.Fatalf("expected second fetch to succeed: %v", err)
+	}
+}
+
+func TestCacheInvalidateOnlyMatchingToken(t *testing.T) {
+	var calls int32
+	c := New(func(context.Context) (Entry[string], error) {
+		token := fmt.Sprintf("token-%d", atomic.AddInt32(&calls, 1))
+		return Entry[string]{Token: token, Value: token, ExpiresIn: 2 * time.Hour}, nil
+	})
+	var hits, misses int
+	c.OnLookup = func(hit bool) {
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+	}
+
+	c.Get(context.Background())
+	// 其他请求已刷新时，过期的旧 token 不应使新 token 失效
+	c.Invalidate("stale")
+	if token, _ := c.Get(context.Background()); token != "token-1" {
+		t.Fatalf("expected token-1 to stay cached, got %s", token)
+	}
+	c.Invalidate("token-1")
+	if token, _ := c.Get(context.Background()); token != "token-2" {
+		t.Fatalf("expected token-2 after invalidate, got %s", token)
+	}
+	if hits != 1 || misses != 2 {
+		t.Fatalf("expected 1 hit and 2 misses, got %d and %d", hits, misses)
+	}
+}
+
+func TestCacheFetchIgnoresCallerCancellation(t *testing.T) {
+	c := New(func(ctx context.Context) (Entry[string], error) {
+		if err := ctx.Err(); err != nil {
+			return Entry[string]{}, err
+		}
+		return Entry[string]{Token: "token", Value: "token", ExpiresIn: 2 * time.Hour}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Get(ctx); err != nil {
+		t.Fatalf("shared refresh should not be canceled by the caller: %v", err)
+	}
+}
+
+func TestCacheWaiterReturnsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	c := New(func(context.Context) (Entry[string], error) {
+		close(started)
+		<-release
+		return Entry[string]{Token: "token", Value: "token", ExpiresIn: 2 * time.Hour}, nil
+	})
+	go c.Get(context.Background())
+	<-started
+
+	// 等待他人刷新的请求随自己的 ctx 返回
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestCacheRecoversAfterFetchPanic(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	c := New(func(context.Context) (Entry[string], error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-release
+			panic("boom")
+		}
+		return Entry[string]{Token: "token", Value: "token", ExpiresIn: 2 * time.Hour}, nil
+	})
+
+	go func() {
+		defer func() { recover() }()
+		c.Get(context.Background())
+	}()
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	waiter := make(chan error, 1)
+	go func() {
+		_, err := c.Get(context.Background())
+		waiter <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if err := <-waiter; err != errFetchAborted {
+		t.Fatalf("expected waiter to get errFetchAborted, got %v", err)
+	}
+	if token, err := c.Get(context.Background()); err != nil || token != "token" {
+		t.Fatalf("expected next Get to fetch again, got %q %v", token, err)
+	}
+}
@@ -1,25 +1,115 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/hellodeveye/report/api"
+	"github.com/hellodeveye/report/internal/config"
+	"github.com/hellodeveye/report/internal/storage"
+	"github.com/hellodeveye/report/pkg/logging"
+	"github.com/hellodeveye/report/pkg/tracing"
 )
 
 func main() {
-	// 设置路由
-	router := api.SetupRoutes()
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径（.yaml/.yml/.toml），环境变量会覆盖文件中的值")
+	printConfig := flag.Bool("print-config", false, "输出隐藏密钥后的最终配置并退出")
+	flag.Parse()
 
-	// 获取端口
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
 	}
+	if *printConfig {
+		if err := cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatalf("Failed to print config: %v", err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid config:\n%v", err)
+	}
+	// 之后的日志（包括标准库 log）均为结构化输出，并隐藏令牌与密钥
+	logging.Setup(&cfg.Log)
+	// 未开启导出时只透传 traceparent
+	shutdownTracing, err := tracing.Setup(context.Background(), &cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Auth.JWTSecret == config.DefaultJWTSecret {
+		slog.Warn("Using the default JWT secret, set JWT_SECRET before deploying")
+	}
+
+	// 收到 SIGINT/SIGTERM 时开始优雅退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// 打开存储并定期清理过期草稿与会话
+	store, err := storage.Open(&cfg.Storage)
+	if err != nil {
+		slog.Error("Failed to open storage", "error", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	storage.StartDraftCleanup(ctx, store, cfg.Storage.DraftMaxAge, cfg.Storage.CleanupInterval)
+	storage.StartSessionCleanup(ctx, store, cfg.Storage.CleanupInterval)
 
-	log.Println("Server starting on port " + port)
-	if err := http.ListenAndServe(":"+port, router); err != nil {
-		log.Fatal(err)
+	srv := api.NewServer(cfg, store)
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("Server starting", "port", cfg.Server.Port, "environment", cfg.Server.Environment)
+		serveErr <- srv.ListenAndServe()
+	}()
+	// /metrics 在独立端口监听，不经过对外服务的路由
+	metricsSrv := api.NewMetricsServer(cfg)
+	if metricsSrv != nil {
+		go func() {
+			slog.Info("Metrics server starting", "addr", cfg.Metrics.Addr)
+			serveErr <- metricsSrv.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Server failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	case <-ctx.Done():
+	}
+	// 恢复默认信号处理，再次收到信号时立即退出
+	stop()
+
+	// 先让就绪探针返回 503，等负载均衡摘除实例后再停止接收新连接
+	slog.Info("Draining, readiness probe now reports unavailable", "delay", cfg.Server.DrainDelay.String())
+	srv.Drain()
+
+	// 停止接收新连接，等待处理中的 GraphQL、流式生成等请求完成
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", cfg.Server.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Graceful shutdown incomplete, closing remaining connections", "error", err)
+		srv.Close()
+	}
+	// 指标服务最后关闭，排空期间仍可采集
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(shutdownCtx); err != nil {
+			metricsSrv.Close()
+		}
+	}
+	// 导出尚未发送的 span
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
 	}
+	slog.Info("Server stopped")
 }
@@ -5,21 +5,57 @@ import "context"
 // contextKey 是用于context的键类型，防止键冲突
 type contextKey string
 
+// principalKey 当前登录用户身份的context键
+const principalKey contextKey = "principal"
+
+// 用户登录平台
 const (
-	// UserOpenIDKey 用户OpenID的context键
-	UserOpenIDKey contextKey = "user_open_id"
-	// UserNameKey 用户姓名的context键
-	UserNameKey contextKey = "user_name"
+	PlatformDingTalk = "dingtalk"
+	PlatformFeishu   = "feishu"
+	PlatformWeCom    = "wecom"
 )
 
-// GetUserOpenID 从context中获取用户OpenID
-func GetUserOpenID(ctx context.Context) (string, bool) {
-	openID, ok := ctx.Value(UserOpenIDKey).(string)
-	return openID, ok
+// WithPrincipal 将登录用户身份写入context
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey, principal)
+}
+
+// GetPrincipal 从context中获取登录用户身份
+func GetPrincipal(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// GetSubject 从context中获取用户的全局唯一标识
+func GetSubject(ctx context.Context) (string, bool) {
+	if principal, ok := GetPrincipal(ctx); ok && principal.Subject != "" {
+		return principal.Subject, true
+	}
+	return "", false
+}
+
+// GetPlatformUserID 从context中获取调用所在平台接口时使用的用户标识
+func GetPlatformUserID(ctx context.Context) (string, bool) {
+	if principal, ok := GetPrincipal(ctx); ok {
+		if id := principal.PlatformUserID(); id != "" {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// GetUserPlatform 从context中获取用户登录平台，未登录时视为钉钉
+func GetUserPlatform(ctx context.Context) string {
+	if principal, ok := GetPrincipal(ctx); ok && principal.Platform != "" {
+		return principal.Platform
+	}
+	return PlatformDingTalk
 }
 
-// GetUserName 从context中获取用户姓名
-func GetUserName(ctx context.Context) (string, bool) {
-	userName, ok := ctx.Value(UserNameKey).(string)
-	return userName, ok
+// GetSessionID 从context中获取当前登录会话ID
+func GetSessionID(ctx context.Context) (string, bool) {
+	if principal, ok := GetPrincipal(ctx); ok && principal.SessionID != "" {
+		return principal.SessionID, true
+	}
+	return "", false
 }
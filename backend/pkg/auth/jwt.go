@@ -1,35 +1,69 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/hellodeveye/report/internal/config"
-	"github.com/hellodeveye/report/internal/models"
 )
 
-// Claims JWT声明
+// Claims JWT声明，sub 为 Principal.Subject
 type Claims struct {
-	OpenID string `json:"open_id"`
-	Name   string `json:"name"`
+	// Platform 用户登录的平台（dingtalk/feishu/wecom）
+	Platform string `json:"platform"`
+	UserID   string `json:"userid,omitempty"`
+	UnionID  string `json:"unionid,omitempty"`
+	OpenID   string `json:"openid,omitempty"`
+	CorpID   string `json:"corp_id,omitempty"`
+	Name     string `json:"name"`
+	// SessionID 签发该 token 的服务端会话，会话撤销后 token 随之失效
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 生成JWT token
-func GenerateToken(openID, name string) (string, int64, error) {
-	expireTime := time.Now().Add(24 * time.Hour)
+// Principal 还原 token 所代表的用户身份
+func (c *Claims) Principal() *Principal {
+	return &Principal{
+		Subject:   c.Subject,
+		Platform:  c.Platform,
+		UserID:    c.UserID,
+		UnionID:   c.UnionID,
+		OpenID:    c.OpenID,
+		CorpID:    c.CorpID,
+		Name:      c.Name,
+		SessionID: c.SessionID,
+	}
+}
+
+// GenerateToken 用 secret 为 principal 签发有效期为 ttl 的JWT访问令牌，每个令牌带有唯一的 jti
+func GenerateToken(secret []byte, principal *Principal, ttl time.Duration) (string, int64, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", 0, err
+	}
+
+	now := time.Now()
+	expireTime := now.Add(ttl)
 	claims := &Claims{
-		OpenID: openID,
-		Name:   name,
+		Platform:  principal.Platform,
+		UserID:    principal.UserID,
+		UnionID:   principal.UnionID,
+		OpenID:    principal.OpenID,
+		CorpID:    principal.CorpID,
+		Name:      principal.Name,
+		SessionID: principal.SessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(expireTime), // 24小时过期
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        jti,
+			Subject:   principal.Subject,
+			ExpiresAt: jwt.NewNumericDate(expireTime),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Issuer:    "report-assistant",
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(config.GetJWTSecret()))
+	tokenString, err := token.SignedString(secret)
 	if err != nil {
 		return "", 0, err
 	}
@@ -37,16 +71,10 @@ func GenerateToken(openID, name string) (string, int64, error) {
 	return tokenString, expireTime.Unix(), nil
 }
 
-// GenerateTokenFromUser 从用户对象生成JWT token (保持向后兼容)
-func GenerateTokenFromUser(user models.User) (string, error) {
-	token, _, err := GenerateToken(user.OpenID, user.Name)
-	return token, err
-}
-
-// ValidateToken 验证JWT token
-func ValidateToken(tokenString string) (*Claims, error) {
+// ValidateToken 验证JWT token的签名与有效期，不检查会话是否已撤销
+func ValidateToken(secret []byte, tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GetJWTSecret()), nil
+		return secret, nil
 	})
 
 	if err != nil {
@@ -59,3 +87,12 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, jwt.NewValidationError("invalid token", jwt.ValidationErrorClaimsInvalid)
 }
+
+// randomHex 生成 n 字节随机数的十六进制表示
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+// Principal 当前登录用户的身份，由 AuthMiddleware 根据访问令牌写入请求上下文
+type Principal struct {
+	// Subject 全局唯一的用户标识，格式为 平台:企业ID:平台内用户ID，草稿、会话等服务端数据按它归属
+	Subject  string
+	Platform string
+	// UserID 企业内成员ID（钉钉/企业微信 userid，飞书 user_id）
+	UserID  string
+	UnionID string
+	OpenID  string
+	CorpID  string
+	Name    string
+	// SessionID 签发访问令牌的服务端会话
+	SessionID string
+}
+
+// NewPrincipal 根据第三方登录返回的用户信息构建身份
+func NewPrincipal(platform string, user *models.User) *Principal {
+	p := &Principal{
+		Platform: platform,
+		UserID:   user.UserID,
+		UnionID:  user.UnionID,
+		OpenID:   user.OpenID,
+		CorpID:   user.CorpID,
+		Name:     user.Name,
+	}
+	p.Subject = subject(platform, user.CorpID, p.PlatformUserID())
+	return p
+}
+
+// PlatformUserID 调用该平台日志接口时使用的用户标识：飞书按 open_id，钉钉与企业微信按 userid
+func (p *Principal) PlatformUserID() string {
+	if p.Platform == PlatformFeishu {
+		return p.OpenID
+	}
+	return p.UserID
+}
+
+// subject 拼接 Subject，企业ID未知时省略
+func subject(platform, corpID, id string) string {
+	parts := []string{platform}
+	if corpID != "" {
+		parts = append(parts, corpID)
+	}
+	return strings.Join(append(parts, id), ":")
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/storage"
+)
+
+var (
+	// ErrInvalidRefreshToken 刷新令牌格式错误、已过期、已轮换或会话已撤销
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrSessionRevoked 访问令牌对应的会话已退出登录或过期
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// refreshGracePeriod 刷新令牌轮换后的宽限期。多个标签页几乎同时用同一刷新令牌刷新时，
+// 宽限期内的重复刷新返回刚签发的令牌对，而不是当作令牌泄露撤销会话
+const refreshGracePeriod = 10 * time.Second
+
+// TokenPair 一次登录或刷新签发的令牌
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  int64
+	RefreshToken     string
+	RefreshExpiresAt int64
+}
+
+// SessionManager 管理登录会话：签发短期访问令牌与可轮换的刷新令牌，并支持服务端撤销
+type SessionManager struct {
+	store      storage.SessionStore
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	// legacyCorps 平台 -> 旧草稿所属的企业ID
+	legacyCorps map[string]string
+
+	// refreshGrace 刷新令牌轮换后的宽限期，见 refreshGracePeriod
+	refreshGrace time.Duration
+	// mu 串行化刷新令牌轮换，保证重复刷新能读到先到请求签发的令牌对
+	mu sync.Mutex
+	// rotated 旧刷新令牌哈希 -> 宽限期内用它换到的令牌对
+	rotated map[string]rotatedRefresh
+}
+
+// rotatedRefresh 一次刷新签发的令牌对及其宽限期截止时间
+type rotatedRefresh struct {
+	pair       *TokenPair
+	graceUntil time.Time
+}
+
+// NewSessionManager 创建会话管理器
+func NewSessionManager(store storage.SessionStore, config *models.AuthConfig) *SessionManager {
+	legacyCorps := make(map[string]string)
+	for _, entry := range config.LegacyDraftCorps {
+		if platform, corpID, ok := strings.Cut(entry, ":"); ok {
+			legacyCorps[platform] = corpID
+		}
+	}
+	return &SessionManager{
+		store:        store,
+		secret:       []byte(config.JWTSecret),
+		accessTTL:    config.AccessTokenTTL,
+		refreshTTL:   config.RefreshTokenTTL,
+		legacyCorps:  legacyCorps,
+		refreshGrace: refreshGracePeriod,
+		rotated:      make(map[string]rotatedRefresh),
+	}
+}
+
+// Login 为登录成功的用户创建会话并签发令牌
+func (m *SessionManager) Login(ctx context.Context, principal *Principal) (*TokenPair, error) {
+	sessionID, err := randomHex(16)
+	if err != nil {
+		return nil, fmt.Errorf("generate session id failed: %v", err)
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token failed: %v", err)
+	}
+
+	now := time.Now()
+	session := &storage.Session{
+		ID:               sessionID,
+		Subject:          principal.Subject,
+		Platform:         principal.Platform,
+		UserID:           principal.UserID,
+		UnionID:          principal.UnionID,
+		OpenID:           principal.OpenID,
+		CorpID:           principal.CorpID,
+		Name:             principal.Name,
+		RefreshTokenHash: hashSecret(secret),
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(m.refreshTTL),
+	}
+	if err := m.store.CreateSession(ctx, session); err != nil {
+		return nil, fmt.Errorf("create session failed: %v", err)
+	}
+	// 认领按 Subject 归属之前保存的草稿，只认领升级前所属企业的用户，失败不影响登录
+	if drafts, ok := m.store.(storage.DraftStore); ok && m.isLegacyCorp(principal) {
+		owner := storage.LegacyDraftOwner(principal.Platform, principal.PlatformUserID())
+		if _, err := drafts.ClaimLegacyDrafts(ctx, owner, principal.Subject); err != nil {
+			slog.WarnContext(ctx, "Failed to claim legacy drafts", "subject", principal.Subject, "error", err)
+		}
+	}
+	return m.issue(session, secret)
+}
+
+// isLegacyCorp 判断用户是否属于按 Subject 归属之前的企业
+func (m *SessionManager) isLegacyCorp(principal *Principal) bool {
+	corpID, ok := m.legacyCorps[principal.Platform]
+	return ok && corpID == principal.CorpID
+}
+
+// Refresh 用刷新令牌换取新的令牌对，旧刷新令牌随即失效。
+// 轮换后宽限期内的重复刷新返回同一令牌对；超过宽限期后
+// 已轮换掉的刷新令牌被再次使用说明令牌可能泄露，此时整个会话会被撤销。
+func (m *SessionManager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	sessionID, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || sessionID == "" || secret == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := m.store.GetSession(ctx, sessionID)
+	if errors.Is(err, storage.ErrSessionNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get session failed: %v", err)
+	}
+	if !session.Active(time.Now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	oldHash := hashSecret(secret)
+	if rotated, ok := m.rotated[sessionID+"."+oldHash]; ok && now.Before(rotated.graceUntil) {
+		return rotated.pair, nil
+	}
+
+	newSecret, err := randomHex(32)
+	if err != nil {
+		return nil, fmt.Errorf("generate refresh token failed: %v", err)
+	}
+	expiresAt := now.Add(m.refreshTTL)
+	err = m.store.RotateRefreshToken(ctx, sessionID, oldHash, hashSecret(newSecret), expiresAt)
+	if errors.Is(err, storage.ErrRefreshTokenMismatch) {
+		if err := m.store.RevokeSession(ctx, sessionID); err != nil {
+			return nil, fmt.Errorf("revoke session failed: %v", err)
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("rotate refresh token failed: %v", err)
+	}
+
+	session.ExpiresAt = expiresAt
+	pair, err := m.issue(session, newSecret)
+	if err != nil {
+		return nil, err
+	}
+	for key, rotated := range m.rotated {
+		if !now.Before(rotated.graceUntil) {
+			delete(m.rotated, key)
+		}
+	}
+	m.rotated[sessionID+"."+oldHash] = rotatedRefresh{pair: pair, graceUntil: now.Add(m.refreshGrace)}
+	return pair, nil
+}
+
+// Validate 验证访问令牌，并确认其所属会话仍然有效，返回令牌代表的用户身份
+func (m *SessionManager) Validate(ctx context.Context, tokenString string) (*Principal, error) {
+	claims, err := ValidateToken(m.secret, tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// 未绑定会话或缺少 sub 的旧 token 无法撤销，一律拒绝
+	if claims.SessionID == "" || claims.Subject == "" {
+		return nil, ErrSessionRevoked
+	}
+
+	session, err := m.store.GetSession(ctx, claims.SessionID)
+	if errors.Is(err, storage.ErrSessionNotFound) {
+		return nil, ErrSessionRevoked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get session failed: %v", err)
+	}
+	if !session.Active(time.Now()) || session.Subject != claims.Subject {
+		return nil, ErrSessionRevoked
+	}
+	return claims.Principal(), nil
+}
+
+// Logout 撤销单个会话，该会话签发的访问令牌与刷新令牌立即失效
+func (m *SessionManager) Logout(ctx context.Context, sessionID string) error {
+	err := m.store.RevokeSession(ctx, sessionID)
+	if err != nil && !errors.Is(err, storage.ErrSessionNotFound) {
+		return fmt.Errorf("revoke session failed: %v", err)
+	}
+	return nil
+}
+
+// LogoutAll 撤销用户在所有设备上的会话，返回撤销数量
+func (m *SessionManager) LogoutAll(ctx context.Context, subject string) (int64, error) {
+	revoked, err := m.store.RevokeUserSessions(ctx, subject)
+	if err != nil {
+		return 0, fmt.Errorf("revoke user sessions failed: %v", err)
+	}
+	return revoked, nil
+}
+
+// issue 为会话签发访问令牌，刷新令牌格式为 <会话ID>.<随机串>
+func (m *SessionManager) issue(session *storage.Session, secret string) (*TokenPair, error) {
+	principal := &Principal{
+		Subject:   session.Subject,
+		Platform:  session.Platform,
+		UserID:    session.UserID,
+		UnionID:   session.UnionID,
+		OpenID:    session.OpenID,
+		CorpID:    session.CorpID,
+		Name:      session.Name,
+		SessionID: session.ID,
+	}
+	accessToken, accessExpiresAt, err := GenerateToken(m.secret, principal, m.accessTTL)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token failed: %v", err)
+	}
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     session.ID + "." + secret,
+		RefreshExpiresAt: session.ExpiresAt.Unix(),
+	}, nil
+}
+
+// hashSecret 刷新令牌只以哈希形式落库
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/storage"
+)
+
+var testSecret = []byte("test-jwt-secret")
+
+func newTestSessionManager() *SessionManager {
+	return NewSessionManager(storage.NewMemoryStore(), &models.AuthConfig{
+		JWTSecret:       string(testSecret),
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+}
+
+func dingtalkUser(userID, name string) *Principal {
+	return NewPrincipal(PlatformDingTalk, &models.User{UserID: userID, Name: name, CorpID: "corp-1"})
+}
+
+func TestSessionRefreshRotatesToken(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSessionManager()
+
+	pair, err := m.Login(ctx, dingtalkUser("user-1", "张三"))
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	principal, err := m.Validate(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if principal.Subject != "dingtalk:corp-1:user-1" || principal.PlatformUserID() != "user-1" || principal.SessionID == "" {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+	claims, _ := ValidateToken(testSecret, pair.AccessToken)
+
+	refreshed, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if refreshed.RefreshToken == pair.RefreshToken || refreshed.AccessToken == pair.AccessToken {
+		t.Fatal("refresh should issue new tokens")
+	}
+	if _, err := m.Validate(ctx, refreshed.AccessToken); err != nil {
+		t.Fatalf("Validate refreshed token failed: %v", err)
+	}
+	newClaims, _ := ValidateToken(testSecret, refreshed.AccessToken)
+	if newClaims.SessionID != claims.SessionID || newClaims.ID == claims.ID || newClaims.Subject != claims.Subject {
+		t.Fatalf("refreshed token should keep session and get a new jti: %+v", newClaims)
+	}
+}
+
+func TestSessionRefreshReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSessionManager()
+
+	m.refreshGrace = 0
+
+	pair, _ := m.Login(ctx, dingtalkUser("user-1", "张三"))
+	refreshed, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	// 宽限期过后旧刷新令牌被重放，整个会话作废
+	if _, err := m.Refresh(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected ErrInvalidRefreshToken on reuse, got %v", err)
+	}
+	if _, err := m.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected session revoked after reuse, got %v", err)
+	}
+	if _, err := m.Validate(ctx, refreshed.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("expected ErrSessionRevoked, got %v", err)
+	}
+
+	for _, token := range []string{"", "no-dot", "missing.secret"} {
+		if _, err := m.Refresh(ctx, token); !errors.Is(err, ErrInvalidRefreshToken) {
+			t.Fatalf("expected ErrInvalidRefreshToken for %q, got %v", token, err)
+		}
+	}
+}
+
+func TestSessionRefreshGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSessionManager()
+
+	pair, _ := m.Login(ctx, dingtalkUser("user-1", "张三"))
+	first, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	// 另一个标签页紧接着用同一刷新令牌刷新，拿到同一令牌对，会话保持有效
+	second, err := m.Refresh(ctx, pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("repeat refresh within grace period failed: %v", err)
+	}
+	if *second != *first {
+		t.Fatalf("repeat refresh should return the same pair: %+v %+v", second, first)
+	}
+	next, err := m.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("session should stay active after repeat refresh: %v", err)
+	}
+
+	// 会话退出后宽限期内的重复刷新同样失败
+	principal, _ := m.Validate(ctx, next.AccessToken)
+	if err := m.Logout(ctx, principal.SessionID); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := m.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected ErrInvalidRefreshToken after logout, got %v", err)
+	}
+}
+
+func TestSessionLogout(t *testing.T) {
+	ctx := context.Background()
+	m := newTestSessionManager()
+
+	laptop, _ := m.Login(ctx, dingtalkUser("user-1", "张三"))
+	phone, _ := m.Login(ctx, dingtalkUser("user-1", "张三"))
+	other, _ := m.Login(ctx, dingtalkUser("user-2", "李四"))
+
+	principal, _ := m.Validate(ctx, laptop.AccessToken)
+	if err := m.Logout(ctx, principal.SessionID); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := m.Validate(ctx, laptop.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("expected ErrSessionRevoked after logout, got %v", err)
+	}
+	if _, err := m.Refresh(ctx, laptop.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expected refresh to fail after logout, got %v", err)
+	}
+	if _, err := m.Validate(ctx, phone.AccessToken); err != nil {
+		t.Fatalf("other device should stay logged in: %v", err)
+	}
+
+	revoked, err := m.LogoutAll(ctx, principal.Subject)
+	if err != nil {
+		t.Fatalf("LogoutAll failed: %v", err)
+	}
+	if revoked != 1 {
+		t.Fatalf("expected 1 session revoked, got %d", revoked)
+	}
+	if _, err := m.Validate(ctx, phone.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("expected ErrSessionRevoked after logout-all, got %v", err)
+	}
+	if _, err := m.Validate(ctx, other.AccessToken); err != nil {
+		t.Fatalf("other user should stay logged in: %v", err)
+	}
+}
+
+func TestValidateRejectsTokenWithoutSession(t *testing.T) {
+	m := newTestSessionManager()
+	token, _, err := GenerateToken(testSecret, dingtalkUser("user-1", "张三"), time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if _, err := m.Validate(context.Background(), token); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("expected ErrSessionRevoked, got %v", err)
+	}
+}
+
+func TestPrincipalIdentifiers(t *testing.T) {
+	feishu := NewPrincipal(PlatformFeishu, &models.User{OpenID: "ou_1", UserID: "u_1", UnionID: "on_1", CorpID: "tenant-1"})
+	if feishu.Subject != "feishu:tenant-1:ou_1" || feishu.PlatformUserID() != "ou_1" {
+		t.Fatalf("unexpected feishu principal: %+v", feishu)
+	}
+
+	// 同一个 userid 在不同企业、不同平台下是不同的用户
+	a := NewPrincipal(PlatformDingTalk, &models.User{UserID: "manager1", CorpID: "corp-a"})
+	b := NewPrincipal(PlatformDingTalk, &models.User{UserID: "manager1", CorpID: "corp-b"})
+	c := NewPrincipal(PlatformWeCom, &models.User{UserID: "manager1", CorpID: "corp-a"})
+	if a.Subject == b.Subject || a.Subject == c.Subject {
+		t.Fatalf("subjects should differ: %s %s %s", a.Subject, b.Subject, c.Subject)
+	}
+	if a.PlatformUserID() != "manager1" || c.PlatformUserID() != "manager1" {
+		t.Fatal("dingtalk and wecom should query reports by userid")
+	}
+}
+
+func TestLoginClaimsLegacyDrafts(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore()
+	m := NewSessionManager(store, &models.AuthConfig{
+		JWTSecret:        string(testSecret),
+		AccessTokenTTL:   time.Minute,
+		RefreshTokenTTL:  time.Hour,
+		LegacyDraftCorps: []string{"dingtalk:corp-1"},
+	})
+
+	legacy := storage.LegacyDraftOwner(PlatformDingTalk, "user-1")
+	if _, err := store.SaveDraft(ctx, legacy, "tpl-1", `{}`, 0); err != nil {
+		t.Fatalf("SaveDraft failed: %v", err)
+	}
+
+	// 其他企业中同一 userid 的用户不能认领
+	other := NewPrincipal(PlatformDingTalk, &models.User{UserID: "user-1", CorpID: "corp-2"})
+	if _, err := m.Login(ctx, other); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if _, err := store.GetDraft(ctx, other.Subject, "tpl-1"); !errors.Is(err, storage.ErrNotFound) {
+		t.Fatalf("legacy draft should not be claimed by another corp, got %v", err)
+	}
+
+	if _, err := m.Login(ctx, dingtalkUser("user-1", "张三")); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if _, err := store.GetDraft(ctx, "dingtalk:corp-1:user-1", "tpl-1"); err != nil {
+		t.Fatalf("expected legacy draft to be claimed, got %v", err)
+	}
+}
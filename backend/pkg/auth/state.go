@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hellodeveye/report/internal/storage"
+)
+
+var (
+	// ErrInvalidState state 缺失、伪造、已被使用或不属于当前登录平台
+	ErrInvalidState = errors.New("invalid oauth state")
+	// ErrStateExpired state 已超过有效期
+	ErrStateExpired = errors.New("oauth state expired")
+)
+
+// StateManager 签发并校验 OAuth 登录的 state，防止登录 CSRF。
+// state 为服务端保存的随机串，带有效期且只能使用一次。
+type StateManager struct {
+	store storage.OAuthStateStore
+	ttl   time.Duration
+}
+
+// NewStateManager 创建 state 管理器，ttl 为 state 的有效期
+func NewStateManager(store storage.OAuthStateStore, ttl time.Duration) *StateManager {
+	return &StateManager{store: store, ttl: ttl}
+}
+
+// Issue 为指定登录平台签发新的 state
+func (m *StateManager) Issue(ctx context.Context, platform string) (string, error) {
+	state, err := randomHex(32)
+	if err != nil {
+		return "", fmt.Errorf("generate oauth state failed: %v", err)
+	}
+
+	now := time.Now()
+	if err := m.store.SaveOAuthState(ctx, &storage.OAuthState{
+		State:     state,
+		Platform:  platform,
+		CreatedAt: now,
+		ExpiresAt: now.Add(m.ttl),
+	}); err != nil {
+		return "", fmt.Errorf("save oauth state failed: %v", err)
+	}
+	return state, nil
+}
+
+// Consume 校验并作废 state，无论校验是否通过 state 都不能再次使用
+func (m *StateManager) Consume(ctx context.Context, state, platform string) error {
+	if state == "" {
+		return ErrInvalidState
+	}
+
+	stored, err := m.store.ConsumeOAuthState(ctx, state)
+	if errors.Is(err, storage.ErrOAuthStateNotFound) {
+		return ErrInvalidState
+	}
+	if err != nil {
+		return fmt.Errorf("consume oauth state failed: %v", err)
+	}
+	if stored.Platform != platform {
+		return ErrInvalidState
+	}
+	if !time.Now().Before(stored.ExpiresAt) {
+		return ErrStateExpired
+	}
+	return nil
+}
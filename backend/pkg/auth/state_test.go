@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/storage"
+)
+
+func TestStateConsumeOnce(t *testing.T) {
+	ctx := context.Background()
+	m := NewStateManager(storage.NewMemoryStore(), time.Minute)
+
+	state, err := m.Issue(ctx, PlatformDingTalk)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if len(state) != 64 {
+		t.Fatalf("expected 32 random bytes in hex, got %q", state)
+	}
+	if err := m.Consume(ctx, state, PlatformDingTalk); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	// 重放
+	if err := m.Consume(ctx, state, PlatformDingTalk); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState on reuse, got %v", err)
+	}
+}
+
+func TestStateRejectsForgedAndMismatched(t *testing.T) {
+	ctx := context.Background()
+	m := NewStateManager(storage.NewMemoryStore(), time.Minute)
+
+	for _, forged := range []string{"", "1718000000000000000", "deadbeef"} {
+		if err := m.Consume(ctx, forged, PlatformDingTalk); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("expected ErrInvalidState for %q, got %v", forged, err)
+		}
+	}
+
+	// 飞书签发的 state 不能用于钉钉登录，且校验失败后同样作废
+	state, _ := m.Issue(ctx, PlatformFeishu)
+	if err := m.Consume(ctx, state, PlatformDingTalk); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState on platform mismatch, got %v", err)
+	}
+	if err := m.Consume(ctx, state, PlatformFeishu); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected state to be consumed after mismatch, got %v", err)
+	}
+}
+
+func TestStateExpired(t *testing.T) {
+	ctx := context.Background()
+	m := NewStateManager(storage.NewMemoryStore(), -time.Second)
+
+	state, _ := m.Issue(ctx, PlatformWeCom)
+	if err := m.Consume(ctx, state, PlatformWeCom); !errors.Is(err, ErrStateExpired) {
+		t.Fatalf("expected ErrStateExpired, got %v", err)
+	}
+}
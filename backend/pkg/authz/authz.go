@@ -0,0 +1,243 @@
+// Package authz 根据用户在组织中的角色判断其能否访问他人的数据，并审计每一次拒绝
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// ErrForbidden 当前用户的角色不覆盖要访问的用户或部门
+var ErrForbidden = errors.New("forbidden")
+
+// Role 数据访问角色
+type Role string
+
+const (
+	// RoleMember 普通成员，只能访问本人的数据
+	RoleMember Role = "member"
+	// RoleTeamLead 部门主管，可访问所主管部门成员及直属下级的数据
+	RoleTeamLead Role = "team_lead"
+	// RoleAdmin 企业管理员或白名单用户，可访问全企业的数据
+	RoleAdmin Role = "admin"
+)
+
+// Grant 用户的角色及其主管的部门
+type Grant struct {
+	Role        Role
+	Departments []string
+}
+
+// Denial 一次被拒绝的访问
+type Denial struct {
+	Subject string
+	Role    Role
+	Action  string
+	Target  string
+	Reason  string
+}
+
+// AuditFunc 记录被拒绝的访问
+type AuditFunc func(ctx context.Context, denial Denial)
+
+// LogAudit 默认的审计方式，以 audit=true 的 warn 日志写入，携带请求 ID
+func LogAudit(ctx context.Context, denial Denial) {
+	slog.WarnContext(ctx, "Access denied",
+		"audit", true,
+		"subject", denial.Subject,
+		"role", denial.Role,
+		"action", denial.Action,
+		"target", denial.Target,
+		"reason", denial.Reason,
+	)
+}
+
+type cacheEntry struct {
+	membership platform.Membership
+	expiresAt  time.Time
+}
+
+// Authorizer 数据访问授权服务，组织关系按用户缓存 ttl 时长
+type Authorizer struct {
+	providers map[string]platform.RoleProvider
+	admins    map[string]bool
+	ttl       time.Duration
+	audit     AuditFunc
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	// sweepAt 下次清理过期缓存的时间，避免离职、调岗用户的缓存一直占用内存
+	sweepAt time.Time
+}
+
+// NewAuthorizer 创建授权服务。providers 按平台名称索引，没有 provider 的平台用户只能访问本人的数据；
+// admins 为管理员白名单，元素为用户的 Principal.Subject；audit 为 nil 时使用 LogAudit
+func NewAuthorizer(providers map[string]platform.RoleProvider, admins []string, ttl time.Duration, audit AuditFunc) *Authorizer {
+	if audit == nil {
+		audit = LogAudit
+	}
+	allowlist := make(map[string]bool, len(admins))
+	for _, subject := range admins {
+		allowlist[subject] = true
+	}
+	return &Authorizer{
+		providers: providers,
+		admins:    allowlist,
+		ttl:       ttl,
+		audit:     audit,
+		now:       time.Now,
+		cache:     make(map[string]cacheEntry),
+	}
+}
+
+// Grant 返回用户的角色：白名单或平台管理员为 admin，担任部门主管为 team_lead，其余为 member
+func (a *Authorizer) Grant(ctx context.Context, principal *auth.Principal) (*Grant, error) {
+	membership, err := a.membership(ctx, principal, principal.PlatformUserID())
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case a.admins[principal.Subject] || membership.Admin:
+		return &Grant{Role: RoleAdmin}, nil
+	case len(membership.LedDepartments) > 0:
+		return &Grant{Role: RoleTeamLead, Departments: membership.LedDepartments}, nil
+	}
+	return &Grant{Role: RoleMember}, nil
+}
+
+// AuthorizeUser 判断当前用户能否在 action 中访问平台 userPlatform 上 userID 的数据。
+// 角色只在用户自己的平台内有效，其他平台的用户一律拒绝；
+// 本人与管理员总是允许；部门主管只能访问所主管部门的成员及直属下级，不包含子部门
+func (a *Authorizer) AuthorizeUser(ctx context.Context, principal *auth.Principal, action, userPlatform, userID string) error {
+	if principal.Platform != userPlatform {
+		grant, err := a.Grant(ctx, principal)
+		if err != nil {
+			return err
+		}
+		return a.deny(ctx, principal, grant, action, "user:"+userID, "user belongs to another platform")
+	}
+	if userID == principal.PlatformUserID() {
+		return nil
+	}
+	grant, err := a.Grant(ctx, principal)
+	if err != nil {
+		return err
+	}
+	switch grant.Role {
+	case RoleAdmin:
+		return nil
+	case RoleTeamLead:
+		target, err := a.membership(ctx, principal, userID)
+		if err != nil {
+			return err
+		}
+		if target.ManagerID == principal.PlatformUserID() || overlaps(target.Departments, grant.Departments) {
+			return nil
+		}
+		return a.deny(ctx, principal, grant, action, "user:"+userID, "user is not in led departments")
+	}
+	return a.deny(ctx, principal, grant, action, "user:"+userID, "members can only access their own data")
+}
+
+// AuthorizeDepartment 判断当前用户能否在 action 中访问部门 deptID 的数据。
+// 管理员总是允许；部门主管只能访问自己主管的部门
+func (a *Authorizer) AuthorizeDepartment(ctx context.Context, principal *auth.Principal, action, deptID string) error {
+	grant, err := a.Grant(ctx, principal)
+	if err != nil {
+		return err
+	}
+	switch grant.Role {
+	case RoleAdmin:
+		return nil
+	case RoleTeamLead:
+		if slices.Contains(grant.Departments, deptID) {
+			return nil
+		}
+		return a.deny(ctx, principal, grant, action, "dept:"+deptID, "department is not led by user")
+	}
+	return a.deny(ctx, principal, grant, action, "dept:"+deptID, "members cannot access department data")
+}
+
+// AuthorizeOrganization 判断当前用户能否在 action 中访问全企业的数据，只允许管理员
+func (a *Authorizer) AuthorizeOrganization(ctx context.Context, principal *auth.Principal, action string) error {
+	grant, err := a.Grant(ctx, principal)
+	if err != nil {
+		return err
+	}
+	if grant.Role == RoleAdmin {
+		return nil
+	}
+	return a.deny(ctx, principal, grant, action, "corp:"+principal.CorpID, "only admins can access organization data")
+}
+
+// deny 审计被拒绝的访问并返回 ErrForbidden
+func (a *Authorizer) deny(ctx context.Context, principal *auth.Principal, grant *Grant, action, target, reason string) error {
+	a.audit(ctx, Denial{
+		Subject: principal.Subject,
+		Role:    grant.Role,
+		Action:  action,
+		Target:  target,
+		Reason:  reason,
+	})
+	return fmt.Errorf("%w: %s is not allowed to access %s", ErrForbidden, action, target)
+}
+
+// membership 查询与 principal 同一平台、同一企业下 userID 的组织关系
+func (a *Authorizer) membership(ctx context.Context, principal *auth.Principal, userID string) (*platform.Membership, error) {
+	provider, ok := a.providers[principal.Platform]
+	if !ok {
+		return &platform.Membership{}, nil
+	}
+
+	key := principal.Platform + ":" + principal.CorpID + ":" + userID
+	now := a.now()
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		membership := entry.membership
+		return &membership, nil
+	}
+
+	membership, err := provider.GetMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if a.ttl > 0 {
+		a.mu.Lock()
+		a.evictExpired(now)
+		a.cache[key] = cacheEntry{membership: *membership, expiresAt: now.Add(a.ttl)}
+		a.mu.Unlock()
+	}
+	return membership, nil
+}
+
+// evictExpired 每隔 ttl 删除一次过期的缓存，调用方需持有 mu
+func (a *Authorizer) evictExpired(now time.Time) {
+	if now.Before(a.sweepAt) {
+		return
+	}
+	for key, entry := range a.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(a.cache, key)
+		}
+	}
+	a.sweepAt = now.Add(a.ttl)
+}
+
+func overlaps(a, b []string) bool {
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,178 @@
+package authz_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/authz"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+func newTestAuthorizer(t *testing.T, admins []string) (*authz.Authorizer, *[]authz.Denial) {
+	t.Helper()
+	server := dingtalktest.NewServer()
+	t.Cleanup(server.Close)
+
+	server.AddUser(dingtalktest.User{UserID: "admin", DeptIDs: []int64{1}, Admin: true})
+	server.AddUser(dingtalktest.User{UserID: "lead", DeptIDs: []int64{100, 300}, LeaderDeptIDs: []int64{100}})
+	server.AddUser(dingtalktest.User{UserID: "member", DeptIDs: []int64{100}, ManagerUserID: "lead"})
+	server.AddUser(dingtalktest.User{UserID: "report", DeptIDs: []int64{300}, ManagerUserID: "lead"})
+	server.AddUser(dingtalktest.User{UserID: "peer", DeptIDs: []int64{300}})
+	server.AddUser(dingtalktest.User{UserID: "outsider", DeptIDs: []int64{200}})
+
+	var denials []authz.Denial
+	authorizer := authz.NewAuthorizer(map[string]platform.RoleProvider{
+		auth.PlatformDingTalk: dingtalk.NewRoleProvider(dingtalk.NewClient(server.Config())),
+	}, admins, time.Minute, func(ctx context.Context, denial authz.Denial) {
+		denials = append(denials, denial)
+	})
+	return authorizer, &denials
+}
+
+func dingtalkPrincipal(userID string) *auth.Principal {
+	return auth.NewPrincipal(auth.PlatformDingTalk, &models.User{UserID: userID, CorpID: dingtalktest.CorpID})
+}
+
+func TestGrantRoles(t *testing.T) {
+	allowlisted := dingtalkPrincipal("outsider")
+	authorizer, _ := newTestAuthorizer(t, []string{allowlisted.Subject})
+
+	tests := []struct {
+		principal *auth.Principal
+		role      authz.Role
+	}{
+		{dingtalkPrincipal("admin"), authz.RoleAdmin},
+		{allowlisted, authz.RoleAdmin},
+		{dingtalkPrincipal("lead"), authz.RoleTeamLead},
+		{dingtalkPrincipal("member"), authz.RoleMember},
+		{auth.NewPrincipal(auth.PlatformFeishu, &models.User{OpenID: "ou_1"}), authz.RoleMember},
+	}
+	for _, tt := range tests {
+		grant, err := authorizer.Grant(context.Background(), tt.principal)
+		if err != nil {
+			t.Fatalf("Grant(%s) failed: %v", tt.principal.Subject, err)
+		}
+		if grant.Role != tt.role {
+			t.Errorf("Grant(%s) = %s, want %s", tt.principal.Subject, grant.Role, tt.role)
+		}
+	}
+}
+
+func TestAuthorizeUser(t *testing.T) {
+	authorizer, denials := newTestAuthorizer(t, nil)
+
+	tests := []struct {
+		name    string
+		userID  string
+		target  string
+		allowed bool
+	}{
+		{"member reads own data", "member", "member", true},
+		{"member reads lead", "member", "lead", false},
+		{"lead reads led department member", "lead", "member", true},
+		{"lead reads direct report outside led department", "lead", "report", true},
+		{"lead reads peer in non-led department", "lead", "peer", false},
+		{"lead reads outsider", "lead", "outsider", false},
+		{"lead reads unknown user", "lead", "ghost", false},
+		{"admin reads anyone", "admin", "outsider", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(*denials)
+			err := authorizer.AuthorizeUser(context.Background(), dingtalkPrincipal(tt.userID), "dingtalkTemplates", auth.PlatformDingTalk, tt.target)
+			if tt.allowed {
+				if err != nil {
+					t.Fatalf("expected allowed, got %v", err)
+				}
+				if len(*denials) != before {
+					t.Fatal("allowed access should not be audited")
+				}
+				return
+			}
+			if !errors.Is(err, authz.ErrForbidden) {
+				t.Fatalf("expected ErrForbidden, got %v", err)
+			}
+			if len(*denials) != before+1 {
+				t.Fatalf("expected denial to be audited, got %d records", len(*denials)-before)
+			}
+			denial := (*denials)[len(*denials)-1]
+			if denial.Subject != dingtalkPrincipal(tt.userID).Subject || denial.Action != "dingtalkTemplates" || denial.Target != "user:"+tt.target {
+				t.Fatalf("unexpected denial: %+v", denial)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserRejectsOtherPlatforms(t *testing.T) {
+	wecomAdmin := auth.NewPrincipal(auth.PlatformWeCom, &models.User{UserID: "outsider", CorpID: "wecom-corp"})
+	authorizer, denials := newTestAuthorizer(t, []string{wecomAdmin.Subject})
+
+	// 其他平台的管理员、userid 恰好相同的用户都不能访问钉钉用户的数据
+	for _, target := range []string{"member", "outsider"} {
+		err := authorizer.AuthorizeUser(context.Background(), wecomAdmin, "dingtalkTemplates", auth.PlatformDingTalk, target)
+		if !errors.Is(err, authz.ErrForbidden) {
+			t.Fatalf("%s: expected ErrForbidden, got %v", target, err)
+		}
+	}
+	if len(*denials) != 2 || (*denials)[0].Role != authz.RoleAdmin {
+		t.Fatalf("expected denials to be audited, got %+v", *denials)
+	}
+}
+
+func TestAuthorizeDepartment(t *testing.T) {
+	authorizer, denials := newTestAuthorizer(t, nil)
+
+	tests := []struct {
+		userID  string
+		deptID  string
+		allowed bool
+	}{
+		{"admin", "200", true},
+		{"lead", "100", true},
+		{"lead", "300", false},
+		{"member", "100", false},
+	}
+	for _, tt := range tests {
+		err := authorizer.AuthorizeDepartment(context.Background(), dingtalkPrincipal(tt.userID), "teamReports", tt.deptID)
+		if tt.allowed && err != nil {
+			t.Errorf("%s -> dept %s: expected allowed, got %v", tt.userID, tt.deptID, err)
+		}
+		if !tt.allowed && !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("%s -> dept %s: expected ErrForbidden, got %v", tt.userID, tt.deptID, err)
+		}
+	}
+	if len(*denials) != 2 {
+		t.Fatalf("expected 2 audited denials, got %d", len(*denials))
+	}
+}
+
+func TestAuthorizeOrganization(t *testing.T) {
+	authorizer, denials := newTestAuthorizer(t, nil)
+
+	tests := []struct {
+		userID  string
+		allowed bool
+	}{
+		{"admin", true},
+		{"lead", false},
+		{"member", false},
+	}
+	for _, tt := range tests {
+		err := authorizer.AuthorizeOrganization(context.Background(), dingtalkPrincipal(tt.userID), "wecomJournalStats")
+		if tt.allowed && err != nil {
+			t.Errorf("%s: expected allowed, got %v", tt.userID, err)
+		}
+		if !tt.allowed && !errors.Is(err, authz.ErrForbidden) {
+			t.Errorf("%s: expected ErrForbidden, got %v", tt.userID, err)
+		}
+	}
+	if len(*denials) != 2 || (*denials)[0].Target != "corp:"+dingtalktest.CorpID {
+		t.Fatalf("unexpected denials: %+v", *denials)
+	}
+}
@@ -0,0 +1,54 @@
+package authz
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// countingRoles 统计组织关系查询次数的 RoleProvider
+type countingRoles struct {
+	calls int
+}
+
+func (r *countingRoles) GetMembership(ctx context.Context, userID string) (*platform.Membership, error) {
+	r.calls++
+	return &platform.Membership{Departments: []string{"100"}}, nil
+}
+
+func TestMembershipCacheExpiresAndEvicts(t *testing.T) {
+	roles := &countingRoles{}
+	a := NewAuthorizer(map[string]platform.RoleProvider{auth.PlatformDingTalk: roles}, nil, time.Minute, nil)
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	principal := func(userID string) *auth.Principal {
+		return auth.NewPrincipal(auth.PlatformDingTalk, &models.User{UserID: userID, CorpID: "corp"})
+	}
+	ctx := context.Background()
+
+	for _, userID := range []string{"user-1", "user-2", "user-1"} {
+		if _, err := a.Grant(ctx, principal(userID)); err != nil {
+			t.Fatalf("Grant failed: %v", err)
+		}
+	}
+	if roles.calls != 2 {
+		t.Fatalf("expected 2 lookups within ttl, got %d", roles.calls)
+	}
+
+	// 过期后重新查询，并清理其他用户的过期缓存
+	now = now.Add(time.Minute)
+	if _, err := a.Grant(ctx, principal("user-1")); err != nil {
+		t.Fatalf("Grant failed: %v", err)
+	}
+	if roles.calls != 3 {
+		t.Fatalf("expected expired entry to be refreshed, got %d lookups", roles.calls)
+	}
+	if len(a.cache) != 1 {
+		t.Fatalf("expected expired entries to be evicted, cache has %d entries", len(a.cache))
+	}
+}
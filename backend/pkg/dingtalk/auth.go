@@ -1,9 +1,9 @@
 package dingtalk
 
 import (
+	"context"
 	"fmt"
 	"net/url"
-	"time"
 
 	"github.com/hellodeveye/report/internal/models"
 )
@@ -14,19 +14,21 @@ type AuthService struct {
 	config *models.DingTalkConfig
 }
 
-// NewAuthService 创建新的钉钉认证服务
-func NewAuthService(config *models.DingTalkConfig) *AuthService {
+// NewAuthService 创建新的钉钉认证服务，与其他服务共用同一个客户端以共享access_token缓存
+func NewAuthService(client *Client) *AuthService {
 	return &AuthService{
-		client: NewClient(config),
-		config: config,
+		client: client,
+		config: client.config,
 	}
 }
 
-// GenerateAuthURL 生成授权URL
-func (s *AuthService) GenerateAuthURL() (string, string, error) {
-	// 生成state参数防CSRF
-	state := fmt.Sprintf("%d", time.Now().UnixNano())
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	return &AuthService{client: s.client.WithContext(ctx), config: s.config}
+}
 
+// GenerateAuthURL 生成授权URL，state 由调用方签发并在回调时校验
+func (s *AuthService) GenerateAuthURL(state string) string {
 	// 构建授权URL
 	authURL := fmt.Sprintf("https://login.dingtalk.com/oauth2/auth?redirect_uri=%s&response_type=code&client_id=%s&scope=openid corpid&state=%s&prompt=consent&corpId=%s",
 		url.QueryEscape(s.config.RedirectURI),
@@ -35,7 +37,7 @@ func (s *AuthService) GenerateAuthURL() (string, string, error) {
 		s.config.CorpId,
 	)
 
-	return authURL, state, nil
+	return authURL
 }
 
 // ExchangeCodeForUser 用授权码换取用户信息
@@ -52,12 +54,7 @@ func (s *AuthService) ExchangeCodeForUser(code string) (*models.User, error) {
 		return nil, fmt.Errorf("failed to get user info: %v", err)
 	}
 
-	accessToken, err := s.client.GetAccessToken()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get access token: %v", err)
-	}
-
-	userByUnionIdResp, err := s.client.GetUserByUnionId(accessToken.AccessToken, userResp.UnionId)
+	userByUnionIdResp, err := s.client.GetUserByUnionId(userResp.UnionId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user by union id: %v", err)
 	}
@@ -65,6 +62,10 @@ func (s *AuthService) ExchangeCodeForUser(code string) (*models.User, error) {
 
 	// 3. 转换为统一的用户模型
 	user := s.convertToUser(userResp)
+	user.CorpID = tokenResp.CorpId
+	if user.CorpID == "" {
+		user.CorpID = s.config.CorpId
+	}
 	return user, nil
 }
 
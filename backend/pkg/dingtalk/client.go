@@ -1,14 +1,19 @@
 package dingtalk
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/tokencache"
+	"github.com/hellodeveye/report/pkg/logging"
 	"resty.dev/v3"
 )
 
@@ -16,33 +21,173 @@ import (
 type Client struct {
 	config     *models.DingTalkConfig
 	httpClient *resty.Client
+	tokens     *tokencache.Cache[*models.DingTalkAccessTokenResponse]
+	// ctx 接口调用使用的 context，见 WithContext
+	ctx context.Context
 }
 
 // NewClient 创建新的钉钉客户端
 func NewClient(config *models.DingTalkConfig) *Client {
-	return &Client{
+	c := &Client{
 		config:     config,
-		httpClient: resty.New().SetTimeout(30 * time.Second).EnableDebug(),
+		httpClient: logging.NewHTTPClient(30 * time.Second),
 	}
+	c.tokens = tokencache.New(c.fetchAccessToken)
+	c.tokens.OnLookup = observeTokenLookup
+	return c
 }
 
+// WithContext 返回使用 ctx 调用接口的客户端副本，共享连接与 access_token 缓存。
+// 请求随 ctx 取消，ctx 中的请求 ID 与链路分别通过 X-Request-ID、traceparent 头传给钉钉
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// request 创建使用 ctx 的请求，ctx 通常来自 startAPICall
+func (c *Client) request(ctx context.Context) *resty.Request {
+	return c.httpClient.R().SetContext(ctx)
+}
+
+// 未配置时使用的钉钉接口地址
+const (
+	defaultOapiBaseURL = "https://oapi.dingtalk.com"
+	defaultAPIBaseURL  = "https://api.dingtalk.com"
+)
+
+// oapiURL 拼接旧版oapi接口地址
+func (c *Client) oapiURL(path string) string {
+	return joinURL(c.config.BaseURL, defaultOapiBaseURL, path)
+}
+
+// apiURL 拼接新版api接口地址
+func (c *Client) apiURL(path string) string {
+	return joinURL(c.config.APIBaseURL, defaultAPIBaseURL, path)
+}
+
+func joinURL(baseURL, defaultBaseURL, path string) string {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
+
+// GetAccessToken 获取企业内部应用access_token，优先使用缓存
 func (c *Client) GetAccessToken() (*models.DingTalkAccessTokenResponse, error) {
-	url := "https://oapi.dingtalk.com/gettoken"
-	resp, err := c.httpClient.R().
+	return c.tokens.Get(c.context())
+}
+
+// InvalidateAccessToken 使缓存的access_token失效，下次调用时重新获取
+func (c *Client) InvalidateAccessToken(accessToken string) {
+	c.tokens.Invalidate(accessToken)
+}
+
+// fetchAccessToken 向钉钉请求新的access_token，由缓存统一调用。
+// ctx 只用于延续触发刷新的请求的链路，不会随该请求取消
+func (c *Client) fetchAccessToken(ctx context.Context) (accessTokenEntry, error) {
+	var entry accessTokenEntry
+	url := c.oapiURL("/gettoken")
+	call := startAPICall(ctx, "/gettoken")
+	resp, err := c.request(call.ctx).
 		SetQueryParam("appkey", c.config.AppKey).
 		SetQueryParam("appsecret", c.config.AppSecret).
 		SetResult(&models.DingTalkAccessTokenResponse{}).
 		Get(url)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
+		call.finish(resultRequestError)
+		return entry, fmt.Errorf("request failed: %v", err)
+	}
+
+	tokenResp := resp.Result().(*models.DingTalkAccessTokenResponse)
+	call.finish(strconv.Itoa(tokenResp.ErrCode))
+	if tokenResp.ErrCode != 0 {
+		return entry, &APIError{Endpoint: "/gettoken", ErrCode: tokenResp.ErrCode, ErrMsg: tokenResp.ErrMsg}
 	}
 
-	return resp.Result().(*models.DingTalkAccessTokenResponse), nil
+	return accessTokenEntry{
+		Token:     tokenResp,
+		Value:     tokenResp.AccessToken,
+		ExpiresIn: time.Duration(tokenResp.ExpiresIn) * time.Second,
+	}, nil
+}
+
+// oapiStatus 旧版oapi接口响应中的通用状态字段
+type oapiStatus struct {
+	ErrCode   int    `json:"errcode"`
+	ErrMsg    string `json:"errmsg"`
+	RequestID string `json:"request_id"`
+}
+
+// postWithToken 携带企业access_token调用旧版oapi接口并解析响应，
+// access_token失效时刷新后重试一次，非2xx响应返回状态码错误，errcode非0时返回*APIError
+func (c *Client) postWithToken(path string, requestBody interface{}, result interface{}) error {
+	url := c.oapiURL(path)
+	for attempt := 0; ; attempt++ {
+		accessToken, err := c.GetAccessToken()
+		if err != nil {
+			return err
+		}
+
+		call := startAPICall(c.context(), path)
+		resp, err := c.request(call.ctx).SetBody(requestBody).Post(url + "?access_token=" + accessToken.AccessToken)
+		if err != nil {
+			call.finish(resultRequestError)
+			return fmt.Errorf("request failed: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			call.finish(resultRequestError)
+			return fmt.Errorf("read response body failed: %v", err)
+		}
+		// 网关错误等非 2xx 响应的响应体通常不是 JSON，按状态码返回
+		if resp.IsError() {
+			call.finish(httpResult(resp.StatusCode()))
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode(), string(body))
+		}
+
+		var status oapiStatus
+		if err := json.Unmarshal(body, &status); err != nil {
+			call.finish(resultInvalidResponse)
+			slog.ErrorContext(c.context(), "Failed to unmarshal DingTalk response", "url", url, "body", string(body))
+			return fmt.Errorf("unmarshal response failed: %v", err)
+		}
+		call.finish(strconv.Itoa(status.ErrCode))
+
+		if isTokenExpiredCode(status.ErrCode) && attempt == 0 {
+			c.InvalidateAccessToken(accessToken.AccessToken)
+			continue
+		}
+
+		if status.ErrCode != 0 {
+			return &APIError{
+				Endpoint:  path,
+				ErrCode:   status.ErrCode,
+				ErrMsg:    status.ErrMsg,
+				RequestID: status.RequestID,
+			}
+		}
+
+		if err := json.Unmarshal(body, result); err != nil {
+			return fmt.Errorf("unmarshal response failed: %v", err)
+		}
+		return nil
+	}
 }
 
 // GetUserAccessToken 通过授权码获取用户访问令牌
 func (c *Client) GetUserAccessToken(code string) (*models.DingTalkOAuthTokenResponse, error) {
-	url := "https://api.dingtalk.com/v1.0/oauth2/userAccessToken"
+	const endpoint = "/v1.0/oauth2/userAccessToken"
+	url := c.apiURL(endpoint)
 
 	requestBody := map[string]string{
 		"clientId":     c.config.AppKey,
@@ -51,16 +196,20 @@ func (c *Client) GetUserAccessToken(code string) (*models.DingTalkOAuthTokenResp
 		"grantType":    "authorization_code",
 	}
 
-	resp, err := c.httpClient.R().SetBody(requestBody).Post(url)
+	call := startAPICall(c.context(), endpoint)
+	resp, err := c.request(call.ctx).SetBody(requestBody).Post(url)
 	if err != nil {
+		call.finish(resultRequestError)
 		return nil, fmt.Errorf("request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		call.finish(resultRequestError)
 		return nil, fmt.Errorf("read response body failed: %v", err)
 	}
+	call.finish(httpResult(resp.StatusCode()))
 
 	if resp.StatusCode() != http.StatusOK {
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode(), string(body))
@@ -76,18 +225,23 @@ func (c *Client) GetUserAccessToken(code string) (*models.DingTalkOAuthTokenResp
 
 // GetUserInfo 通过用户访问令牌获取用户信息
 func (c *Client) GetUserInfo(accessToken string) (*models.DingTalkUserInfoResponse, error) {
-	url := "https://api.dingtalk.com/v1.0/contact/users/me"
+	const endpoint = "/v1.0/contact/users/me"
+	url := c.apiURL(endpoint)
 
-	resp, err := c.httpClient.R().SetHeader("x-acs-dingtalk-access-token", accessToken).Get(url)
+	call := startAPICall(c.context(), endpoint)
+	resp, err := c.request(call.ctx).SetHeader("x-acs-dingtalk-access-token", accessToken).Get(url)
 	if err != nil {
+		call.finish(resultRequestError)
 		return nil, fmt.Errorf("request failed: %v", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		call.finish(resultRequestError)
 		return nil, fmt.Errorf("read response body failed: %v", err)
 	}
+	call.finish(httpResult(resp.StatusCode()))
 
 	if resp.StatusCode() != http.StatusOK {
 		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode(), string(body))
@@ -101,43 +255,15 @@ func (c *Client) GetUserInfo(accessToken string) (*models.DingTalkUserInfoRespon
 	return &userResp, nil
 }
 
-func (c *Client) GetUserByUnionId(accessToken string, unionId string) (*models.DingTalkUserByUnionIdResponse, error) {
-	url := "https://oapi.dingtalk.com/topapi/user/getbyunionid?access_token=" + accessToken
-
+// GetUserByUnionId 通过unionid获取企业内用户的userid
+func (c *Client) GetUserByUnionId(unionId string) (*models.DingTalkUserByUnionIdResponse, error) {
 	requestBody := map[string]string{
 		"unionid": unionId,
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request body failed: %v", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.R().SetBody(jsonData).Post(url)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response body failed: %v", err)
-	}
-
-	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode(), string(body))
-	}
-
 	var userResp models.DingTalkUserByUnionIdResponse
-	if err := json.Unmarshal(body, &userResp); err != nil {
-		return nil, fmt.Errorf("unmarshal response failed: %v", err)
+	if err := c.postWithToken("/topapi/user/getbyunionid", requestBody, &userResp); err != nil {
+		return nil, err
 	}
 
 	return &userResp, nil
@@ -1,17 +1,20 @@
-package dingtalk
+package dingtalk_test
 
 import (
-	"os"
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
-	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
 )
 
 func TestGetAccessToken(t *testing.T) {
-	client := NewClient(&models.DingTalkConfig{
-		AppKey:    os.Getenv("DINGTALK_APP_KEY"),
-		AppSecret: os.Getenv("DINGTALK_APP_SECRET"),
-	})
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	client := dingtalk.NewClient(server.Config())
 	accessToken, err := client.GetAccessToken()
 	if err != nil {
 		t.Fatalf("GetAccessToken failed: %v", err)
@@ -22,3 +25,147 @@ func TestGetAccessToken(t *testing.T) {
 	}
 	t.Logf("accessToken: %v", accessToken.AccessToken)
 }
+
+func TestExchangeCodeForUser(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	code := server.AddUser(dingtalktest.User{UserID: "user-1", UnionID: "union-1", OpenID: "open-1", Name: "张三"})
+	authService := dingtalk.NewAuthService(dingtalk.NewClient(server.Config()))
+
+	user, err := authService.ExchangeCodeForUser(code)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForUser failed: %v", err)
+	}
+	if user.UserID != "user-1" || user.UnionID != "union-1" || user.Name != "张三" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+}
+
+func TestWithContextCancelsRequests(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+	server.AddUser(dingtalktest.User{UserID: "user-1", Name: "张三"})
+
+	client := dingtalk.NewClient(server.Config())
+	contacts := dingtalk.NewContactService(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := contacts.WithContext(ctx).GetUser("user-1"); err == nil {
+		t.Fatal("expected canceled context to abort the request")
+	}
+	// access_token 缓存不受单个请求的 context 影响
+	if _, err := contacts.GetUser("user-1"); err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+}
+
+func TestReportServiceAgainstStub(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	server.AddTemplate(dingtalk.TemplateDetailResult{
+		ID:   "tpl-daily",
+		Name: "日报",
+		Fields: []dingtalk.Field{
+			{FieldName: "今日完成工作", Sort: 0, Type: 1},
+		},
+	})
+	service := dingtalk.NewReportService(dingtalk.NewClient(server.Config()))
+
+	templates, err := service.GetTemplates("user-1")
+	if err != nil {
+		t.Fatalf("GetTemplates failed: %v", err)
+	}
+	if len(templates.Result.TemplateList) != 1 || templates.Result.TemplateList[0].Name != "日报" {
+		t.Fatalf("unexpected templates: %+v", templates.Result)
+	}
+
+	createReq := dingtalk.CreateReportRequest{}
+	createReq.CreateReportParam.TemplateID = "tpl-daily"
+	createReq.CreateReportParam.UserID = "user-1"
+	createReq.CreateReportParam.Contents = []dingtalk.ContentItem{{Key: "今日完成工作", Content: "写代码"}}
+	created, err := service.Create("user-1", &createReq)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Result == "" {
+		t.Fatal("expected report id")
+	}
+
+	draft, err := service.SaveContent("user-1", dingtalk.SaveReportParam{
+		TemplateID: "tpl-daily",
+		UserID:     "user-1",
+		Contents:   []dingtalk.ContentItem{{Key: "今日完成工作", Content: "草稿内容"}},
+	})
+	if err != nil {
+		t.Fatalf("SaveContent failed: %v", err)
+	}
+	if drafts := server.SavedDrafts(); draft.Result == "" || len(drafts) != 1 || drafts[0].Contents[0].Content != "草稿内容" {
+		t.Fatalf("unexpected draft %q: %+v", draft.Result, drafts)
+	}
+
+	reports, err := service.GetReports("user-1", "日报", time.Now().Add(-24*time.Hour).Unix(), time.Now().Add(time.Minute).Unix(), 0, 20)
+	if err != nil {
+		t.Fatalf("GetReports failed: %v", err)
+	}
+	if len(reports.Result.DataList) != 1 || reports.Result.DataList[0].ReportID != created.Result {
+		t.Fatalf("unexpected reports: %+v", reports.Result)
+	}
+}
+
+func TestExpiredTokenIsRefreshed(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	service := dingtalk.NewReportService(dingtalk.NewClient(server.Config()))
+	if _, err := service.GetTemplates("user-1"); err != nil {
+		t.Fatalf("GetTemplates failed: %v", err)
+	}
+
+	server.ExpireTokens()
+	if _, err := service.GetTemplates("user-1"); err != nil {
+		t.Fatalf("GetTemplates after expiry failed: %v", err)
+	}
+	if got := server.TokenRequests(); got != 2 {
+		t.Fatalf("expected 2 gettoken calls, got %d", got)
+	}
+}
+
+func TestListAllReportsFollowsCursorAcrossWindows(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	// 一年内每天一篇日报，需要跨多个180天区间并多次翻页
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for day := 0; day < 365; day++ {
+		server.AddReport(dingtalk.ReportData{
+			ReportID:     fmt.Sprintf("report-%03d", day),
+			CreatorID:    "user-1",
+			TemplateName: "日报",
+			CreateTime:   start.AddDate(0, 0, day).UnixMilli(),
+		})
+	}
+	service := dingtalk.NewReportService(dingtalk.NewClient(server.Config()))
+
+	reports, err := service.ListAllReports("user-1", "日报", start.Unix(), start.AddDate(1, 0, 0).Unix())
+	if err != nil {
+		t.Fatalf("ListAllReports failed: %v", err)
+	}
+	if len(reports) != 365 {
+		t.Fatalf("expected 365 reports, got %d", len(reports))
+	}
+
+	var count int
+	err = service.IterateReports("user-1", "日报", start.Unix(), start.AddDate(1, 0, 0).Unix(), func(dingtalk.ReportData) bool {
+		count++
+		return count < 25
+	})
+	if err != nil {
+		t.Fatalf("IterateReports failed: %v", err)
+	}
+	if count != 25 {
+		t.Fatalf("expected iteration to stop at 25, got %d", count)
+	}
+}
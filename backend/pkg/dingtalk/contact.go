@@ -0,0 +1,136 @@
+package dingtalk
+
+import "context"
+
+// ContactService 钉钉通讯录服务
+type ContactService struct {
+	client *Client
+}
+
+// NewContactService 创建新的钉钉通讯录服务
+func NewContactService(client *Client) *ContactService {
+	return &ContactService{client: client}
+}
+
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *ContactService) WithContext(ctx context.Context) *ContactService {
+	return &ContactService{client: s.client.WithContext(ctx)}
+}
+
+type DeptLeader struct {
+	DeptID int64 `json:"dept_id"`
+	Leader bool  `json:"leader"`
+}
+
+type UserDetail struct {
+	UserID        string       `json:"userid"`
+	UnionID       string       `json:"unionid"`
+	Name          string       `json:"name"`
+	Avatar        string       `json:"avatar"`
+	Mobile        string       `json:"mobile"`
+	Email         string       `json:"email"`
+	OrgEmail      string       `json:"org_email"`
+	Title         string       `json:"title"`
+	DeptIDList    []int64      `json:"dept_id_list"`
+	LeaderInDept  []DeptLeader `json:"leader_in_dept"`
+	ManagerUserID string       `json:"manager_userid"`
+	Admin         bool         `json:"admin"`
+	Boss          bool         `json:"boss"`
+	Active        bool         `json:"active"`
+}
+
+type UserDetailResponse struct {
+	ErrCode   int        `json:"errcode"`
+	ErrMsg    string     `json:"errmsg"`
+	Result    UserDetail `json:"result"`
+	RequestID string     `json:"request_id"`
+}
+
+// GetUser 获取通讯录中的用户详情，用户不存在时返回 errcode 60121
+func (s *ContactService) GetUser(userID string) (*UserDetailResponse, error) {
+	requestBody := map[string]interface{}{
+		"userid":   userID,
+		"language": "zh_CN",
+	}
+
+	var response UserDetailResponse
+	if err := s.client.postWithToken("/topapi/v2/user/get", requestBody, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+type DepartmentDetail struct {
+	DeptID   int64  `json:"dept_id"`
+	Name     string `json:"name"`
+	ParentID int64  `json:"parent_id"`
+}
+
+type DepartmentDetailResponse struct {
+	ErrCode   int              `json:"errcode"`
+	ErrMsg    string           `json:"errmsg"`
+	Result    DepartmentDetail `json:"result"`
+	RequestID string           `json:"request_id"`
+}
+
+// GetDepartment 获取部门详情，部门不存在时返回 errcode 60003
+func (s *ContactService) GetDepartment(deptID int64) (*DepartmentDetailResponse, error) {
+	requestBody := map[string]interface{}{
+		"dept_id":  deptID,
+		"language": "zh_CN",
+	}
+
+	var response DepartmentDetailResponse
+	if err := s.client.postWithToken("/topapi/v2/department/get", requestBody, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+type DeptMember struct {
+	UserID string `json:"userid"`
+	Name   string `json:"name"`
+}
+
+type DeptMemberListResult struct {
+	HasMore    bool         `json:"has_more"`
+	NextCursor int64        `json:"next_cursor"`
+	List       []DeptMember `json:"list"`
+}
+
+type DeptMemberListResponse struct {
+	ErrCode   int                  `json:"errcode"`
+	ErrMsg    string               `json:"errmsg"`
+	Result    DeptMemberListResult `json:"result"`
+	RequestID string               `json:"request_id"`
+}
+
+// maxDeptMemberPageSize 部门用户列表接口单页最大条数
+const maxDeptMemberPageSize = 100
+
+// ListDeptMembers 获取部门的直属成员，自动翻页
+func (s *ContactService) ListDeptMembers(deptID int64) ([]DeptMember, error) {
+	var members []DeptMember
+	var cursor int64
+	for {
+		requestBody := map[string]interface{}{
+			"dept_id":  deptID,
+			"cursor":   cursor,
+			"size":     maxDeptMemberPageSize,
+			"language": "zh_CN",
+		}
+
+		var response DeptMemberListResponse
+		if err := s.client.postWithToken("/topapi/user/listsimple", requestBody, &response); err != nil {
+			return nil, err
+		}
+		members = append(members, response.Result.List...)
+
+		if !response.Result.HasMore || response.Result.NextCursor <= cursor {
+			return members, nil
+		}
+		cursor = response.Result.NextCursor
+	}
+}
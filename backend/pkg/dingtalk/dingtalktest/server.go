@@ -0,0 +1,591 @@
+// Package dingtalktest 提供基于 httptest 的钉钉开放平台模拟服务，
+// 用于在无网络环境下测试依赖钉钉接口的代码。
+package dingtalktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+)
+
+// 模拟服务使用的应用凭证
+const (
+	AppKey    = "test-app-key"
+	AppSecret = "test-app-secret"
+	CorpID    = "test-corp-id"
+)
+
+// User 模拟的钉钉用户
+type User struct {
+	UserID        string
+	UnionID       string
+	OpenID        string
+	Name          string
+	Avatar        string
+	Email         string
+	Mobile        string
+	Title         string
+	DeptIDs       []int64
+	ManagerUserID string
+	Admin         bool
+	// LeaderDeptIDs 用户担任主管的部门，需同时出现在 DeptIDs 中
+	LeaderDeptIDs []int64
+}
+
+// Server 模拟的钉钉开放平台，同时提供 oapi 与 api 两套接口
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	tokenSeq      int
+	tokenRequests int
+	accessTokens  map[string]bool
+	authCodes     map[string]string // 授权码 -> userid
+	userTokens    map[string]string // 用户access_token -> userid
+	users         map[string]User
+	departments   map[int64]string
+	userRequests  int
+	requests      map[string]int // oapi 路径 -> 调用次数
+	templates     []dingtalk.TemplateDetailResult
+	reports       []dingtalk.ReportData
+	created       []dingtalk.CreateReportRequest
+	drafts        []dingtalk.SaveReportParam
+	reportSeq     int
+	injected      map[string]oapiError
+}
+
+type oapiError struct {
+	code int
+	msg  string
+}
+
+// NewServer 启动模拟服务，测试结束后需调用 Close
+func NewServer() *Server {
+	s := &Server{
+		accessTokens: make(map[string]bool),
+		authCodes:    make(map[string]string),
+		userTokens:   make(map[string]string),
+		users:        make(map[string]User),
+		departments:  make(map[int64]string),
+		injected:     make(map[string]oapiError),
+		requests:     make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/gettoken", s.handleGetToken)
+	mux.HandleFunc("/v1.0/oauth2/userAccessToken", s.handleUserAccessToken)
+	mux.HandleFunc("/v1.0/contact/users/me", s.handleUsersMe)
+	mux.HandleFunc("/topapi/user/getbyunionid", s.withAccessToken(s.handleGetByUnionID))
+	mux.HandleFunc("/topapi/v2/user/get", s.withAccessToken(s.handleUserGet))
+	mux.HandleFunc("/topapi/user/listsimple", s.withAccessToken(s.handleUserListSimple))
+	mux.HandleFunc("/topapi/v2/department/get", s.withAccessToken(s.handleDepartmentGet))
+	mux.HandleFunc("/topapi/report/template/listbyuserid", s.withAccessToken(s.handleTemplateList))
+	mux.HandleFunc("/topapi/report/template/getbyname", s.withAccessToken(s.handleTemplateGetByName))
+	mux.HandleFunc("/topapi/report/list", s.withAccessToken(s.handleReportList))
+	mux.HandleFunc("/topapi/report/create", s.withAccessToken(s.handleReportCreate))
+	mux.HandleFunc("/topapi/report/savecontent", s.withAccessToken(s.handleSaveContent))
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config 返回指向模拟服务的钉钉配置
+func (s *Server) Config() *models.DingTalkConfig {
+	return &models.DingTalkConfig{
+		CorpId:      CorpID,
+		AppKey:      AppKey,
+		AppSecret:   AppSecret,
+		RedirectURI: s.URL + "/auth/callback",
+		BaseURL:     s.URL,
+		APIBaseURL:  s.URL,
+	}
+}
+
+// AddUser 注册用户，并返回可用于 OAuth 换取用户 token 的授权码
+func (s *Server) AddUser(user User) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.UserID] = user
+	code := "code-" + user.UserID
+	s.authCodes[code] = user.UserID
+	return code
+}
+
+// AddDepartment 注册部门名称
+func (s *Server) AddDepartment(deptID int64, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.departments[deptID] = name
+}
+
+// UserRequests 返回用户详情接口被调用的次数
+func (s *Server) UserRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userRequests
+}
+
+// Requests 返回 oapi 接口 path 被调用的次数
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// AddTemplate 添加日志模板，模板对所有用户可见
+func (s *Server) AddTemplate(template dingtalk.TemplateDetailResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates = append(s.templates, template)
+}
+
+// AddReport 添加一条已提交的日志，CreateTime 为毫秒时间戳
+func (s *Server) AddReport(report dingtalk.ReportData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = append(s.reports, report)
+}
+
+// CreatedReports 返回通过 report/create 提交的请求
+func (s *Server) CreatedReports() []dingtalk.CreateReportRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]dingtalk.CreateReportRequest(nil), s.created...)
+}
+
+// SavedDrafts 返回通过 report/savecontent 保存的草稿
+func (s *Server) SavedDrafts() []dingtalk.SaveReportParam {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]dingtalk.SaveReportParam(nil), s.drafts...)
+}
+
+// TokenRequests 返回 gettoken 被调用的次数
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+// ExpireTokens 使已签发的企业 access_token 全部失效
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokens = make(map[string]bool)
+}
+
+// InjectError 让下一次对 path 的 oapi 调用返回指定的 errcode
+func (s *Server) InjectError(path string, errCode int, errMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.injected[path] = oapiError{code: errCode, msg: errMsg}
+}
+
+func (s *Server) handleGetToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRequests++
+
+	query := r.URL.Query()
+	if query.Get("appkey") != AppKey || query.Get("appsecret") != AppSecret {
+		writeJSON(w, map[string]interface{}{"errcode": 40089, "errmsg": "不合法的corpid或corpsecret"})
+		return
+	}
+
+	s.tokenSeq++
+	token := fmt.Sprintf("access-token-%d", s.tokenSeq)
+	s.accessTokens[token] = true
+	writeJSON(w, map[string]interface{}{
+		"errcode":      0,
+		"errmsg":       "ok",
+		"access_token": token,
+		"expires_in":   7200,
+	})
+}
+
+func (s *Server) handleUserAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientID     string `json:"clientId"`
+		ClientSecret string `json:"clientSecret"`
+		Code         string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "InvalidParameter", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.ClientID != AppKey || req.ClientSecret != AppSecret {
+		writeAPIError(w, http.StatusBadRequest, "InvalidClient", "client credentials mismatch")
+		return
+	}
+	userID, ok := s.authCodes[req.Code]
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, "InvalidAuthCode", "authorization code is invalid")
+		return
+	}
+	token := "user-token-" + userID
+	s.userTokens[token] = userID
+	writeJSON(w, models.DingTalkOAuthTokenResponse{
+		AccessToken:  token,
+		RefreshToken: "refresh-" + token,
+		ExpireIn:     7200,
+		CorpId:       CorpID,
+	})
+}
+
+func (s *Server) handleUsersMe(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID, ok := s.userTokens[r.Header.Get("x-acs-dingtalk-access-token")]
+	if !ok {
+		writeAPIError(w, http.StatusUnauthorized, "InvalidAuthentication", "user access token is invalid")
+		return
+	}
+	user := s.users[userID]
+	writeJSON(w, models.DingTalkUserInfoResponse{
+		Nick:      user.Name,
+		AvatarUrl: user.Avatar,
+		Mobile:    user.Mobile,
+		OpenId:    user.OpenID,
+		UnionId:   user.UnionID,
+		Email:     user.Email,
+		StateCode: "86",
+	})
+}
+
+// withAccessToken 校验 oapi 请求携带的企业 access_token，并处理注入的错误
+func (s *Server) withAccessToken(next func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		valid := s.accessTokens[r.URL.Query().Get("access_token")]
+		injected, hasInjected := s.injected[r.URL.Path]
+		delete(s.injected, r.URL.Path)
+		s.mu.Unlock()
+
+		if !valid {
+			writeOapiError(w, 40014, "不合法的access_token")
+			return
+		}
+		if hasInjected {
+			writeOapiError(w, injected.code, injected.msg)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleGetByUnionID(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UnionID string `json:"unionid"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if user.UnionID == req.UnionID {
+			writeOapiResult(w, map[string]interface{}{"contact_type": 0, "userid": user.UserID})
+			return
+		}
+	}
+	writeOapiError(w, 60121, "找不到该用户")
+}
+
+func (s *Server) handleUserGet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"userid"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userRequests++
+	user, ok := s.users[req.UserID]
+	if !ok {
+		writeOapiError(w, 60121, "找不到该用户")
+		return
+	}
+	leaderInDept := make([]dingtalk.DeptLeader, 0, len(user.DeptIDs))
+	for _, deptID := range user.DeptIDs {
+		leaderInDept = append(leaderInDept, dingtalk.DeptLeader{DeptID: deptID, Leader: slices.Contains(user.LeaderDeptIDs, deptID)})
+	}
+	writeOapiResult(w, dingtalk.UserDetail{
+		UserID:        user.UserID,
+		UnionID:       user.UnionID,
+		Name:          user.Name,
+		Avatar:        user.Avatar,
+		Mobile:        user.Mobile,
+		Email:         user.Email,
+		Title:         user.Title,
+		DeptIDList:    user.DeptIDs,
+		LeaderInDept:  leaderInDept,
+		ManagerUserID: user.ManagerUserID,
+		Admin:         user.Admin,
+		Active:        true,
+	})
+}
+
+func (s *Server) handleDepartmentGet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DeptID int64 `json:"dept_id"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, ok := s.departments[req.DeptID]
+	if !ok {
+		writeOapiError(w, 60003, "部门不存在")
+		return
+	}
+	writeOapiResult(w, dingtalk.DepartmentDetail{DeptID: req.DeptID, Name: name, ParentID: 1})
+}
+
+func (s *Server) handleUserListSimple(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DeptID int64 `json:"dept_id"`
+		Cursor int   `json:"cursor"`
+		Size   int   `json:"size"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+	if req.Size <= 0 || req.Size > 100 {
+		writeOapiError(w, 400002, "size参数不合法")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var members []dingtalk.DeptMember
+	for _, user := range s.users {
+		for _, deptID := range user.DeptIDs {
+			if deptID == req.DeptID {
+				members = append(members, dingtalk.DeptMember{UserID: user.UserID, Name: user.Name})
+				break
+			}
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+
+	result := dingtalk.DeptMemberListResult{List: []dingtalk.DeptMember{}}
+	if req.Cursor < len(members) {
+		end := req.Cursor + req.Size
+		if end > len(members) {
+			end = len(members)
+		}
+		result.List = members[req.Cursor:end]
+		result.HasMore = end < len(members)
+		result.NextCursor = int64(end)
+	}
+	writeOapiResult(w, result)
+}
+
+func (s *Server) handleTemplateList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]dingtalk.TemplateItem, 0, len(s.templates))
+	for _, template := range s.templates {
+		list = append(list, dingtalk.TemplateItem{
+			Name:       template.Name,
+			ReportCode: template.ID,
+			IconURL:    s.URL + "/icons/" + template.ID + ".png",
+			URL:        s.URL + "/templates/" + template.ID,
+		})
+	}
+	writeOapiResult(w, dingtalk.TemplateListResult{TemplateList: list})
+}
+
+func (s *Server) handleTemplateGetByName(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID       string `json:"userid"`
+		TemplateName string `json:"template_name"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, template := range s.templates {
+		if template.Name == req.TemplateName {
+			template.UserID = req.UserID
+			template.UserName = s.users[req.UserID].Name
+			writeOapiResult(w, template)
+			return
+		}
+	}
+	writeOapiError(w, 400002, "模板不存在")
+}
+
+func (s *Server) handleReportList(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID       string `json:"userid"`
+		TemplateName string `json:"template_name"`
+		StartTime    int64  `json:"start_time"`
+		EndTime      int64  `json:"end_time"`
+		Cursor       int    `json:"cursor"`
+		Size         int    `json:"size"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+	if req.Size <= 0 || req.Size > 20 {
+		writeOapiError(w, 400002, "size参数不合法")
+		return
+	}
+	if req.EndTime-req.StartTime > (180 * 24 * time.Hour).Milliseconds() {
+		writeOapiError(w, 400002, "查询时间跨度不能超过180天")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []dingtalk.ReportData
+	for _, report := range s.reports {
+		if req.UserID != "" && report.CreatorID != req.UserID {
+			continue
+		}
+		if req.TemplateName != "" && report.TemplateName != req.TemplateName {
+			continue
+		}
+		if report.CreateTime < req.StartTime || report.CreateTime > req.EndTime {
+			continue
+		}
+		matched = append(matched, report)
+	}
+
+	result := dingtalk.ReportListResult{DataList: []dingtalk.ReportData{}}
+	if req.Cursor < len(matched) {
+		end := req.Cursor + req.Size
+		if end > len(matched) {
+			end = len(matched)
+		}
+		result.DataList = matched[req.Cursor:end]
+		result.HasMore = end < len(matched)
+		result.NextCursor = int64(end)
+	}
+	result.Size = len(result.DataList)
+	writeOapiResult(w, result)
+}
+
+func (s *Server) handleReportCreate(w http.ResponseWriter, r *http.Request) {
+	var req dingtalk.CreateReportRequest
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	param := req.CreateReportParam
+	s.created = append(s.created, req)
+	s.reportSeq++
+	reportID := fmt.Sprintf("report-%d", s.reportSeq)
+
+	report := dingtalk.ReportData{
+		CreateTime:   time.Now().UnixMilli(),
+		CreatorID:    param.UserID,
+		CreatorName:  s.users[param.UserID].Name,
+		ModifiedTime: time.Now().UnixMilli(),
+		ReportID:     reportID,
+		TemplateName: s.templateName(param.TemplateID),
+	}
+	for _, item := range param.Contents {
+		report.Contents = append(report.Contents, dingtalk.ReportContent{
+			Key:   item.Key,
+			Sort:  strconv.Itoa(item.Sort),
+			Type:  strconv.Itoa(item.Type),
+			Value: item.Content,
+		})
+	}
+	s.reports = append(s.reports, report)
+
+	writeOapiResult(w, reportID)
+}
+
+func (s *Server) handleSaveContent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CreateReportParam dingtalk.SaveReportParam `json:"create_report_param"`
+	}
+	if !decodeOapiRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drafts = append(s.drafts, req.CreateReportParam)
+	writeOapiResult(w, fmt.Sprintf("draft-%d", len(s.drafts)))
+}
+
+// templateName 根据模板ID查找模板名称，调用方需持有锁
+func (s *Server) templateName(templateID string) string {
+	for _, template := range s.templates {
+		if template.ID == templateID {
+			return template.Name
+		}
+	}
+	return ""
+}
+
+func decodeOapiRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeOapiError(w, 400002, "请求参数不合法: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeOapiResult(w http.ResponseWriter, result interface{}) {
+	writeJSON(w, map[string]interface{}{
+		"errcode":    0,
+		"errmsg":     "ok",
+		"result":     result,
+		"request_id": newRequestID(),
+	})
+}
+
+func writeOapiError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, map[string]interface{}{
+		"errcode":    code,
+		"errmsg":     msg,
+		"request_id": newRequestID(),
+	})
+}
+
+// writeAPIError 以新版 api 接口的格式返回错误
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"code":      code,
+		"message":   message,
+		"requestid": newRequestID(),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func newRequestID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
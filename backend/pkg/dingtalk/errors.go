@@ -0,0 +1,59 @@
+package dingtalk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError 钉钉接口返回的业务错误（errcode != 0）
+type APIError struct {
+	Endpoint  string
+	ErrCode   int
+	ErrMsg    string
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("dingtalk %s failed: errcode=%d errmsg=%s request_id=%s", e.Endpoint, e.ErrCode, e.ErrMsg, e.RequestID)
+}
+
+// 钉钉限流相关错误码
+var rateLimitedCodes = map[int]bool{
+	90002: true, // 应用调用接口频率超限
+	90006: true, // 企业调用接口频率超限
+	90018: true, // 接口调用次数超过限制
+}
+
+// 钉钉权限相关错误码
+var permissionDeniedCodes = map[int]bool{
+	60011: true, // 管理员权限不足
+	60020: true, // 访问IP不在白名单中
+	88:    true, // 应用未开通接口权限
+}
+
+// errCodeUserNotFound 通讯录中找不到该用户
+const errCodeUserNotFound = 60121
+
+// IsRateLimited 判断错误是否为钉钉限流
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && rateLimitedCodes[apiErr.ErrCode]
+}
+
+// IsTokenExpired 判断错误是否为access_token失效
+func IsTokenExpired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && isTokenExpiredCode(apiErr.ErrCode)
+}
+
+// IsPermissionDenied 判断错误是否为权限不足
+func IsPermissionDenied(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && permissionDeniedCodes[apiErr.ErrCode]
+}
+
+// IsUserNotFound 判断错误是否为通讯录中找不到该用户
+func IsUserNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ErrCode == errCodeUserNotFound
+}
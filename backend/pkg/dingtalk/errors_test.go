@@ -0,0 +1,57 @@
+package dingtalk
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+func TestPostWithTokenReturnsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"errcode":90018,"errmsg":"too many requests","request_id":"req-1"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(&models.DingTalkConfig{BaseURL: server.URL})
+	stubAccessTokens(client, "token")
+
+	var response CreateReportResponse
+	err := client.postWithToken("/topapi/report/create", map[string]string{}, &response)
+
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.ErrCode != 90018 || apiErr.RequestID != "req-1" || apiErr.Endpoint != "/topapi/report/create" {
+		t.Fatalf("unexpected error: %+v", apiErr)
+	}
+	if !IsRateLimited(fmt.Errorf("wrapped: %w", err)) {
+		t.Fatal("expected IsRateLimited to unwrap")
+	}
+	if IsTokenExpired(err) || IsPermissionDenied(err) {
+		t.Fatal("unexpected classification")
+	}
+}
+
+func TestPostWithTokenReturnsStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprint(w, "<html>502 Bad Gateway</html>")
+	}))
+	defer server.Close()
+
+	client := NewClient(&models.DingTalkConfig{BaseURL: server.URL})
+	stubAccessTokens(client, "token")
+
+	var response models.DingTalkUserByUnionIdResponse
+	err := client.postWithToken("/topapi/user/getbyunionid", map[string]string{}, &response)
+	if err == nil || !strings.Contains(err.Error(), "status 502") {
+		t.Fatalf("expected status error, got %v", err)
+	}
+}
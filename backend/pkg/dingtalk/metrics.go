@@ -0,0 +1,60 @@
+package dingtalk
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// 接口调用未拿到 errcode 时的结果标签
+const (
+	resultRequestError    = "request_error"
+	resultInvalidResponse = "invalid_response"
+)
+
+var (
+	apiRequestsTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "dingtalk_api_requests_total",
+		Help:      "钉钉接口调用次数，按接口与 errcode 统计",
+	}, []string{"endpoint", "errcode"})
+	apiRequestDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metrics.Namespace,
+		Name:      "dingtalk_api_request_duration_seconds",
+		Help:      "钉钉接口调用耗时，按接口统计",
+		Buckets:   metrics.DurationBuckets,
+	}, []string{"endpoint"})
+	tokenCacheRequests = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "dingtalk_token_cache_requests_total",
+		Help:      "企业 access_token 缓存查询次数，result 为 hit 或 miss",
+	}, []string{"result"})
+)
+
+// observeAPICall 记录一次钉钉接口调用。errcode 为钉钉返回的 errcode，新版接口以 HTTP 状态码表示结果，
+// 成功记为 0、失败记为 http_状态码；请求失败、响应无法解析时分别为 request_error、invalid_response
+func observeAPICall(endpoint, errcode string, start time.Time) {
+	apiRequestsTotal.WithLabelValues(endpoint, errcode).Inc()
+	apiRequestDuration.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+}
+
+// observeTokenLookup 记录 access_token 缓存是否命中
+func observeTokenLookup(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	tokenCacheRequests.WithLabelValues(result).Inc()
+}
+
+// httpResult 新版接口的结果标签
+func httpResult(statusCode int) string {
+	if statusCode == http.StatusOK {
+		return "0"
+	}
+	return "http_" + strconv.Itoa(statusCode)
+}
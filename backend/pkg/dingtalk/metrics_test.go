@@ -0,0 +1,59 @@
+package dingtalk
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPostWithTokenRecordsMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/gettoken":
+			fmt.Fprint(w, `{"errcode":0,"access_token":"token-1","expires_in":7200}`)
+		case "/topapi/v2/user/get":
+			fmt.Fprint(w, `{"errcode":60121,"errmsg":"user not found"}`)
+		default:
+			fmt.Fprint(w, `{"errcode":0,"errmsg":"ok"}`)
+		}
+	}))
+	defer server.Close()
+
+	counter := func(endpoint, errcode string) float64 {
+		return testutil.ToFloat64(apiRequestsTotal.WithLabelValues(endpoint, errcode))
+	}
+	tokenOK := counter("/gettoken", "0")
+	listOK := counter("/topapi/report/template/listbyuserid", "0")
+	notFound := counter("/topapi/v2/user/get", "60121")
+	hits := testutil.ToFloat64(tokenCacheRequests.WithLabelValues("hit"))
+	misses := testutil.ToFloat64(tokenCacheRequests.WithLabelValues("miss"))
+
+	client := NewClient(&models.DingTalkConfig{BaseURL: server.URL})
+	if _, err := NewReportService(client).GetTemplates("user-1"); err != nil {
+		t.Fatalf("GetTemplates failed: %v", err)
+	}
+	if _, err := NewContactService(client).GetUser("missing"); !IsUserNotFound(err) {
+		t.Fatalf("expected user not found, got %v", err)
+	}
+
+	if got := counter("/gettoken", "0") - tokenOK; got != 1 {
+		t.Fatalf("expected 1 token request, got %v", got)
+	}
+	if got := counter("/topapi/report/template/listbyuserid", "0") - listOK; got != 1 {
+		t.Fatalf("expected 1 successful template request, got %v", got)
+	}
+	if got := counter("/topapi/v2/user/get", "60121") - notFound; got != 1 {
+		t.Fatalf("expected errcode 60121 to be recorded, got %v", got)
+	}
+	if got := testutil.ToFloat64(tokenCacheRequests.WithLabelValues("miss")) - misses; got != 1 {
+		t.Fatalf("expected 1 token cache miss, got %v", got)
+	}
+	if got := testutil.ToFloat64(tokenCacheRequests.WithLabelValues("hit")) - hits; got != 1 {
+		t.Fatalf("expected 1 token cache hit, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package dingtalk
+
+import "time"
+
+const (
+	// maxReportPageSize 钉钉日志列表接口单页最大条数
+	maxReportPageSize = 20
+	// maxReportWindow 钉钉日志列表接口单次查询的最大时间跨度
+	maxReportWindow = 180 * 24 * time.Hour
+)
+
+// IterateReports 遍历时间范围内的全部日志，自动跟随 next_cursor 翻页，
+// 并将超过180天的时间范围拆分为多个子区间依次查询。
+// startTime、endTime 为秒级时间戳，fn 返回 false 时停止遍历。
+func (s *ReportService) IterateReports(userID, templateName string, startTime, endTime int64, fn func(ReportData) bool) error {
+	window := int64(maxReportWindow / time.Second)
+	// 相邻子区间共享边界时间点，用 report_id 去重
+	seen := make(map[string]bool)
+
+	for windowStart := startTime; windowStart <= endTime; {
+		windowEnd := windowStart + window
+		if windowEnd > endTime {
+			windowEnd = endTime
+		}
+
+		cursor := 0
+		for {
+			resp, err := s.GetReports(userID, templateName, windowStart, windowEnd, cursor, maxReportPageSize)
+			if err != nil {
+				return err
+			}
+
+			for _, report := range resp.Result.DataList {
+				if seen[report.ReportID] {
+					continue
+				}
+				seen[report.ReportID] = true
+				if !fn(report) {
+					return nil
+				}
+			}
+
+			next := int(resp.Result.NextCursor)
+			if !resp.Result.HasMore || next <= cursor {
+				break
+			}
+			cursor = next
+		}
+
+		if windowEnd == endTime {
+			break
+		}
+		windowStart = windowEnd
+	}
+
+	return nil
+}
+
+// ListAllReports 返回时间范围内的全部日志，详见 IterateReports
+func (s *ReportService) ListAllReports(userID, templateName string, startTime, endTime int64) ([]ReportData, error) {
+	var reports []ReportData
+	err := s.IterateReports(userID, templateName, startTime, endTime, func(report ReportData) bool {
+		reports = append(reports, report)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
@@ -0,0 +1,65 @@
+package dingtalk
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// ProfileProvider 基于钉钉通讯录接口实现 platform.ProfileProvider
+type ProfileProvider struct {
+	contacts *ContactService
+}
+
+var _ platform.ProfileProvider = (*ProfileProvider)(nil)
+
+// NewProfileProvider 创建钉钉用户资料服务，与其他服务共用同一个客户端
+func NewProfileProvider(client *Client) *ProfileProvider {
+	return &ProfileProvider{contacts: NewContactService(client)}
+}
+
+func (p *ProfileProvider) GetProfile(ctx context.Context, userID string) (*platform.Profile, error) {
+	resp, err := p.contacts.WithContext(ctx).GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	user := resp.Result
+
+	email := user.Email
+	if email == "" {
+		email = user.OrgEmail
+	}
+	profile := &platform.Profile{
+		Name:        user.Name,
+		Avatar:      user.Avatar,
+		Email:       email,
+		Mobile:      user.Mobile,
+		Title:       user.Title,
+		Departments: []platform.Department{},
+	}
+
+	for _, deptID := range user.DeptIDList {
+		dept, err := p.contacts.WithContext(ctx).GetDepartment(deptID)
+		if err != nil {
+			return nil, err
+		}
+		profile.Departments = append(profile.Departments, platform.Department{
+			ID:   strconv.FormatInt(deptID, 10),
+			Name: dept.Result.Name,
+		})
+	}
+
+	if user.ManagerUserID != "" {
+		profile.Manager = &platform.Recipient{ID: user.ManagerUserID}
+		manager, err := p.contacts.WithContext(ctx).GetUser(user.ManagerUserID)
+		// 主管已离职时只返回其 userid
+		if err != nil && !IsUserNotFound(err) {
+			return nil, err
+		}
+		if err == nil {
+			profile.Manager.Name = manager.Result.Name
+		}
+	}
+	return profile, nil
+}
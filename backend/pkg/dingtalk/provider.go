@@ -0,0 +1,261 @@
+package dingtalk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// ReportProvider 基于钉钉日志接口实现 platform.ReportProvider
+type ReportProvider struct {
+	reports  *ReportService
+	contacts *ContactService
+}
+
+var _ platform.ReportProvider = (*ReportProvider)(nil)
+
+// NewReportProvider 创建钉钉日志服务，与其他服务共用同一个客户端
+func NewReportProvider(client *Client) *ReportProvider {
+	return &ReportProvider{
+		reports:  NewReportService(client),
+		contacts: NewContactService(client),
+	}
+}
+
+func (p *ReportProvider) ListTemplates(ctx context.Context, userID string) ([]platform.Template, error) {
+	resp, err := p.reports.WithContext(ctx).GetTemplates(userID)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]platform.Template, 0, len(resp.Result.TemplateList))
+	for _, item := range resp.Result.TemplateList {
+		templates = append(templates, platform.Template{Name: item.Name, IconURL: item.IconURL})
+	}
+	return templates, nil
+}
+
+func (p *ReportProvider) GetTemplate(ctx context.Context, userID, name string) (*platform.Template, error) {
+	detail, err := p.getTemplateDetail(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	template := &platform.Template{ID: detail.ID, Name: detail.Name}
+	for _, field := range detail.Fields {
+		template.Fields = append(template.Fields, platform.Field{Name: field.FieldName, Sort: field.Sort, Type: field.Type})
+	}
+	for _, receiver := range detail.DefaultReceivers {
+		template.Receivers = append(template.Receivers, platform.Recipient{ID: receiver.UserID, Name: receiver.UserName})
+	}
+	for _, conv := range detail.DefaultReceivedConvs {
+		template.Chats = append(template.Chats, platform.Recipient{ID: conv.ConversationID, Name: conv.Title})
+	}
+	return template, nil
+}
+
+func (p *ReportProvider) ListReports(ctx context.Context, userID, templateName string, startTime, endTime int64) ([]platform.Report, error) {
+	reports := []platform.Report{}
+	err := p.reports.WithContext(ctx).IterateReports(userID, templateName, startTime, endTime, func(data ReportData) bool {
+		reports = append(reports, toPlatformReport(data))
+		return ctx.Err() == nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// CreateReport 创建并发送钉钉日志。
+// 接收人为显式指定的 ToUserIDs/ToChatIDs，UseTemplateDefaults 为 true 时合并模板默认的接收人与接收群。
+func (p *ReportProvider) CreateReport(ctx context.Context, userID string, req platform.CreateReportRequest) (*platform.Report, error) {
+	detail, err := p.getTemplateDetail(ctx, userID, req.TemplateName)
+	if err != nil {
+		return nil, err
+	}
+	recipients, err := p.resolveRecipients(ctx, detail, req.ToUserIDs, req.ToChatIDs, req.UseTemplateDefaults)
+	if err != nil {
+		return nil, err
+	}
+
+	var createReq CreateReportRequest
+	createReq.CreateReportParam.TemplateID = templateID(req, detail)
+	createReq.CreateReportParam.UserID = userID
+	createReq.CreateReportParam.Contents = buildReportContents(detail, req.Contents)
+	createReq.CreateReportParam.ToChat = req.ToChat
+	createReq.CreateReportParam.ToCIDs = recipients.cids()
+	createReq.CreateReportParam.ToUserIDs = recipients.userIDs()
+
+	createResp, err := p.reports.WithContext(ctx).Create(userID, &createReq)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &platform.Report{
+		ID:           createResp.Result,
+		TemplateName: req.TemplateName,
+		CreatorID:    userID,
+		ToChat:       req.ToChat,
+	}
+	for _, item := range createReq.CreateReportParam.Contents {
+		report.Contents = append(report.Contents, platform.Content{Key: item.Key, Value: item.Content, Sort: item.Sort, Type: item.Type})
+	}
+	for _, receiver := range recipients.receivers {
+		report.Receivers = append(report.Receivers, platform.Recipient{ID: receiver.UserID, Name: receiver.UserName})
+	}
+	for _, conv := range recipients.convs {
+		report.Chats = append(report.Chats, platform.Recipient{ID: conv.ConversationID, Name: conv.Title})
+	}
+	return report, nil
+}
+
+// SaveDraft 将内容保存为钉钉日志草稿，用户可在钉钉客户端中确认后再发送
+func (p *ReportProvider) SaveDraft(ctx context.Context, userID string, req platform.CreateReportRequest) (string, error) {
+	detail, err := p.getTemplateDetail(ctx, userID, req.TemplateName)
+	if err != nil {
+		return "", err
+	}
+	saveResp, err := p.reports.WithContext(ctx).SaveContent(userID, SaveReportParam{
+		TemplateID: templateID(req, detail), UserID: userID, Contents: buildReportContents(detail, req.Contents),
+	})
+	if err != nil {
+		return "", err
+	}
+	return saveResp.Result, nil
+}
+
+// templateID 返回请求指定的模板ID，未指定时使用按模板名称查到的模板ID
+func templateID(req platform.CreateReportRequest, detail *TemplateDetailResult) string {
+	if req.TemplateID != "" {
+		return req.TemplateID
+	}
+	return detail.ID
+}
+
+// getTemplateDetail 获取模板详情，用于字段映射与默认接收人
+func (p *ReportProvider) getTemplateDetail(ctx context.Context, userID, templateName string) (*TemplateDetailResult, error) {
+	templateDetail, err := p.reports.WithContext(ctx).GetTemplateDetail(userID, templateName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template details: %w", err)
+	}
+	return &templateDetail.Result, nil
+}
+
+// buildReportContents 按模板字段将 key/value 形式的内容转换为钉钉日志内容，忽略模板中不存在的字段
+func buildReportContents(templateDetail *TemplateDetailResult, contents []platform.Content) []ContentItem {
+	fieldMap := make(map[string]Field)
+	for _, field := range templateDetail.Fields {
+		fieldMap[field.FieldName] = field
+	}
+	var reportContents []ContentItem
+	for _, content := range contents {
+		if field, exists := fieldMap[content.Key]; exists {
+			reportContents = append(reportContents, ContentItem{
+				Key: field.FieldName, Sort: field.Sort, Type: field.Type, Content: content.Value, ContentType: "markdown",
+			})
+		}
+	}
+	return reportContents
+}
+
+// toPlatformReport 将钉钉日志转换为平台无关的日志
+func toPlatformReport(data ReportData) platform.Report {
+	report := platform.Report{
+		ID:           data.ReportID,
+		TemplateName: data.TemplateName,
+		CreatorID:    data.CreatorID,
+		CreatorName:  data.CreatorName,
+		DeptName:     data.DeptName,
+		CreateTime:   data.CreateTime,
+	}
+	for _, content := range data.Contents {
+		sort, _ := strconv.Atoi(content.Sort)
+		contentType, _ := strconv.Atoi(content.Type)
+		report.Contents = append(report.Contents, platform.Content{Key: content.Key, Value: content.Value, Sort: sort, Type: contentType})
+	}
+	return report
+}
+
+// reportRecipients 日志实际发送的接收人与接收群
+type reportRecipients struct {
+	receivers []Receiver
+	convs     []Conversation
+}
+
+func (r *reportRecipients) userIDs() []string {
+	ids := make([]string, 0, len(r.receivers))
+	for _, receiver := range r.receivers {
+		ids = append(ids, receiver.UserID)
+	}
+	return ids
+}
+
+func (r *reportRecipients) cids() []string {
+	ids := make([]string, 0, len(r.convs))
+	for _, conv := range r.convs {
+		ids = append(ids, conv.ConversationID)
+	}
+	return ids
+}
+
+// resolveRecipients 合并显式指定与模板默认的接收人、接收群并去重。
+// 显式指定的接收人需存在于企业通讯录中，否则返回 *platform.InvalidRecipientsError；
+// 接收群ID不能为空，否则返回 platform.ErrInvalidRequest。
+func (p *ReportProvider) resolveRecipients(ctx context.Context, templateDetail *TemplateDetailResult, toUserIDs, toCIDs []string, useTemplateDefaults bool) (*reportRecipients, error) {
+	// 群ID无法在发送前校验是否存在，至少拒绝空值
+	if slices.Contains(toCIDs, "") {
+		return nil, fmt.Errorf("%w: to_cids must not contain empty conversation ids", platform.ErrInvalidRequest)
+	}
+	recipients := &reportRecipients{}
+	seenUsers := make(map[string]bool)
+	seenConvs := make(map[string]bool)
+
+	if useTemplateDefaults {
+		for _, receiver := range templateDetail.DefaultReceivers {
+			if !seenUsers[receiver.UserID] {
+				seenUsers[receiver.UserID] = true
+				recipients.receivers = append(recipients.receivers, receiver)
+			}
+		}
+		for _, conv := range templateDetail.DefaultReceivedConvs {
+			if !seenConvs[conv.ConversationID] {
+				seenConvs[conv.ConversationID] = true
+				recipients.convs = append(recipients.convs, conv)
+			}
+		}
+	}
+
+	var invalid []string
+	for _, userID := range toUserIDs {
+		if userID == "" || seenUsers[userID] {
+			continue
+		}
+		user, err := p.contacts.WithContext(ctx).GetUser(userID)
+		if IsUserNotFound(err) {
+			invalid = append(invalid, userID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate recipient %s: %w", userID, err)
+		}
+		seenUsers[userID] = true
+		recipients.receivers = append(recipients.receivers, Receiver{UserID: userID, UserName: user.Result.Name})
+	}
+	if len(invalid) > 0 {
+		return nil, &platform.InvalidRecipientsError{UserIDs: invalid}
+	}
+
+	for _, cid := range toCIDs {
+		if seenConvs[cid] {
+			continue
+		}
+		seenConvs[cid] = true
+		recipients.convs = append(recipients.convs, Conversation{ConversationID: cid})
+	}
+
+	return recipients, nil
+}
@@ -0,0 +1,166 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+func TestReportProviderCreateReport(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	server.AddUser(dingtalktest.User{UserID: "boss", Name: "老板"})
+	server.AddTemplate(dingtalk.TemplateDetailResult{
+		ID:                   "tpl-daily",
+		Name:                 "日报",
+		Fields:               []dingtalk.Field{{FieldName: "今日完成工作", Sort: 0, Type: 1}},
+		DefaultReceivers:     []dingtalk.Receiver{{UserID: "lead", UserName: "组长"}},
+		DefaultReceivedConvs: []dingtalk.Conversation{{ConversationID: "cid-team", Title: "项目群"}},
+	})
+
+	var provider platform.ReportProvider = dingtalk.NewReportProvider(dingtalk.NewClient(server.Config()))
+	report, err := provider.CreateReport(context.Background(), "user-1", platform.CreateReportRequest{
+		TemplateID:          "tpl-daily",
+		TemplateName:        "日报",
+		Contents:            []platform.Content{{Key: "今日完成工作", Value: "接口联调"}, {Key: "不存在的字段", Value: "忽略"}},
+		ToUserIDs:           []string{"boss", "lead"},
+		UseTemplateDefaults: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateReport failed: %v", err)
+	}
+	if len(report.Receivers) != 2 || report.Receivers[1].Name != "老板" || len(report.Chats) != 1 {
+		t.Fatalf("unexpected recipients: %+v %+v", report.Receivers, report.Chats)
+	}
+
+	created := server.CreatedReports()
+	if len(created) != 1 || len(created[0].CreateReportParam.Contents) != 1 || created[0].CreateReportParam.Contents[0].Type != 1 {
+		t.Fatalf("unexpected created report: %+v", created)
+	}
+
+	_, err = provider.CreateReport(context.Background(), "user-1", platform.CreateReportRequest{
+		TemplateName: "日报",
+		ToUserIDs:    []string{"ghost"},
+	})
+	var recipientsErr *platform.InvalidRecipientsError
+	if !errors.As(err, &recipientsErr) || recipientsErr.UserIDs[0] != "ghost" {
+		t.Fatalf("expected InvalidRecipientsError, got %v", err)
+	}
+}
+
+func TestReportProviderValidatesRecipients(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	server.AddUser(dingtalktest.User{UserID: "boss", Name: "老板"})
+	server.AddTemplate(dingtalk.TemplateDetailResult{
+		ID:                   "tpl-daily",
+		Name:                 "日报",
+		DefaultReceivers:     []dingtalk.Receiver{{UserID: "lead", UserName: "组长"}},
+		DefaultReceivedConvs: []dingtalk.Conversation{{ConversationID: "cid-team", Title: "项目群"}},
+	})
+	provider := dingtalk.NewReportProvider(dingtalk.NewClient(server.Config()))
+
+	tests := []struct {
+		name      string
+		req       platform.CreateReportRequest
+		invalid   []string
+		wantErr   error
+		receivers []string
+		chats     []string
+	}{
+		{
+			name:      "duplicates and empty user ids are skipped",
+			req:       platform.CreateReportRequest{ToUserIDs: []string{"boss", "", "boss"}, ToChatIDs: []string{"cid-1", "cid-1"}},
+			receivers: []string{"boss"},
+			chats:     []string{"cid-1"},
+		},
+		{
+			name:    "empty conversation ids are rejected",
+			req:     platform.CreateReportRequest{ToUserIDs: []string{"boss"}, ToChatIDs: []string{"cid-1", ""}},
+			wantErr: platform.ErrInvalidRequest,
+		},
+		{
+			name:      "template defaults are not looked up again",
+			req:       platform.CreateReportRequest{ToUserIDs: []string{"lead", "boss"}, ToChatIDs: []string{"cid-team"}, UseTemplateDefaults: true},
+			receivers: []string{"lead", "boss"},
+			chats:     []string{"cid-team"},
+		},
+		{
+			name:    "explicit recipients must exist without defaults",
+			req:     platform.CreateReportRequest{ToUserIDs: []string{"lead", "boss"}},
+			invalid: []string{"lead"},
+		},
+		{
+			name:    "all unknown recipients are reported together",
+			req:     platform.CreateReportRequest{ToUserIDs: []string{"ghost-1", "boss", "ghost-2"}},
+			invalid: []string{"ghost-1", "ghost-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(server.CreatedReports())
+			tt.req.TemplateID = "tpl-daily"
+			tt.req.TemplateName = "日报"
+			report, err := provider.CreateReport(context.Background(), "user-1", tt.req)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				if len(server.CreatedReports()) != before {
+					t.Fatal("report should not be created with an invalid request")
+				}
+				return
+			}
+			if tt.invalid != nil {
+				var recipientsErr *platform.InvalidRecipientsError
+				if !errors.As(err, &recipientsErr) || fmt.Sprint(recipientsErr.UserIDs) != fmt.Sprint(tt.invalid) {
+					t.Fatalf("expected invalid recipients %v, got %v", tt.invalid, err)
+				}
+				if len(server.CreatedReports()) != before {
+					t.Fatal("report should not be created with invalid recipients")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateReport failed: %v", err)
+			}
+			var receivers, chats []string
+			for _, receiver := range report.Receivers {
+				receivers = append(receivers, receiver.ID)
+			}
+			for _, chat := range report.Chats {
+				chats = append(chats, chat.ID)
+			}
+			if fmt.Sprint(receivers) != fmt.Sprint(tt.receivers) || fmt.Sprint(chats) != fmt.Sprint(tt.chats) {
+				t.Fatalf("unexpected recipients: %v %v", receivers, chats)
+			}
+		})
+	}
+
+	// 未指定模板ID时使用按名称查到的模板
+	if _, err := provider.CreateReport(context.Background(), "user-1", platform.CreateReportRequest{TemplateName: "日报"}); err != nil {
+		t.Fatalf("CreateReport without template id failed: %v", err)
+	}
+	created := server.CreatedReports()
+	if templateID := created[len(created)-1].CreateReportParam.TemplateID; templateID != "tpl-daily" {
+		t.Fatalf("expected template id from template lookup, got %q", templateID)
+	}
+
+	// 通讯录查询本身失败时返回原始错误，而不是把接收人判为无效
+	server.InjectError("/topapi/v2/user/get", 90018, "too many requests")
+	_, err := provider.CreateReport(context.Background(), "user-1", platform.CreateReportRequest{
+		TemplateID: "tpl-daily", TemplateName: "日报", ToUserIDs: []string{"boss"},
+	})
+	var recipientsErr *platform.InvalidRecipientsError
+	if errors.As(err, &recipientsErr) || !dingtalk.IsRateLimited(err) {
+		t.Fatalf("expected rate limited lookup error, got %v", err)
+	}
+}
@@ -1,9 +1,8 @@
 package dingtalk
 
 import (
-	"encoding/json"
-	"io"
-	"log"
+	"context"
+	"log/slog"
 )
 
 type ReportService struct {
@@ -14,6 +13,11 @@ func NewReportService(client *Client) *ReportService {
 	return &ReportService{client: client}
 }
 
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *ReportService) WithContext(ctx context.Context) *ReportService {
+	return &ReportService{client: s.client.WithContext(ctx)}
+}
+
 type TemplateItem struct {
 	IconURL    string `json:"icon_url"`
 	Name       string `json:"name"`
@@ -40,33 +44,9 @@ func (s *ReportService) GetTemplates(userId string) (*TemplateListResponse, erro
 		"size":   "100",
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, err
-	}
-
-	accessToken, err := s.client.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := s.client.httpClient.R().SetBody(jsonData).Post("https://oapi.dingtalk.com/topapi/report/template/listbyuserid?access_token=" + accessToken.AccessToken)
-	if err != nil {
-		log.Println("request failed:", err)
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Println("read response body failed:", err)
-		return nil, err
-	}
-
 	var response TemplateListResponse
-
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := s.client.postWithToken("/topapi/report/template/listbyuserid", requestBody, &response); err != nil {
+		slog.ErrorContext(s.client.context(), "Failed to list DingTalk templates", "userid", userId, "error", err)
 		return nil, err
 	}
 
@@ -106,13 +86,6 @@ type ReportListResponse struct {
 }
 
 func (s *ReportService) GetReports(userID string, templateName string, startTime, endTime int64, cursor, size int) (*ReportListResponse, error) {
-	accessToken, err := s.client.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
-	url := "https://oapi.dingtalk.com/topapi/report/list?access_token=" + accessToken.AccessToken
-
 	requestBody := map[string]interface{}{
 		"userid":        userID,
 		"template_name": templateName,
@@ -122,25 +95,8 @@ func (s *ReportService) GetReports(userID string, templateName string, startTime
 		"size":          size,
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := s.client.httpClient.R().SetBody(jsonData).Post(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var response ReportListResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		log.Printf("Failed to unmarshal DingTalk report list response. Body: %s", string(body))
+	if err := s.client.postWithToken("/topapi/report/list", requestBody, &response); err != nil {
 		return nil, err
 	}
 
@@ -149,38 +105,13 @@ func (s *ReportService) GetReports(userID string, templateName string, startTime
 
 // 获取模板详情
 func (s *ReportService) GetTemplateDetail(userId, template_name string) (*TemplateDetailResponse, error) {
-	accessToken, err := s.client.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
-	url := "https://oapi.dingtalk.com/topapi/report/template/getbyname?access_token=" + accessToken.AccessToken
-
 	requestBody := map[string]interface{}{
 		"userid":        userId,
 		"template_name": template_name,
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := s.client.httpClient.R().SetBody(jsonData).Post(url)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var response TemplateDetailResponse
-
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := s.client.postWithToken("/topapi/report/template/getbyname", requestBody, &response); err != nil {
 		return nil, err
 	}
 
@@ -189,6 +120,7 @@ func (s *ReportService) GetTemplateDetail(userId, template_name string) (*Templa
 
 type TemplateDetailResponse struct {
 	ErrCode   int                  `json:"errcode"`
+	ErrMsg    string               `json:"errmsg"`
 	Result    TemplateDetailResult `json:"result"`
 	RequestID string               `json:"request_id"`
 }
@@ -246,29 +178,10 @@ type CreateReportResponse struct {
 	RequestID string `json:"request_id"`
 }
 
-// 保存草稿
+// Create 创建日志并发送给接收人
 func (s *ReportService) Create(userId string, createReq *CreateReportRequest) (*CreateReportResponse, error) {
-	accessToken, err := s.client.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
-	url := "https://oapi.dingtalk.com/topapi/report/create?access_token=" + accessToken.AccessToken
-
-	resp, err := s.client.httpClient.R().SetBody(createReq).Post(url)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
 	var response CreateReportResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := s.client.postWithToken("/topapi/report/create", createReq, &response); err != nil {
 		return nil, err
 	}
 
@@ -284,37 +197,19 @@ type SaveReportParam struct {
 
 type SaveReportResponse struct {
 	ErrCode   int    `json:"errcode"`
+	ErrMsg    string `json:"errmsg"`
 	Result    string `json:"result"`
 	RequestID string `json:"request_id"`
 }
 
+// SaveContent 保存日志内容为草稿，不会发送给接收人，返回的 Result 为草稿ID
 func (s *ReportService) SaveContent(userId string, param SaveReportParam) (*SaveReportResponse, error) {
-	accessToken, err := s.client.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
-	url := "https://oapi.dingtalk.com/topapi/report/savecontent?access_token=" + accessToken.AccessToken
-
-	jsonData, err := json.Marshal(param)
-	if err != nil {
-		return nil, err
-	}
-
-	resp, err := s.client.httpClient.R().SetBody(jsonData).Post(url)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	requestBody := map[string]interface{}{
+		"create_report_param": param,
 	}
 
 	var response SaveReportResponse
-	if err := json.Unmarshal(body, &response); err != nil {
+	if err := s.client.postWithToken("/topapi/report/savecontent", requestBody, &response); err != nil {
 		return nil, err
 	}
 
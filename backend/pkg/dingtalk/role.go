@@ -0,0 +1,46 @@
+package dingtalk
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// RoleProvider 基于钉钉通讯录的管理员与部门主管标记实现 platform.RoleProvider
+type RoleProvider struct {
+	contacts *ContactService
+}
+
+var _ platform.RoleProvider = (*RoleProvider)(nil)
+
+// NewRoleProvider 创建钉钉组织关系服务，与其他服务共用同一个客户端
+func NewRoleProvider(client *Client) *RoleProvider {
+	return &RoleProvider{contacts: NewContactService(client)}
+}
+
+// GetMembership 查询用户的组织关系，通讯录中不存在的用户视为不属于任何部门
+func (p *RoleProvider) GetMembership(ctx context.Context, userID string) (*platform.Membership, error) {
+	resp, err := p.contacts.WithContext(ctx).GetUser(userID)
+	if IsUserNotFound(err) {
+		return &platform.Membership{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	user := resp.Result
+
+	membership := &platform.Membership{
+		Admin:     user.Admin || user.Boss,
+		ManagerID: user.ManagerUserID,
+	}
+	for _, deptID := range user.DeptIDList {
+		membership.Departments = append(membership.Departments, strconv.FormatInt(deptID, 10))
+	}
+	for _, leader := range user.LeaderInDept {
+		if leader.Leader {
+			membership.LedDepartments = append(membership.LedDepartments, strconv.FormatInt(leader.DeptID, 10))
+		}
+	}
+	return membership, nil
+}
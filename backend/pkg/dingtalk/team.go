@@ -0,0 +1,124 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// teamReportWorkers 并发拉取成员日志的协程数，避免触发钉钉接口限流
+const teamReportWorkers = 5
+
+// TeamService 团队日志汇总服务
+type TeamService struct {
+	reportService  *ReportService
+	contactService *ContactService
+}
+
+// NewTeamService 创建团队日志汇总服务
+func NewTeamService(client *Client) *TeamService {
+	return &TeamService{
+		reportService:  NewReportService(client),
+		contactService: NewContactService(client),
+	}
+}
+
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *TeamService) WithContext(ctx context.Context) *TeamService {
+	return &TeamService{
+		reportService:  s.reportService.WithContext(ctx),
+		contactService: s.contactService.WithContext(ctx),
+	}
+}
+
+// MemberReports 单个成员在时间范围内提交的日志，拉取失败时 Error 为失败原因
+type MemberReports struct {
+	UserID  string       `json:"user_id"`
+	Name    string       `json:"name"`
+	Reports []ReportData `json:"reports"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// TeamReports 部门日志汇总，Missing 为时间范围内未提交日志的成员
+type TeamReports struct {
+	DeptID  int64           `json:"dept_id"`
+	Members []MemberReports `json:"members"`
+	Missing []DeptMember    `json:"missing"`
+}
+
+// GetTeamReports 获取部门直属成员在时间范围内的日志，按成员分组。
+// startTime、endTime 为秒级时间戳。单个成员拉取失败时记录在该成员的 Error 中，其余成员照常返回；
+// 全部成员都失败时返回错误，请求取消后不再拉取剩余成员并返回 ctx 的错误
+func (s *TeamService) GetTeamReports(deptID int64, templateName string, startTime, endTime int64) (*TeamReports, error) {
+	members, err := s.contactService.ListDeptMembers(deptID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := s.reportService.client.context()
+
+	results := make([]MemberReports, len(members))
+	errs := make([]error, len(members))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < teamReportWorkers && w < len(members); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				member := members[i]
+				reports, err := s.reportService.ListAllReports(member.UserID, templateName, startTime, endTime)
+				results[i] = MemberReports{UserID: member.UserID, Name: member.Name, Reports: reports}
+				errs[i] = err
+			}
+		}()
+	}
+send:
+	for i := range members {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	team := &TeamReports{DeptID: deptID, Members: []MemberReports{}, Missing: []DeptMember{}}
+	failed := 0
+	for i, member := range members {
+		if errs[i] != nil {
+			failed++
+			slog.WarnContext(ctx, "Failed to list member reports", "dept_id", deptID, "userid", member.UserID, "error", errs[i])
+			results[i].Reports = []ReportData{}
+			results[i].Error = memberError(errs[i])
+			team.Members = append(team.Members, results[i])
+			continue
+		}
+		if len(results[i].Reports) == 0 {
+			team.Missing = append(team.Missing, member)
+			continue
+		}
+		team.Members = append(team.Members, results[i])
+	}
+	if failed > 0 && failed == len(members) {
+		return nil, errs[0]
+	}
+	return team, nil
+}
+
+// memberError 返回成员拉取失败的原因，只暴露钉钉接口的错误信息，网络等内部错误统一描述
+func memberError(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Error()
+	}
+	return "failed to list reports"
+}
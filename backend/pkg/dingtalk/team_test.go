@@ -0,0 +1,118 @@
+package dingtalk_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+)
+
+func TestGetTeamReportsGroupsByMember(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		userID := fmt.Sprintf("member-%02d", i)
+		server.AddUser(dingtalktest.User{UserID: userID, Name: userID, DeptIDs: []int64{100}})
+		// 每4人中有1人未提交
+		if i%4 == 3 {
+			continue
+		}
+		for day := 0; day < 3; day++ {
+			server.AddReport(dingtalk.ReportData{
+				ReportID:     fmt.Sprintf("%s-%d", userID, day),
+				CreatorID:    userID,
+				TemplateName: "日报",
+				CreateTime:   start.AddDate(0, 0, day).UnixMilli(),
+			})
+		}
+	}
+	server.AddUser(dingtalktest.User{UserID: "outsider", DeptIDs: []int64{200}})
+
+	team, err := dingtalk.NewTeamService(dingtalk.NewClient(server.Config())).
+		GetTeamReports(100, "日报", start.Unix(), start.AddDate(0, 0, 7).Unix())
+	if err != nil {
+		t.Fatalf("GetTeamReports failed: %v", err)
+	}
+
+	if len(team.Members) != 9 || len(team.Missing) != 3 {
+		t.Fatalf("expected 9 members with reports and 3 missing, got %d and %d", len(team.Members), len(team.Missing))
+	}
+	for _, member := range team.Members {
+		if len(member.Reports) != 3 {
+			t.Fatalf("expected 3 reports for %s, got %d", member.UserID, len(member.Reports))
+		}
+	}
+	if team.Missing[0].UserID != "member-03" {
+		t.Fatalf("unexpected missing list: %+v", team.Missing)
+	}
+}
+
+// newTeamServer 启动模拟服务，部门 100 有 n 名成员，每人提交一篇日报
+func newTeamServer(t *testing.T, n int, start time.Time) *dingtalktest.Server {
+	t.Helper()
+	server := dingtalktest.NewServer()
+	t.Cleanup(server.Close)
+	for i := 0; i < n; i++ {
+		userID := fmt.Sprintf("member-%02d", i)
+		server.AddUser(dingtalktest.User{UserID: userID, Name: userID, DeptIDs: []int64{100}})
+		server.AddReport(dingtalk.ReportData{ReportID: userID, CreatorID: userID, TemplateName: "日报", CreateTime: start.UnixMilli()})
+	}
+	return server
+}
+
+func TestGetTeamReportsReportsMemberFailures(t *testing.T) {
+	start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	server := newTeamServer(t, 4, start)
+	server.InjectError("/topapi/report/list", 90018, "too many requests")
+
+	team, err := dingtalk.NewTeamService(dingtalk.NewClient(server.Config())).
+		GetTeamReports(100, "日报", start.Unix(), start.AddDate(0, 0, 1).Unix())
+	if err != nil {
+		t.Fatalf("single member failure should not fail the query: %v", err)
+	}
+	if len(team.Members) != 4 || len(team.Missing) != 0 {
+		t.Fatalf("expected 4 members and none missing, got %d and %d", len(team.Members), len(team.Missing))
+	}
+	failed := 0
+	for _, member := range team.Members {
+		if member.Error != "" {
+			failed++
+			if len(member.Reports) != 0 {
+				t.Fatalf("failed member should have no reports: %+v", member)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Fatalf("expected 1 failed member, got %d", failed)
+	}
+}
+
+func TestGetTeamReportsStopsWhenContextCanceled(t *testing.T) {
+	start := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	server := newTeamServer(t, 50, start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for server.Requests("/topapi/report/list") < 3 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	_, err := dingtalk.NewTeamService(dingtalk.NewClient(server.Config())).WithContext(ctx).
+		GetTeamReports(100, "日报", start.Unix(), start.AddDate(0, 0, 1).Unix())
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// 取消后不再为剩余成员发起请求，最多多出正在进行中的几个
+	if requests := server.Requests("/topapi/report/list"); requests >= 20 {
+		t.Fatalf("expected requests to stop after cancel, got %d", requests)
+	}
+}
@@ -0,0 +1,20 @@
+package dingtalk
+
+import (
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/tokencache"
+)
+
+// accessTokenEntry 缓存中的企业内部应用 access_token
+type accessTokenEntry = tokencache.Entry[*models.DingTalkAccessTokenResponse]
+
+// 钉钉返回的 access_token 失效错误码
+const (
+	errCodeInvalidToken = 40014
+	errCodeTokenExpired = 42001
+)
+
+// isTokenExpiredCode 判断错误码是否表示 access_token 失效
+func isTokenExpiredCode(code int) bool {
+	return code == errCodeInvalidToken || code == errCodeTokenExpired
+}
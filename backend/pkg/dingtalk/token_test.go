@@ -0,0 +1,57 @@
+package dingtalk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/tokencache"
+)
+
+// stubAccessTokens 让客户端依次使用给定的 access_token，不请求 /gettoken
+func stubAccessTokens(client *Client, tokens ...string) {
+	client.tokens = tokencache.New(func(context.Context) (accessTokenEntry, error) {
+		token := tokens[0]
+		if len(tokens) > 1 {
+			tokens = tokens[1:]
+		}
+		return accessTokenEntry{
+			Token:     &models.DingTalkAccessTokenResponse{AccessToken: token, ExpiresIn: 7200},
+			Value:     token,
+			ExpiresIn: 2 * time.Hour,
+		}, nil
+	})
+}
+
+func TestPostWithTokenRetriesOnExpiredToken(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("access_token") == "stale" {
+			fmt.Fprint(w, `{"errcode":42001,"errmsg":"access_token expired"}`)
+			return
+		}
+		fmt.Fprint(w, `{"errcode":0,"errmsg":"ok","result":"report-1"}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(&models.DingTalkConfig{BaseURL: server.URL})
+	stubAccessTokens(client, "stale", "fresh")
+
+	var response CreateReportResponse
+	if err := client.postWithToken("/topapi/report/create", map[string]string{}, &response); err != nil {
+		t.Fatalf("postWithToken failed: %v", err)
+	}
+	if response.Result != "report-1" {
+		t.Fatalf("unexpected result: %+v", response)
+	}
+	if requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", requests)
+	}
+}
@@ -0,0 +1,36 @@
+package dingtalk
+
+import (
+	"context"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// apiCall 一次钉钉接口调用，ctx 中携带该调用的 span，下层 HTTP 请求的 span 挂在其下
+type apiCall struct {
+	ctx      context.Context
+	span     trace.Span
+	endpoint string
+	start    time.Time
+}
+
+// startAPICall 开始一次接口调用，span 以接口路径命名
+func startAPICall(ctx context.Context, endpoint string) *apiCall {
+	ctx, span := tracing.Tracer().Start(ctx, "dingtalk "+endpoint,
+		trace.WithAttributes(attribute.String("dingtalk.endpoint", endpoint)))
+	return &apiCall{ctx: ctx, span: span, endpoint: endpoint, start: time.Now()}
+}
+
+// finish 记录调用结果并结束 span，errcode 含义同 observeAPICall，非 0 时 span 标记为失败
+func (a *apiCall) finish(errcode string) {
+	observeAPICall(a.endpoint, errcode, a.start)
+	a.span.SetAttributes(attribute.String("dingtalk.errcode", errcode))
+	if errcode != "0" {
+		a.span.SetStatus(codes.Error, "errcode "+errcode)
+	}
+	a.span.End()
+}
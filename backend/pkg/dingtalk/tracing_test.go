@@ -0,0 +1,52 @@
+package dingtalk_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestAPICallSpansNestUnderCaller(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	server := dingtalktest.NewServer()
+	defer server.Close()
+	server.AddUser(dingtalktest.User{UserID: "user-1", Name: "张三"})
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "graphql Query.user")
+	contacts := dingtalk.NewContactService(dingtalk.NewClient(server.Config()))
+	if _, err := contacts.WithContext(ctx).GetUser("user-1"); err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	parent.End()
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		byName[span.Name()] = span
+	}
+	parentOf := func(name string) trace.SpanID {
+		span, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing span %q, got %v", name, byName)
+		}
+		return span.Parent().SpanID()
+	}
+
+	// access_token 刷新与接口调用都挂在调用方的 span 之下，HTTP 请求又挂在各自的接口 span 之下
+	token := byName["dingtalk /gettoken"]
+	call := byName["dingtalk /topapi/v2/user/get"]
+	if parentOf("dingtalk /gettoken") != parent.SpanContext().SpanID() ||
+		parentOf("dingtalk /topapi/v2/user/get") != parent.SpanContext().SpanID() {
+		t.Fatal("DingTalk API spans should be children of the caller's span")
+	}
+	if parentOf("GET") != token.SpanContext().SpanID() || parentOf("POST") != call.SpanContext().SpanID() {
+		t.Fatal("HTTP client spans should be children of the DingTalk API spans")
+	}
+}
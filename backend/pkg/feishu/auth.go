@@ -0,0 +1,116 @@
+package feishu
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+// AuthService 飞书认证服务
+type AuthService struct {
+	client *Client
+	config *models.FeishuConfig
+}
+
+// NewAuthService 创建新的飞书认证服务
+func NewAuthService(client *Client) *AuthService {
+	return &AuthService{
+		client: client,
+		config: client.config,
+	}
+}
+
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	return &AuthService{client: s.client.WithContext(ctx), config: s.config}
+}
+
+// GenerateAuthURL 生成授权URL，state 由调用方签发并在回调时校验
+func (s *AuthService) GenerateAuthURL(state string) string {
+	authURL := fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&response_type=code&state=%s",
+		s.client.apiURL("/open-apis/authen/v1/authorize"),
+		url.QueryEscape(s.config.AppID),
+		url.QueryEscape(s.config.RedirectURI),
+		state,
+	)
+
+	return authURL
+}
+
+// UserAccessToken 用户访问令牌
+type UserAccessToken struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// UserInfo 飞书登录用户信息
+type UserInfo struct {
+	Name      string `json:"name"`
+	EnName    string `json:"en_name"`
+	AvatarURL string `json:"avatar_url"`
+	OpenID    string `json:"open_id"`
+	UnionID   string `json:"union_id"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Mobile    string `json:"mobile"`
+	TenantKey string `json:"tenant_key"`
+}
+
+// ExchangeCodeForUser 用授权码换取用户信息
+func (s *AuthService) ExchangeCodeForUser(code string) (*models.User, error) {
+	// 1. 用授权码获取用户访问令牌
+	tokenResp, err := s.GetUserAccessToken(code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user access token: %v", err)
+	}
+
+	// 2. 用用户访问令牌获取用户信息
+	var userInfo UserInfo
+	if err := s.client.do("GET", "/open-apis/authen/v1/user_info", tokenResp.AccessToken, nil, nil, &userInfo); err != nil {
+		return nil, fmt.Errorf("failed to get user info: %v", err)
+	}
+
+	return &models.User{
+		OpenID:  userInfo.OpenID,
+		UnionID: userInfo.UnionID,
+		UserID:  userInfo.UserID,
+		Name:    userInfo.Name,
+		Avatar:  userInfo.AvatarURL,
+		Email:   userInfo.Email,
+		Mobile:  userInfo.Mobile,
+		CorpID:  userInfo.TenantKey,
+	}, nil
+}
+
+// GetUserAccessToken 通过授权码获取用户访问令牌
+func (s *AuthService) GetUserAccessToken(code string) (*UserAccessToken, error) {
+	path := "/open-apis/authen/v2/oauth/token"
+	requestBody := map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     s.config.AppID,
+		"client_secret": s.config.AppSecret,
+		"code":          code,
+		"redirect_uri":  s.config.RedirectURI,
+	}
+
+	// oauth/token 接口的令牌字段与 code 同级，不在 data 中
+	var tokenResp struct {
+		Code             int    `json:"code"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+		UserAccessToken
+	}
+	resp, err := s.client.request().SetBody(requestBody).SetResult(&tokenResp).SetError(&tokenResp).Post(s.client.apiURL(path))
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	if tokenResp.Code != 0 || tokenResp.AccessToken == "" {
+		return nil, &APIError{Endpoint: path, Code: tokenResp.Code, Msg: tokenResp.ErrorDescription, LogID: resp.Header().Get("X-Tt-Logid")}
+	}
+
+	return &tokenResp.UserAccessToken, nil
+}
@@ -0,0 +1,173 @@
+// Package feishu 封装飞书开放平台的登录与汇报接口
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/tokencache"
+	"github.com/hellodeveye/report/pkg/logging"
+	"resty.dev/v3"
+)
+
+const defaultBaseURL = "https://open.feishu.cn"
+
+// Client 飞书API客户端
+type Client struct {
+	config     *models.FeishuConfig
+	httpClient *resty.Client
+	tokens     *tokencache.Cache[string]
+	// ctx 接口调用使用的 context，见 WithContext
+	ctx context.Context
+}
+
+// NewClient 创建新的飞书客户端
+func NewClient(config *models.FeishuConfig) *Client {
+	c := &Client{
+		config:     config,
+		httpClient: logging.NewHTTPClient(30 * time.Second),
+	}
+	c.tokens = tokencache.New(c.fetchTenantAccessToken)
+	return c
+}
+
+// WithContext 返回使用 ctx 调用接口的客户端副本，共享连接与 tenant_access_token 缓存
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// request 创建携带客户端 context 的请求
+func (c *Client) request() *resty.Request {
+	return c.httpClient.R().SetContext(c.context())
+}
+
+// apiURL 拼接开放平台接口地址
+func (c *Client) apiURL(path string) string {
+	baseURL := c.config.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
+
+// apiResponse 飞书接口通用响应
+type apiResponse struct {
+	Code int             `json:"code"`
+	Msg  string          `json:"msg"`
+	Data json.RawMessage `json:"data"`
+}
+
+// GetTenantAccessToken 获取企业自建应用的 tenant_access_token，优先使用缓存
+func (c *Client) GetTenantAccessToken() (string, error) {
+	return c.tokens.Get(c.context())
+}
+
+// fetchTenantAccessToken 向飞书请求新的 tenant_access_token，由缓存统一调用
+func (c *Client) fetchTenantAccessToken(ctx context.Context) (tokencache.Entry[string], error) {
+	var entry tokencache.Entry[string]
+	path := "/open-apis/auth/v3/tenant_access_token/internal"
+	requestBody := map[string]string{
+		"app_id":     c.config.AppID,
+		"app_secret": c.config.AppSecret,
+	}
+
+	resp, err := c.httpClient.R().SetContext(ctx).SetBody(requestBody).Post(c.apiURL(path))
+	if err != nil {
+		return entry, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return entry, fmt.Errorf("read response body failed: %v", err)
+	}
+
+	var tokenResp struct {
+		Code              int    `json:"code"`
+		Msg               string `json:"msg"`
+		TenantAccessToken string `json:"tenant_access_token"`
+		Expire            int    `json:"expire"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return entry, fmt.Errorf("unmarshal response failed: %v", err)
+	}
+	if tokenResp.Code != 0 {
+		return entry, &APIError{Endpoint: path, Code: tokenResp.Code, Msg: tokenResp.Msg, LogID: resp.Header().Get("X-Tt-Logid")}
+	}
+
+	return tokencache.Entry[string]{
+		Token:     tokenResp.TenantAccessToken,
+		Value:     tokenResp.TenantAccessToken,
+		ExpiresIn: time.Duration(tokenResp.Expire) * time.Second,
+	}, nil
+}
+
+// doWithTenantToken 携带 tenant_access_token 调用接口并将 data 解析到 result，
+// token 失效时刷新后重试一次，code 非0时返回 *APIError
+func (c *Client) doWithTenantToken(method, path string, query map[string]string, requestBody interface{}, result interface{}) error {
+	for attempt := 0; ; attempt++ {
+		accessToken, err := c.GetTenantAccessToken()
+		if err != nil {
+			return err
+		}
+
+		err = c.do(method, path, accessToken, query, requestBody, result)
+		if IsTokenExpired(err) && attempt == 0 {
+			c.tokens.Invalidate(accessToken)
+			continue
+		}
+		return err
+	}
+}
+
+// do 以 Bearer token 调用飞书接口
+func (c *Client) do(method, path, accessToken string, query map[string]string, requestBody interface{}, result interface{}) error {
+	req := c.request().SetAuthToken(accessToken).SetQueryParams(query)
+	if requestBody != nil {
+		req.SetBody(requestBody)
+	}
+
+	resp, err := req.Execute(method, c.apiURL(path))
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %v", err)
+	}
+
+	var envelope apiResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if resp.StatusCode() != http.StatusOK {
+			return fmt.Errorf("API returned status %d: %s", resp.StatusCode(), string(body))
+		}
+		return fmt.Errorf("unmarshal response failed: %v", err)
+	}
+	if envelope.Code != 0 {
+		return &APIError{Endpoint: path, Code: envelope.Code, Msg: envelope.Msg, LogID: resp.Header().Get("X-Tt-Logid")}
+	}
+
+	if result != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, result); err != nil {
+			return fmt.Errorf("unmarshal response data failed: %v", err)
+		}
+	}
+	return nil
+}
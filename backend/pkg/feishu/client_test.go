@@ -0,0 +1,169 @@
+package feishu_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/feishu"
+)
+
+// fakeFeishu 模拟飞书开放平台的登录与汇报接口
+type fakeFeishu struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	tokenCount   int
+	expireTokens bool
+	taskQueries  []map[string]interface{}
+}
+
+func newFakeFeishu(t *testing.T) *fakeFeishu {
+	f := &fakeFeishu{}
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux.HandleFunc("/open-apis/auth/v3/tenant_access_token/internal", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.tokenCount++
+		f.expireTokens = false
+		f.mu.Unlock()
+		writeJSON(w, map[string]interface{}{"code": 0, "tenant_access_token": "t-token", "expire": 7200})
+	})
+	mux.HandleFunc("/open-apis/authen/v2/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["code"] != "good-code" || body["client_id"] != "cli_app" {
+			writeJSON(w, map[string]interface{}{"code": 20003, "error": "invalid_grant", "error_description": "bad code"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"code": 0, "access_token": "u-token", "expires_in": 7200})
+	})
+	mux.HandleFunc("/open-apis/authen/v1/user_info", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer u-token" {
+			writeJSON(w, map[string]interface{}{"code": 99991668, "msg": "invalid user token"})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"code": 0, "data": map[string]string{
+			"name": "张三", "open_id": "ou_1", "union_id": "on_1", "user_id": "u1",
+		}})
+	})
+	mux.HandleFunc("/open-apis/report/v1/rules/query", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"code": 0, "data": map[string]interface{}{
+			"rules": []map[string]string{{"rule_id": "rule-daily", "name": r.URL.Query().Get("rule_name")}},
+		}})
+	})
+	mux.HandleFunc("/open-apis/report/v1/tasks/query", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		expired := f.expireTokens
+		f.mu.Unlock()
+		if expired {
+			writeJSON(w, map[string]interface{}{"code": 99991663, "msg": "invalid tenant access token"})
+			return
+		}
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.taskQueries = append(f.taskQueries, body)
+		f.mu.Unlock()
+
+		if body["page_token"] == "" {
+			writeJSON(w, map[string]interface{}{"code": 0, "data": map[string]interface{}{
+				"items": []map[string]interface{}{{"task_id": "1", "rule_name": "日报"}}, "has_more": true, "page_token": "p2",
+			}})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"code": 0, "data": map[string]interface{}{
+			"items": []map[string]interface{}{{"task_id": "2", "rule_name": "日报"}}, "has_more": false,
+		}})
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeFeishu) config() *models.FeishuConfig {
+	return &models.FeishuConfig{AppID: "cli_app", AppSecret: "secret", RedirectURI: "http://localhost/callback", BaseURL: f.URL}
+}
+
+func TestExchangeCodeForUser(t *testing.T) {
+	f := newFakeFeishu(t)
+	authService := feishu.NewAuthService(feishu.NewClient(f.config()))
+
+	user, err := authService.ExchangeCodeForUser("good-code")
+	if err != nil {
+		t.Fatalf("ExchangeCodeForUser failed: %v", err)
+	}
+	if user.OpenID != "ou_1" || user.UserID != "u1" || user.Name != "张三" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	if _, err := authService.ExchangeCodeForUser("bad-code"); err == nil {
+		t.Fatal("expected error for invalid code")
+	}
+}
+
+func TestListAllTasksFollowsPageToken(t *testing.T) {
+	f := newFakeFeishu(t)
+	reportService := feishu.NewReportService(feishu.NewClient(f.config()))
+
+	tasks, err := reportService.ListAllTasks("日报", "ou_1", 1700000000, 1700600000)
+	if err != nil {
+		t.Fatalf("ListAllTasks failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].TaskID != "1" || tasks[1].TaskID != "2" {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+	if len(f.taskQueries) != 2 || f.taskQueries[0]["rule_id"] != "rule-daily" || f.taskQueries[0]["user_id"] != "ou_1" {
+		t.Fatalf("unexpected task queries: %+v", f.taskQueries)
+	}
+	if f.tokenCount != 1 {
+		t.Fatalf("tenant token should be fetched once, got %d", f.tokenCount)
+	}
+}
+
+func TestExpiredTenantTokenIsRefreshed(t *testing.T) {
+	f := newFakeFeishu(t)
+	reportService := feishu.NewReportService(feishu.NewClient(f.config()))
+
+	if _, err := reportService.QueryTasks("", "ou_1", 0, 1, "", 20); err != nil {
+		t.Fatalf("QueryTasks failed: %v", err)
+	}
+	f.mu.Lock()
+	f.expireTokens = true
+	f.mu.Unlock()
+
+	if _, err := reportService.QueryTasks("", "ou_1", 0, 1, "", 20); err != nil {
+		t.Fatalf("QueryTasks after expiry failed: %v", err)
+	}
+	if f.tokenCount != 2 {
+		t.Fatalf("expected token refresh, got %d token requests", f.tokenCount)
+	}
+}
+
+func TestWithContextCancelsRequests(t *testing.T) {
+	f := newFakeFeishu(t)
+	reportService := feishu.NewReportService(feishu.NewClient(f.config()))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := reportService.WithContext(ctx).QueryRules("日报"); err == nil {
+		t.Fatal("expected canceled context to abort the request")
+	}
+	// tenant_access_token 缓存不受单个请求的 context 影响
+	if f.tokenCount != 1 {
+		t.Fatalf("tenant token should be fetched despite cancellation, got %d", f.tokenCount)
+	}
+	if _, err := reportService.QueryRules("日报"); err != nil {
+		t.Fatalf("QueryRules failed: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package feishu
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError 飞书接口返回的业务错误（code != 0）
+type APIError struct {
+	Endpoint string
+	Code     int
+	Msg      string
+	LogID    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("feishu %s failed: code=%d msg=%s log_id=%s", e.Endpoint, e.Code, e.Msg, e.LogID)
+}
+
+// 飞书返回的 tenant_access_token 失效错误码
+const (
+	codeInvalidTenantToken = 99991663
+	codeRateLimited        = 99991400
+)
+
+func isTokenExpiredCode(code int) bool {
+	return code == codeInvalidTenantToken
+}
+
+// IsRateLimited 判断错误是否为飞书限流
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == codeRateLimited
+}
+
+// IsTokenExpired 判断错误是否为 tenant_access_token 失效
+func IsTokenExpired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && isTokenExpiredCode(apiErr.Code)
+}
@@ -0,0 +1,74 @@
+package feishu
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// ReportProvider 基于飞书汇报接口实现 platform.ReportProvider。
+// 飞书开放平台只提供汇报的查询接口，列出全部规则、创建汇报与保存草稿返回 platform.ErrNotSupported。
+type ReportProvider struct {
+	reports *ReportService
+}
+
+var _ platform.ReportProvider = (*ReportProvider)(nil)
+
+// NewReportProvider 创建飞书汇报服务
+func NewReportProvider(client *Client) *ReportProvider {
+	return &ReportProvider{reports: NewReportService(client)}
+}
+
+func (p *ReportProvider) ListTemplates(ctx context.Context, userID string) ([]platform.Template, error) {
+	return nil, platform.ErrNotSupported
+}
+
+func (p *ReportProvider) GetTemplate(ctx context.Context, userID, name string) (*platform.Template, error) {
+	rules, err := p.reports.WithContext(ctx).QueryRules(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("feishu report rule %q not found", name)
+	}
+
+	rule := rules[0]
+	template := &platform.Template{ID: rule.RuleID, Name: rule.Name}
+	for i, field := range rule.FormSchema {
+		template.Fields = append(template.Fields, platform.Field{Name: field.Name, Sort: i})
+	}
+	return template, nil
+}
+
+func (p *ReportProvider) ListReports(ctx context.Context, userID, templateName string, startTime, endTime int64) ([]platform.Report, error) {
+	tasks, err := p.reports.WithContext(ctx).ListAllTasks(templateName, userID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]platform.Report, 0, len(tasks))
+	for _, task := range tasks {
+		report := platform.Report{
+			ID:           task.TaskID,
+			TemplateName: task.RuleName,
+			CreatorID:    task.FromUserID,
+			CreatorName:  task.FromUserName,
+			DeptName:     task.DepartmentName,
+			CreateTime:   task.CommitTime * 1000,
+		}
+		for i, content := range task.FormContents {
+			report.Contents = append(report.Contents, platform.Content{Key: content.FieldName, Value: content.FieldValue, Sort: i})
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (p *ReportProvider) CreateReport(ctx context.Context, userID string, req platform.CreateReportRequest) (*platform.Report, error) {
+	return nil, platform.ErrNotSupported
+}
+
+func (p *ReportProvider) SaveDraft(ctx context.Context, userID string, req platform.CreateReportRequest) (string, error) {
+	return "", platform.ErrNotSupported
+}
@@ -0,0 +1,128 @@
+package feishu
+
+import "context"
+
+// ReportService 飞书汇报服务
+type ReportService struct {
+	client *Client
+}
+
+// NewReportService 创建新的飞书汇报服务
+func NewReportService(client *Client) *ReportService {
+	return &ReportService{client: client}
+}
+
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *ReportService) WithContext(ctx context.Context) *ReportService {
+	return &ReportService{client: s.client.WithContext(ctx)}
+}
+
+// Rule 汇报规则，对应钉钉的日志模板
+type Rule struct {
+	RuleID          string      `json:"rule_id"`
+	Name            string      `json:"name"`
+	IconName        string      `json:"icon_name"`
+	CreatedAt       int64       `json:"created_at"`
+	CreatorUserID   string      `json:"creator_user_id"`
+	CreatorUserName string      `json:"creator_user_name"`
+	FormSchema      []RuleField `json:"form_schema"`
+}
+
+// RuleField 汇报规则中的表单字段
+type RuleField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// QueryRules 按名称查询汇报规则（模板）
+func (s *ReportService) QueryRules(ruleName string) ([]Rule, error) {
+	query := map[string]string{
+		"rule_name":       ruleName,
+		"include_deleted": "0",
+		"user_id_type":    "open_id",
+	}
+
+	var result struct {
+		Rules []Rule `json:"rules"`
+	}
+	if err := s.client.doWithTenantToken("GET", "/open-apis/report/v1/rules/query", query, nil, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Rules, nil
+}
+
+type FormContent struct {
+	FieldID    string `json:"field_id"`
+	FieldName  string `json:"field_name"`
+	FieldValue string `json:"field_value"`
+}
+
+// Task 一条已提交的汇报
+type Task struct {
+	TaskID         string        `json:"task_id"`
+	RuleName       string        `json:"rule_name"`
+	FromUserID     string        `json:"from_user_id"`
+	FromUserName   string        `json:"from_user_name"`
+	DepartmentName string        `json:"department_name"`
+	CommitTime     int64         `json:"commit_time"`
+	FormContents   []FormContent `json:"form_contents"`
+}
+
+type TaskListResult struct {
+	Items     []Task `json:"items"`
+	HasMore   bool   `json:"has_more"`
+	PageToken string `json:"page_token"`
+}
+
+// maxTaskPageSize 汇报任务查询接口单页最大条数
+const maxTaskPageSize = 20
+
+// QueryTasks 查询汇报任务（已提交的汇报）。
+// userOpenID 为提交人的 open_id，startTime、endTime 为秒级时间戳。
+func (s *ReportService) QueryTasks(ruleID, userOpenID string, startTime, endTime int64, pageToken string, pageSize int) (*TaskListResult, error) {
+	requestBody := map[string]interface{}{
+		"commit_start_time": startTime,
+		"commit_end_time":   endTime,
+		"rule_id":           ruleID,
+		"user_id":           userOpenID,
+		"page_token":        pageToken,
+		"page_size":         pageSize,
+	}
+
+	var result TaskListResult
+	if err := s.client.doWithTenantToken("POST", "/open-apis/report/v1/tasks/query", map[string]string{"user_id_type": "open_id"}, requestBody, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ListAllTasks 按规则名称查询时间范围内的全部汇报，自动翻页
+func (s *ReportService) ListAllTasks(ruleName, userOpenID string, startTime, endTime int64) ([]Task, error) {
+	var ruleID string
+	if ruleName != "" {
+		rules, err := s.QueryRules(ruleName)
+		if err != nil {
+			return nil, err
+		}
+		if len(rules) == 0 {
+			return []Task{}, nil
+		}
+		ruleID = rules[0].RuleID
+	}
+
+	tasks := []Task{}
+	pageToken := ""
+	for {
+		result, err := s.QueryTasks(ruleID, userOpenID, startTime, endTime, pageToken, maxTaskPageSize)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, result.Items...)
+		if !result.HasMore || result.PageToken == "" || result.PageToken == pageToken {
+			return tasks, nil
+		}
+		pageToken = result.PageToken
+	}
+}
@@ -0,0 +1,144 @@
+// Package llmtest 提供基于 httptest 的 OpenAI 兼容大模型模拟服务
+package llmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/llm"
+)
+
+// APIKey 模拟服务接受的密钥
+const APIKey = "test-llm-key"
+
+// Model 模拟服务返回的模型名称
+const Model = "fake-model"
+
+// Server 模拟的 chat/completions 服务。
+// 回复内容由 Reply 决定，流式输出时按 ChunkSize 个字符切分，每段间隔 ChunkDelay。
+type Server struct {
+	*httptest.Server
+
+	Reply      func(messages []llm.Message) string
+	ChunkSize  int
+	ChunkDelay time.Duration
+
+	mu       sync.Mutex
+	requests [][]llm.Message
+}
+
+// NewServer 启动模拟服务，reply 为空时原样返回最后一条消息
+func NewServer(reply func(messages []llm.Message) string) *Server {
+	if reply == nil {
+		reply = func(messages []llm.Message) string {
+			return messages[len(messages)-1].Content
+		}
+	}
+	s := &Server{Reply: reply, ChunkSize: 4}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat/completions", s.handleCompletions)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config 返回指向模拟服务的大模型配置
+func (s *Server) Config() *models.LLMConfig {
+	return &models.LLMConfig{
+		BaseURL:   s.URL,
+		APIKey:    APIKey,
+		Model:     Model,
+		MaxTokens: 2000,
+		Timeout:   10,
+	}
+}
+
+// Requests 返回收到的全部请求消息
+func (s *Server) Requests() [][]llm.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]llm.Message(nil), s.requests...)
+}
+
+func (s *Server) handleCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+APIKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]string{"message": "invalid api key", "type": "authentication_error"},
+		})
+		return
+	}
+
+	var req struct {
+		Messages []llm.Message `json:"messages"`
+		Stream   bool          `json:"stream"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req.Messages)
+	s.mu.Unlock()
+
+	reply := s.Reply(req.Messages)
+	usage := llm.Usage{PromptTokens: promptTokens(req.Messages), CompletionTokens: utf8.RuneCountInString(reply)}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+
+	if !req.Stream {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"model": Model,
+			"choices": []map[string]interface{}{
+				{"index": 0, "message": llm.Message{Role: llm.RoleAssistant, Content: reply}, "finish_reason": "stop"},
+			},
+			"usage": usage,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher, _ := w.(http.Flusher)
+	runes := []rune(reply)
+	for start := 0; start < len(runes); start += s.ChunkSize {
+		end := start + s.ChunkSize
+		if end > len(runes) {
+			end = len(runes)
+		}
+		writeEvent(w, map[string]interface{}{
+			"model":   Model,
+			"choices": []map[string]interface{}{{"index": 0, "delta": map[string]string{"content": string(runes[start:end])}}},
+		})
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(s.ChunkDelay):
+		}
+	}
+	writeEvent(w, map[string]interface{}{"model": Model, "choices": []interface{}{}, "usage": usage})
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func writeEvent(w http.ResponseWriter, v interface{}) {
+	data, _ := json.Marshal(v)
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+func promptTokens(messages []llm.Message) int {
+	var n int
+	for _, message := range messages {
+		n += utf8.RuneCountInString(message.Content)
+	}
+	return n
+}
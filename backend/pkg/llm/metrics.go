@@ -0,0 +1,19 @@
+package llm
+
+import (
+	"github.com/hellodeveye/report/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var tokensTotal = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "llm_tokens_total",
+	Help:      "大模型 token 用量，按模型与类型（prompt/completion）统计",
+}, []string{"model", "type"})
+
+// recordUsage 累计一次调用的 token 用量，model 为请求使用的模型
+func recordUsage(model string, usage Usage) {
+	tokensTotal.WithLabelValues(model, "prompt").Add(float64(usage.PromptTokens))
+	tokensTotal.WithLabelValues(model, "completion").Add(float64(usage.CompletionTokens))
+}
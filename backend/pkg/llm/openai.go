@@ -0,0 +1,196 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/logging"
+	"resty.dev/v3"
+)
+
+// OpenAIProvider 兼容 OpenAI chat/completions 协议的服务（DeepSeek、火山方舟等）
+type OpenAIProvider struct {
+	config     *models.LLMConfig
+	httpClient *resty.Client
+}
+
+// NewOpenAIProvider 创建 OpenAI 兼容的大模型服务
+func NewOpenAIProvider(config *models.LLMConfig) *OpenAIProvider {
+	timeout := time.Duration(config.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = 120 * time.Second
+	}
+	return &OpenAIProvider{
+		config:     config,
+		httpClient: logging.NewHTTPClient(timeout),
+	}
+}
+
+type chatCompletionRequest struct {
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Stream        bool           `json:"stream"`
+	StreamOptions *streamOptions `json:"stream_options,omitempty"`
+	Temperature   float64        `json:"temperature,omitempty"`
+	MaxTokens     int            `json:"max_tokens,omitempty"`
+}
+
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type chatCompletionResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message Message `json:"message"`
+		Delta   Message `json:"delta"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage"`
+}
+
+// Chat 调用 chat/completions 一次性返回完整结果
+func (p *OpenAIProvider) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if p.config.APIKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	body := p.buildRequest(req, false)
+	resp, err := p.httpClient.R().
+		SetContext(ctx).
+		SetAuthToken(p.config.APIKey).
+		SetBody(body).
+		Post(p.completionsURL())
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %v", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("LLM API returned status %d: %s", resp.StatusCode(), string(respBody))
+	}
+
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(respBody, &completion); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed: %v", err)
+	}
+	if len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("LLM API returned no choices")
+	}
+
+	result := &ChatResponse{Model: completion.Model, Content: completion.Choices[0].Message.Content}
+	if completion.Usage != nil {
+		result.Usage = *completion.Usage
+	}
+	recordUsage(body.Model, result.Usage)
+	return result, nil
+}
+
+// ChatStream 以 SSE 流式调用 chat/completions
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*ChatResponse, error) {
+	if p.config.APIKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	body := p.buildRequest(req, true)
+	resp, err := p.httpClient.R().
+		SetContext(ctx).
+		SetAuthToken(p.config.APIKey).
+		SetHeader("Accept", "text/event-stream").
+		SetBody(body).
+		SetDoNotParseResponse(true).
+		Post(p.completionsURL())
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode() != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("LLM API returned status %d: %s", resp.StatusCode(), string(respBody))
+	}
+
+	result := &ChatResponse{}
+	var content strings.Builder
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk chatCompletionResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("unmarshal stream chunk failed: %v", err)
+		}
+		if chunk.Model != "" {
+			result.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta.Content
+		content.WriteString(delta)
+		if onDelta != nil {
+			if err := onDelta(delta); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read stream failed: %v", err)
+	}
+
+	result.Content = content.String()
+	recordUsage(body.Model, result.Usage)
+	return result, nil
+}
+
+// buildRequest 合并请求参数与服务端默认配置
+func (p *OpenAIProvider) buildRequest(req ChatRequest, stream bool) chatCompletionRequest {
+	body := chatCompletionRequest{
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Stream:      stream,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+	if body.Model == "" {
+		body.Model = p.config.Model
+	}
+	if body.Temperature == 0 {
+		body.Temperature = p.config.Temperature
+	}
+	if body.MaxTokens == 0 {
+		body.MaxTokens = p.config.MaxTokens
+	}
+	if stream {
+		body.StreamOptions = &streamOptions{IncludeUsage: true}
+	}
+	return body
+}
+
+func (p *OpenAIProvider) completionsURL() string {
+	return strings.TrimRight(p.config.BaseURL, "/") + "/chat/completions"
+}
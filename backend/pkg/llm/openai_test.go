@@ -0,0 +1,76 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/llm/llmtest"
+)
+
+func TestOpenAIProviderChat(t *testing.T) {
+	server := llmtest.NewServer(func(messages []llm.Message) string { return "你好，世界" })
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.Config())
+	resp, err := provider.Chat(context.Background(), llm.ChatRequest{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "你好，世界" || resp.Model != llmtest.Model {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Usage.CompletionTokens != 5 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIProviderChatStream(t *testing.T) {
+	reply := strings.Repeat("流式输出测试", 5)
+	server := llmtest.NewServer(func(messages []llm.Message) string { return reply })
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.Config())
+	var deltas []string
+	resp, err := provider.ChatStream(context.Background(), llm.ChatRequest{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "hi"}},
+	}, func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if len(deltas) < 2 || strings.Join(deltas, "") != reply || resp.Content != reply {
+		t.Fatalf("unexpected stream: %d deltas, content %q", len(deltas), resp.Content)
+	}
+	if resp.Usage.TotalTokens == 0 {
+		t.Fatal("expected usage in final chunk")
+	}
+}
+
+func TestOpenAIProviderChatStreamAbort(t *testing.T) {
+	server := llmtest.NewServer(func(messages []llm.Message) string { return strings.Repeat("x", 100) })
+	defer server.Close()
+
+	provider := llm.NewOpenAIProvider(server.Config())
+	stop := errors.New("stop")
+	_, err := provider.ChatStream(context.Background(), llm.ChatRequest{
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "hi"}},
+	}, func(delta string) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected stop error, got %v", err)
+	}
+}
+
+func TestOpenAIProviderNotConfigured(t *testing.T) {
+	provider := llm.NewOpenAIProvider(&models.LLMConfig{BaseURL: "http://127.0.0.1:0"})
+	if _, err := provider.Chat(context.Background(), llm.ChatRequest{}); !errors.Is(err, llm.ErrNotConfigured) {
+		t.Fatalf("expected ErrNotConfigured, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+// Package llm 封装服务端调用的大模型接口
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotConfigured 未配置大模型服务
+var ErrNotConfigured = errors.New("llm provider is not configured")
+
+// 对话消息角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message 对话消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest 对话补全请求，未设置的参数使用服务端配置的默认值
+type ChatRequest struct {
+	Model       string
+	Messages    []Message
+	Temperature float64
+	MaxTokens   int
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatResponse 对话补全结果
+type ChatResponse struct {
+	Model   string
+	Content string
+	Usage   Usage
+}
+
+// Provider 大模型服务提供方
+type Provider interface {
+	// Chat 一次性返回完整结果
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	// ChatStream 流式生成，每收到一段增量内容调用一次 onDelta，
+	// onDelta 返回错误时中止生成。结束后返回完整内容与用量。
+	ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (*ChatResponse, error)
+}
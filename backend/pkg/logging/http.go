@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/tracing"
+	"resty.dev/v3"
+)
+
+// debugBodyLimit 调试日志中请求体与响应体的最大字节数，超出时不输出内容
+const debugBodyLimit = 8 * 1024
+
+// NewHTTPClient 创建调用第三方接口的 resty 客户端：请求携带 context 中的请求 ID 与 traceparent，
+// 每次调用记录一个 client span，resty 自身的日志转入 slog；开启 HTTPDebug 时以 debug 级别记录隐藏了敏感信息的请求与响应
+func NewHTTPClient(timeout time.Duration) *resty.Client {
+	client := tracing.InstrumentClient(resty.New().
+		SetTimeout(timeout).
+		SetLogger(restyLogger{}).
+		AddRequestMiddleware(propagateRequestID))
+	if httpDebug.Load() {
+		// 不使用 resty 默认的多行格式，由 logDebug 输出结构化日志
+		client.EnableDebug().
+			SetDebugBodyLimit(debugBodyLimit).
+			SetDebugLogFormatter(nil).
+			OnDebugLog(logDebug)
+	}
+	return client
+}
+
+func propagateRequestID(c *resty.Client, r *resty.Request) error {
+	if id := RequestID(r.Context()); id != "" {
+		r.SetHeader(RequestIDHeader, id)
+	}
+	return nil
+}
+
+// logDebug 输出单次调用的请求与响应，地址与请求体、响应体中的令牌和密钥会被隐藏，
+// 请求头中只保留请求 ID
+func logDebug(dl *resty.DebugLog) {
+	req, resp := dl.Request, dl.Response
+	attrs := []any{
+		"method", req.Method,
+		"url", Redact(req.Host + req.URI),
+		"request_body", Redact(req.Body),
+	}
+	if id := req.Header.Get(RequestIDHeader); id != "" {
+		attrs = append(attrs, "request_id", id)
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			"status", resp.StatusCode,
+			"duration", resp.Duration,
+			"response_body", Redact(resp.Body),
+		)
+	}
+	slog.Debug("http call", attrs...)
+}
+
+// restyLogger 将 resty 的日志转入 slog
+type restyLogger struct{}
+
+func (restyLogger) Errorf(format string, v ...any) {
+	slog.Error(Redact(fmt.Sprintf(format, v...)), "component", "resty")
+}
+
+func (restyLogger) Warnf(format string, v ...any) {
+	slog.Warn(Redact(fmt.Sprintf(format, v...)), "component", "resty")
+}
+
+func (restyLogger) Debugf(format string, v ...any) {
+	slog.Debug(Redact(fmt.Sprintf(format, v...)), "component", "resty")
+}
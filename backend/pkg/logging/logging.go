@@ -0,0 +1,91 @@
+// Package logging 基于 log/slog 的结构化日志：按配置输出 text 或 json，
+// 自动附加请求 ID，并隐藏令牌、授权码、密钥等敏感信息
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"github.com/hellodeveye/report/internal/models"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader 请求 ID 的 HTTP 头，入站请求与调用第三方接口时使用同一个头
+const RequestIDHeader = "X-Request-ID"
+
+// httpDebug 是否输出第三方接口的请求与响应，由 Setup 设置
+var httpDebug atomic.Bool
+
+// Setup 按配置初始化全局日志，标准库 log 的输出同样转入 slog
+func Setup(config *models.LogConfig) {
+	slog.SetDefault(slog.New(NewHandler(os.Stderr, config)))
+	httpDebug.Store(config.HTTPDebug)
+}
+
+// NewHandler 创建隐藏敏感信息并附加请求 ID 的日志处理器
+func NewHandler(w io.Writer, config *models.LogConfig) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(config.Level),
+		ReplaceAttr: redactAttr,
+	}
+	var handler slog.Handler
+	if config.Format == "json" {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	return contextHandler{handler}
+}
+
+func parseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 将请求 ID 写入 context，之后带该 context 的日志与第三方接口调用都会携带
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID 返回 context 中的请求 ID，不存在时返回空字符串
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler 为日志附加 context 中的请求 ID 与链路 ID
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.Handler.Handle(ctx, r)
+	}
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"query params", "/gettoken?appkey=key&appsecret=s3cret", "/gettoken?appkey=key&appsecret=******"},
+		{"access token query", "/topapi/report/list?access_token=abc123", "/topapi/report/list?access_token=******"},
+		{"form body", "code=auth-code&state=xyz&grant_type=authorization_code", "code=******&state=******&grant_type=authorization_code"},
+		{"json fields", `{"clientId":"key","clientSecret":"s3cret","code":"auth-code"}`, `{"clientId":"key","clientSecret":"******","code":"******"}`},
+		{"json token response", `{"accessToken": "tok", "refreshToken":"ref","expireIn":7200}`, `{"accessToken": "******", "refreshToken":"******","expireIn":7200}`},
+		{"bearer token", "Authorization: Bearer sk-abc.def", "Authorization: Bearer ******"},
+		{"errcode is kept", `{"errcode":0,"errmsg":"ok"}`, `{"errcode":0,"errmsg":"ok"}`},
+		{"plain message", "Failed to refresh token: session revoked", "Failed to refresh token: session revoked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Fatalf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerAddsRequestIDAndRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, &models.LogConfig{Level: "info", Format: "json"}))
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "exchange",
+		"code", "auth-code",
+		"app_secret", "s3cret",
+		"url", "https://oapi.dingtalk.com/gettoken?appsecret=s3cret",
+		"error", errors.New(`API returned status 400: {"accessToken":"user-token"}`),
+	)
+	logger.Debug("hidden")
+
+	out := buf.String()
+	for _, secret := range []string{"auth-code", "s3cret", "user-token"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("log should not contain %q: %s", secret, out)
+		}
+	}
+	if !strings.Contains(out, `"request_id":"req-1"`) {
+		t.Fatalf("log should contain request id: %s", out)
+	}
+	if strings.Contains(out, "hidden") {
+		t.Fatalf("debug log should be filtered at info level: %s", out)
+	}
+}
+
+func TestHTTPClientPropagatesRequestIDAndRedactsDebug(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(RequestIDHeader)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"errcode":0,"access_token":"server-token","expires_in":7200}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(&buf, &models.LogConfig{Level: "debug", Format: "json"})))
+	httpDebug.Store(true)
+	defer func() {
+		slog.SetDefault(previous)
+		httpDebug.Store(false)
+	}()
+
+	client := NewHTTPClient(5 * time.Second)
+	ctx := WithRequestID(context.Background(), "req-2")
+	if _, err := client.R().SetContext(ctx).Get(server.URL + "/gettoken?appkey=key&appsecret=s3cret"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if received != "req-2" {
+		t.Fatalf("expected request id to be sent, got %q", received)
+	}
+	out := buf.String()
+	if !strings.Contains(out, `"msg":"http call"`) || !strings.Contains(out, `"request_id":"req-2"`) {
+		t.Fatalf("expected debug log with request id: %s", out)
+	}
+	for _, secret := range []string{"s3cret", "server-token"} {
+		if strings.Contains(out, secret) {
+			t.Fatalf("debug log should not contain %q: %s", secret, out)
+		}
+	}
+}
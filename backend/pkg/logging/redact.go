@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// mask 替换敏感值的占位符
+const mask = "******"
+
+// sensitiveKeys 需要隐藏取值的参数名，覆盖各平台的 access_token、appsecret、授权码与 OAuth state
+const sensitiveKeys = `(?i:access_?token|refresh_?token|app_?secret|client_?secret|corp_?secret|api_?key|code|state|password)`
+
+var (
+	// queryParamPattern 匹配 URL 查询参数与表单中的 key=value
+	queryParamPattern = regexp.MustCompile(`(^|[?&\s])(` + sensitiveKeys + `)=[^&#\s"']*`)
+	// jsonFieldPattern 匹配 JSON 中的 "key": "value"
+	jsonFieldPattern = regexp.MustCompile(`("` + sensitiveKeys + `"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	// bearerPattern 匹配 Authorization 头中的令牌
+	bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`)
+)
+
+// Redact 隐藏字符串中出现的令牌、授权码、密钥等敏感值，用于请求地址、请求体与错误信息
+func Redact(s string) string {
+	s = queryParamPattern.ReplaceAllString(s, "${1}${2}="+mask)
+	s = jsonFieldPattern.ReplaceAllString(s, `${1}"`+mask+`"`)
+	return bearerPattern.ReplaceAllString(s, "${1}"+mask)
+}
+
+// isSensitiveKey 判断日志字段或 HTTP 头是否整体属于敏感信息
+func isSensitiveKey(key string) bool {
+	k := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	switch k {
+	case "code", "state", "password", "authorization", "cookie", "setcookie":
+		return true
+	}
+	return strings.Contains(k, "token") || strings.Contains(k, "secret") || strings.Contains(k, "apikey")
+}
+
+// redactAttr 作为 slog.HandlerOptions.ReplaceAttr，隐藏敏感字段及字符串、错误中的敏感值
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, mask)
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(Redact(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			a.Value = slog.StringValue(Redact(err.Error()))
+		}
+	}
+	return a
+}
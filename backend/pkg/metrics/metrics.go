@@ -0,0 +1,32 @@
+// Package metrics 提供 Prometheus 指标注册表与 /metrics 处理器，
+// 各模块在自己的包内定义指标并注册到 Registry
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace 本服务指标名的统一前缀
+const Namespace = "report"
+
+// Registry 服务指标注册表，包含 Go 运行时与进程指标
+var Registry = prometheus.NewRegistry()
+
+// DurationBuckets 耗时直方图的分桶（秒），覆盖普通接口到大模型生成的耗时范围
+var DurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler 以 Prometheus 文本格式输出 Registry 中的全部指标
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
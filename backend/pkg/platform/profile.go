@@ -0,0 +1,27 @@
+package platform
+
+import "context"
+
+// Department 用户所在部门
+type Department struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Profile 用户在平台通讯录中的资料
+type Profile struct {
+	Name        string       `json:"name"`
+	Avatar      string       `json:"avatar_url"`
+	Email       string       `json:"email"`
+	Mobile      string       `json:"mobile"`
+	Title       string       `json:"title"`
+	Departments []Department `json:"departments"`
+	// Manager 直属主管，未设置时为 nil
+	Manager *Recipient `json:"manager"`
+}
+
+// ProfileProvider 平台通讯录服务，userID 为平台内的用户ID
+type ProfileProvider interface {
+	// GetProfile 返回用户资料，包括所在部门与直属主管
+	GetProfile(ctx context.Context, userID string) (*Profile, error)
+}
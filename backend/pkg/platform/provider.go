@@ -0,0 +1,95 @@
+// Package platform 定义与具体办公平台（钉钉、飞书等）无关的日志模型与 ReportProvider 接口，
+// GraphQL 按登录用户所在平台选择对应的实现
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotSupported 平台不支持该操作
+var ErrNotSupported = errors.New("operation not supported by platform")
+
+// ErrInvalidRequest 请求参数不合法，具体原因见包装后的错误信息
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Field 模板字段
+type Field struct {
+	Name string `json:"name"`
+	Sort int    `json:"sort"`
+	Type int    `json:"type"`
+}
+
+// Recipient 日志接收人或接收群
+type Recipient struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Template 日志模板
+type Template struct {
+	ID        string      `json:"id"`
+	Name      string      `json:"name"`
+	IconURL   string      `json:"icon_url"`
+	Fields    []Field     `json:"fields"`
+	Receivers []Recipient `json:"receivers"`
+	Chats     []Recipient `json:"chats"`
+}
+
+// Content 日志中单个字段的内容
+type Content struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Sort  int    `json:"sort"`
+	Type  int    `json:"type"`
+}
+
+// Report 日志，CreateTime 为毫秒级时间戳
+type Report struct {
+	ID           string      `json:"id"`
+	TemplateName string      `json:"template_name"`
+	CreatorID    string      `json:"creator_id"`
+	CreatorName  string      `json:"creator_name"`
+	DeptName     string      `json:"dept_name"`
+	CreateTime   int64       `json:"create_time"`
+	Contents     []Content   `json:"contents"`
+	ToChat       bool        `json:"to_chat"`
+	Receivers    []Recipient `json:"receivers"`
+	Chats        []Recipient `json:"chats"`
+}
+
+// CreateReportRequest 创建日志或保存草稿的请求。
+// Contents 只需填写 Key 与 Value，字段顺序和类型由实现按模板补全。
+type CreateReportRequest struct {
+	TemplateID          string
+	TemplateName        string
+	Contents            []Content
+	ToUserIDs           []string
+	ToChatIDs           []string
+	ToChat              bool
+	UseTemplateDefaults bool
+}
+
+// ReportProvider 平台日志服务。userID 为平台内的用户ID，startTime、endTime 为秒级时间戳。
+type ReportProvider interface {
+	// ListTemplates 返回用户可用的日志模板
+	ListTemplates(ctx context.Context, userID string) ([]Template, error)
+	// GetTemplate 按名称获取模板详情（含字段与默认接收人）
+	GetTemplate(ctx context.Context, userID, name string) (*Template, error)
+	// ListReports 返回时间范围内用户提交的全部日志
+	ListReports(ctx context.Context, userID, templateName string, startTime, endTime int64) ([]Report, error)
+	// CreateReport 创建日志并发送给接收人
+	CreateReport(ctx context.Context, userID string, req CreateReportRequest) (*Report, error)
+	// SaveDraft 将日志保存为平台侧草稿，返回草稿ID
+	SaveDraft(ctx context.Context, userID string, req CreateReportRequest) (string, error)
+}
+
+// InvalidRecipientsError 指定的接收人不在企业通讯录中
+type InvalidRecipientsError struct {
+	UserIDs []string
+}
+
+func (e *InvalidRecipientsError) Error() string {
+	return fmt.Sprintf("recipients not found in organization: %v", e.UserIDs)
+}
@@ -0,0 +1,20 @@
+package platform
+
+import "context"
+
+// Membership 用户在平台通讯录中的组织关系，用于推导数据访问角色
+type Membership struct {
+	// Admin 企业管理员或老板
+	Admin bool
+	// Departments 用户所在部门ID
+	Departments []string
+	// LedDepartments 用户担任主管的部门ID
+	LedDepartments []string
+	// ManagerID 直属主管的平台用户ID
+	ManagerID string
+}
+
+// RoleProvider 平台组织关系查询服务，userID 为平台内的用户ID
+type RoleProvider interface {
+	GetMembership(ctx context.Context, userID string) (*Membership, error)
+}
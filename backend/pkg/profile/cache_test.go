@@ -0,0 +1,34 @@
+package profile
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+)
+
+func TestCacheEvictsExpiredProfiles(t *testing.T) {
+	s := NewService(nil, time.Minute)
+	now := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		if _, err := s.Get(ctx, auth.NewPrincipal(auth.PlatformFeishu, &models.User{OpenID: userID})); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+	if len(s.cache) != 2 {
+		t.Fatalf("expected 2 cached profiles, got %d", len(s.cache))
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := s.Get(ctx, auth.NewPrincipal(auth.PlatformFeishu, &models.User{OpenID: "user-3"})); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if len(s.cache) != 1 {
+		t.Fatalf("expected expired profiles to be evicted, cache has %d entries", len(s.cache))
+	}
+}
@@ -0,0 +1,118 @@
+// Package profile 查询当前登录用户的资料，并按用户缓存以减少通讯录接口调用
+package profile
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// Profile 当前登录用户的资料：登录身份加平台通讯录信息
+type Profile struct {
+	Platform    string                `json:"platform"`
+	UserID      string                `json:"userid"`
+	OpenID      string                `json:"open_id"`
+	UnionID     string                `json:"union_id"`
+	CorpID      string                `json:"corp_id"`
+	Name        string                `json:"name"`
+	Avatar      string                `json:"avatar_url"`
+	Email       string                `json:"email"`
+	Mobile      string                `json:"mobile"`
+	Title       string                `json:"title"`
+	Departments []platform.Department `json:"departments"`
+	Manager     *platform.Recipient   `json:"manager"`
+}
+
+type cacheEntry struct {
+	profile   Profile
+	expiresAt time.Time
+}
+
+// Service 用户资料服务，资料按 Principal.Subject 缓存 ttl 时长
+type Service struct {
+	providers map[string]platform.ProfileProvider
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+	// sweepAt 下次清理过期缓存的时间，避免不再登录的用户的缓存一直占用内存
+	sweepAt time.Time
+}
+
+// NewService 创建用户资料服务，providers 按平台名称索引；
+// 没有对应 provider 的平台只返回登录时记录的身份信息
+func NewService(providers map[string]platform.ProfileProvider, ttl time.Duration) *Service {
+	return &Service{
+		providers: providers,
+		ttl:       ttl,
+		now:       time.Now,
+		cache:     make(map[string]cacheEntry),
+	}
+}
+
+// Get 返回登录用户的资料，缓存未命中或已过期时从平台通讯录拉取
+func (s *Service) Get(ctx context.Context, principal *auth.Principal) (*Profile, error) {
+	now := s.now()
+	s.mu.Lock()
+	entry, ok := s.cache[principal.Subject]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		profile := entry.profile
+		return &profile, nil
+	}
+
+	profile := Profile{
+		Platform:    principal.Platform,
+		UserID:      principal.UserID,
+		OpenID:      principal.OpenID,
+		UnionID:     principal.UnionID,
+		CorpID:      principal.CorpID,
+		Name:        principal.Name,
+		Departments: []platform.Department{},
+	}
+	if provider, ok := s.providers[principal.Platform]; ok {
+		details, err := provider.GetProfile(ctx, principal.PlatformUserID())
+		if err != nil {
+			return nil, err
+		}
+		profile.Name = details.Name
+		profile.Avatar = details.Avatar
+		profile.Email = details.Email
+		profile.Mobile = details.Mobile
+		profile.Title = details.Title
+		profile.Departments = details.Departments
+		profile.Manager = details.Manager
+	}
+
+	if s.ttl > 0 {
+		s.mu.Lock()
+		s.evictExpired(now)
+		s.cache[principal.Subject] = cacheEntry{profile: profile, expiresAt: now.Add(s.ttl)}
+		s.mu.Unlock()
+	}
+	return &profile, nil
+}
+
+// evictExpired 每隔 ttl 删除一次过期的缓存，调用方需持有 mu
+func (s *Service) evictExpired(now time.Time) {
+	if now.Before(s.sweepAt) {
+		return
+	}
+	for subject, entry := range s.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(s.cache, subject)
+		}
+	}
+	s.sweepAt = now.Add(s.ttl)
+}
+
+// Invalidate 清除用户的资料缓存
+func (s *Service) Invalidate(subject string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, subject)
+}
@@ -0,0 +1,80 @@
+package profile_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/auth"
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+	"github.com/hellodeveye/report/pkg/platform"
+	"github.com/hellodeveye/report/pkg/profile"
+)
+
+func TestGetEnrichesAndCachesDingTalkProfile(t *testing.T) {
+	server := dingtalktest.NewServer()
+	defer server.Close()
+
+	server.AddDepartment(100, "研发部")
+	server.AddDepartment(200, "架构组")
+	server.AddUser(dingtalktest.User{UserID: "lead", Name: "组长"})
+	server.AddUser(dingtalktest.User{
+		UserID:        "user-1",
+		Name:          "张三",
+		Email:         "zhangsan@example.com",
+		Title:         "工程师",
+		DeptIDs:       []int64{100, 200},
+		ManagerUserID: "lead",
+	})
+
+	service := profile.NewService(map[string]platform.ProfileProvider{
+		auth.PlatformDingTalk: dingtalk.NewProfileProvider(dingtalk.NewClient(server.Config())),
+	}, time.Minute)
+	principal := auth.NewPrincipal(auth.PlatformDingTalk, &models.User{UserID: "user-1", OpenID: "open-1", Name: "张三", CorpID: dingtalktest.CorpID})
+
+	me, err := service.Get(context.Background(), principal)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if me.UserID != "user-1" || me.OpenID != "open-1" || me.Email != "zhangsan@example.com" || me.Title != "工程师" {
+		t.Fatalf("unexpected profile: %+v", me)
+	}
+	if len(me.Departments) != 2 || me.Departments[0].Name != "研发部" || me.Departments[1].ID != "200" {
+		t.Fatalf("unexpected departments: %+v", me.Departments)
+	}
+	if me.Manager == nil || me.Manager.ID != "lead" || me.Manager.Name != "组长" {
+		t.Fatalf("unexpected manager: %+v", me.Manager)
+	}
+
+	// 缓存有效期内不再调用通讯录接口
+	requests := server.UserRequests()
+	if _, err := service.Get(context.Background(), principal); err != nil {
+		t.Fatalf("cached Get failed: %v", err)
+	}
+	if server.UserRequests() != requests {
+		t.Fatalf("expected cached profile, got %d more user requests", server.UserRequests()-requests)
+	}
+
+	service.Invalidate(principal.Subject)
+	if _, err := service.Get(context.Background(), principal); err != nil {
+		t.Fatalf("Get after invalidate failed: %v", err)
+	}
+	if server.UserRequests() == requests {
+		t.Fatal("expected profile to be fetched again after invalidate")
+	}
+}
+
+func TestGetWithoutProviderReturnsIdentity(t *testing.T) {
+	service := profile.NewService(map[string]platform.ProfileProvider{}, time.Minute)
+	principal := auth.NewPrincipal(auth.PlatformFeishu, &models.User{OpenID: "ou_1", Name: "李四", CorpID: "tenant-1"})
+
+	me, err := service.Get(context.Background(), principal)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if me.Platform != auth.PlatformFeishu || me.OpenID != "ou_1" || me.Name != "李四" || me.Departments == nil {
+		t.Fatalf("unexpected profile: %+v", me)
+	}
+}
@@ -0,0 +1,175 @@
+// Package summary 基于日报内容生成周报、月报等汇总草稿
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// ErrNoSourceReports 时间范围内没有可用于汇总的日报
+var ErrNoSourceReports = errors.New("no source reports in the given time range")
+
+const systemPrompt = "你是一个专业的工作报告撰写助手，请根据用户提供的日报内容撰写汇总报告。只返回要求格式的结果，不要添加额外的解释。"
+
+// fieldHints 常见字段的撰写要求，与前端 ReportSummarizer 保持一致
+var fieldHints = map[string]string{
+	"本月总结":   "撰写本月工作总结，突出主要成就和完成的工作",
+	"本月工作总结": "撰写本月工作总结，突出主要成就和完成的工作",
+	"本周工作总结": "撰写本周工作总结，突出主要成就和完成的工作",
+	"主要成就":   "提取并总结主要成就和亮点",
+	"进展同步":   "总结项目进展情况",
+	"下月计划":   "基于日报中的计划内容，制定下月工作计划",
+	"下月工作计划": "基于日报中的计划内容，制定下月工作计划",
+	"下周工作计划": "基于日报中的计划内容，制定下周工作计划",
+	"复盘总结":   "进行工作复盘，分析经验教训",
+	"遇到的挑战":  "提取遇到的问题和挑战",
+	"团队反馈":   "总结团队协作和反馈情况",
+}
+
+// FieldDraft 目标模板中单个字段的草稿内容
+type FieldDraft struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Sort  int    `json:"sort"`
+	Type  int    `json:"type"`
+}
+
+// Draft 汇总草稿，Contents 与目标模板字段一一对应
+type Draft struct {
+	TemplateID   string       `json:"template_id"`
+	TemplateName string       `json:"template_name"`
+	Contents     []FieldDraft `json:"contents"`
+	SourceCount  int          `json:"source_count"`
+	Usage        llm.Usage    `json:"usage"`
+}
+
+// Generator 汇总草稿生成器，日报来源与目标模板由调用方按用户所在平台传入
+type Generator struct {
+	provider llm.Provider
+}
+
+// NewGenerator 创建汇总草稿生成器
+func NewGenerator(provider llm.Provider) *Generator {
+	return &Generator{provider: provider}
+}
+
+// Generate 从 reports 拉取 templateName 在时间范围内的日报，按 targetTemplate 的字段生成汇总草稿。
+// userID 为平台内的用户ID，startTime、endTime 为秒级时间戳。
+func (g *Generator) Generate(ctx context.Context, reports platform.ReportProvider, userID, templateName string, startTime, endTime int64, targetTemplate string) (*Draft, error) {
+	sources, err := reports.ListReports(ctx, userID, templateName, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source reports: %w", err)
+	}
+	if len(sources) == 0 {
+		return nil, ErrNoSourceReports
+	}
+
+	template, err := reports.GetTemplate(ctx, userID, targetTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get target template: %w", err)
+	}
+	fields := append([]platform.Field(nil), template.Fields...)
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Sort < fields[j].Sort })
+
+	resp, err := g.provider.Chat(ctx, llm.ChatRequest{
+		Messages: []llm.Message{
+			{Role: llm.RoleSystem, Content: systemPrompt},
+			{Role: llm.RoleUser, Content: BuildPrompt(targetTemplate, fields, sources)},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+
+	values, err := parseFieldValues(resp.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	draft := &Draft{
+		TemplateID:   template.ID,
+		TemplateName: template.Name,
+		SourceCount:  len(sources),
+		Usage:        resp.Usage,
+	}
+	for _, field := range fields {
+		draft.Contents = append(draft.Contents, FieldDraft{
+			Key:   field.Name,
+			Value: values[field.Name],
+			Sort:  field.Sort,
+			Type:  field.Type,
+		})
+	}
+	return draft, nil
+}
+
+// BuildPrompt 根据目标模板字段与源日报构建提示词，要求模型以字段名为键返回 JSON
+func BuildPrompt(targetTemplate string, fields []platform.Field, reports []platform.Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "请基于以下日报内容，为「%s」撰写各字段内容。要求：\n", targetTemplate)
+	b.WriteString("1. 内容简洁明了，突出重点\n")
+	b.WriteString("2. 保持专业的工作报告语气，可以使用Markdown格式\n")
+	b.WriteString("3. 每个字段的字数控制在100-300字之间\n")
+	b.WriteString("4. 仅返回一个JSON对象，键为字段名，值为该字段内容\n\n")
+
+	b.WriteString("字段列表：\n")
+	for _, field := range fields {
+		if hint, ok := fieldHints[field.Name]; ok {
+			fmt.Fprintf(&b, "- %s：%s\n", field.Name, hint)
+		} else {
+			fmt.Fprintf(&b, "- %s\n", field.Name)
+		}
+	}
+
+	b.WriteString("\n以下是源报告内容：\n")
+	for i, report := range reports {
+		if i > 0 {
+			b.WriteString("\n---\n")
+		}
+		date := time.UnixMilli(report.CreateTime).Format("2006-01-02")
+		fmt.Fprintf(&b, "【%s %s】\n", report.TemplateName, date)
+		for _, content := range report.Contents {
+			fmt.Fprintf(&b, "%s: %s\n", content.Key, content.Value)
+		}
+	}
+	return b.String()
+}
+
+// parseFieldValues 从模型输出中解析字段内容，兼容被代码块包裹的 JSON
+func parseFieldValues(content string) (map[string]string, error) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("llm output is not a JSON object: %q", content)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("unmarshal llm output failed: %v", err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case []interface{}:
+			lines := make([]string, 0, len(v))
+			for _, item := range v {
+				lines = append(lines, fmt.Sprintf("- %v", item))
+			}
+			values[key] = strings.Join(lines, "\n")
+		default:
+			values[key] = fmt.Sprintf("%v", v)
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,133 @@
+package summary_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/dingtalk"
+	"github.com/hellodeveye/report/pkg/dingtalk/dingtalktest"
+	"github.com/hellodeveye/report/pkg/llm"
+	"github.com/hellodeveye/report/pkg/llm/llmtest"
+	"github.com/hellodeveye/report/pkg/platform"
+	"github.com/hellodeveye/report/pkg/summary"
+)
+
+func TestGenerateMapsFieldsFromLLMOutput(t *testing.T) {
+	dt := dingtalktest.NewServer()
+	defer dt.Close()
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local)
+	for day := 0; day < 3; day++ {
+		dt.AddReport(dingtalk.ReportData{
+			ReportID:     "daily-" + string(rune('a'+day)),
+			CreatorID:    "user-1",
+			TemplateName: "日报",
+			CreateTime:   start.AddDate(0, 0, day).Add(18 * time.Hour).UnixMilli(),
+			Contents:     []dingtalk.ReportContent{{Key: "今日完成工作", Value: "完成接口联调"}},
+		})
+	}
+	dt.AddTemplate(dingtalk.TemplateDetailResult{
+		ID:   "tpl-weekly",
+		Name: "周报",
+		Fields: []dingtalk.Field{
+			{FieldName: "下周工作计划", Sort: 1, Type: 1},
+			{FieldName: "本周工作总结", Sort: 0, Type: 1},
+		},
+	})
+
+	ai := llmtest.NewServer(func(messages []llm.Message) string {
+		return "```json\n{\"本周工作总结\": \"完成了接口联调\", \"下周工作计划\": [\"上线\", \"复盘\"]}\n```"
+	})
+	defer ai.Close()
+
+	generator := summary.NewGenerator(llm.NewOpenAIProvider(ai.Config()))
+	reports := dingtalk.NewReportProvider(dingtalk.NewClient(dt.Config()))
+	draft, err := generator.Generate(context.Background(), reports, "user-1", "日报", start.Unix(), start.AddDate(0, 0, 7).Unix(), "周报")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	if draft.TemplateID != "tpl-weekly" || draft.SourceCount != 3 || len(draft.Contents) != 2 {
+		t.Fatalf("unexpected draft: %+v", draft)
+	}
+	if draft.Contents[0].Key != "本周工作总结" || draft.Contents[0].Value != "完成了接口联调" {
+		t.Fatalf("unexpected first field: %+v", draft.Contents[0])
+	}
+	if draft.Contents[1].Value != "- 上线\n- 复盘" {
+		t.Fatalf("unexpected second field: %+v", draft.Contents[1])
+	}
+
+	prompt := ai.Requests()[0][1].Content
+	if !strings.Contains(prompt, "本周工作总结") || strings.Count(prompt, "完成接口联调") != 3 {
+		t.Fatalf("prompt missing fields or source reports:\n%s", prompt)
+	}
+}
+
+func TestGenerateWithoutSourceReports(t *testing.T) {
+	dt := dingtalktest.NewServer()
+	defer dt.Close()
+	ai := llmtest.NewServer(nil)
+	defer ai.Close()
+
+	generator := summary.NewGenerator(llm.NewOpenAIProvider(ai.Config()))
+	reports := dingtalk.NewReportProvider(dingtalk.NewClient(dt.Config()))
+	now := time.Now()
+	_, err := generator.Generate(context.Background(), reports, "user-1", "日报", now.AddDate(0, 0, -7).Unix(), now.Unix(), "周报")
+	if !errors.Is(err, summary.ErrNoSourceReports) {
+		t.Fatalf("expected ErrNoSourceReports, got %v", err)
+	}
+	if len(ai.Requests()) != 0 {
+		t.Fatal("llm should not be called without source reports")
+	}
+}
+
+// stubProvider 不依赖具体平台的日志服务，验证飞书、企业微信等平台同样可以生成汇总
+type stubProvider struct {
+	platform.ReportProvider
+	reports  []platform.Report
+	template *platform.Template
+}
+
+func (s *stubProvider) ListReports(ctx context.Context, userID, templateName string, startTime, endTime int64) ([]platform.Report, error) {
+	return s.reports, nil
+}
+
+func (s *stubProvider) GetTemplate(ctx context.Context, userID, name string) (*platform.Template, error) {
+	if s.template == nil {
+		return nil, platform.ErrNotSupported
+	}
+	return s.template, nil
+}
+
+func TestGenerateWithAnyReportProvider(t *testing.T) {
+	ai := llmtest.NewServer(func(messages []llm.Message) string {
+		return `{"本周工作总结": "完成飞书对接"}`
+	})
+	defer ai.Close()
+
+	reports := &stubProvider{
+		reports: []platform.Report{{
+			TemplateName: "日报",
+			CreateTime:   time.Now().UnixMilli(),
+			Contents:     []platform.Content{{Key: "今日完成工作", Value: "飞书对接"}},
+		}},
+		template: &platform.Template{ID: "rule-weekly", Name: "周报", Fields: []platform.Field{{Name: "本周工作总结"}}},
+	}
+	generator := summary.NewGenerator(llm.NewOpenAIProvider(ai.Config()))
+	now := time.Now()
+	draft, err := generator.Generate(context.Background(), reports, "ou-1", "日报", now.AddDate(0, 0, -7).Unix(), now.Unix(), "周报")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if draft.TemplateID != "rule-weekly" || draft.Contents[0].Value != "完成飞书对接" {
+		t.Fatalf("unexpected draft: %+v", draft)
+	}
+
+	reports.template = nil
+	if _, err := generator.Generate(context.Background(), reports, "ou-1", "日报", now.AddDate(0, 0, -7).Unix(), now.Unix(), "周报"); !errors.Is(err, platform.ErrNotSupported) {
+		t.Fatalf("expected ErrNotSupported, got %v", err)
+	}
+}
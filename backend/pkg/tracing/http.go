@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+type clientSpanKey struct{}
+
+// InstrumentClient 为 resty 客户端的每次调用创建 client span，并通过 traceparent 头传递链路。
+// span 只记录不含查询参数的地址，避免 access_token 等凭据进入链路数据
+func InstrumentClient(client *resty.Client) *resty.Client {
+	return client.
+		AddRequestMiddleware(startClientSpan).
+		OnSuccess(func(_ *resty.Client, resp *resty.Response) {
+			endClientSpan(resp.Request.Context(), resp, nil)
+		}).
+		OnError(func(req *resty.Request, err error) {
+			var resp *resty.Response
+			if respErr, ok := err.(*resty.ResponseError); ok {
+				resp = respErr.Response
+			}
+			endClientSpan(req.Context(), resp, err)
+		}).
+		OnInvalid(func(req *resty.Request, err error) {
+			endClientSpan(req.Context(), nil, err)
+		})
+}
+
+func startClientSpan(_ *resty.Client, r *resty.Request) error {
+	ctx, span := Tracer().Start(r.Context(), r.Method, trace.WithSpanKind(trace.SpanKindClient))
+	if u, err := url.Parse(r.URL); err == nil {
+		span.SetAttributes(
+			semconv.ServerAddress(u.Hostname()),
+			semconv.URLFull(u.Scheme+"://"+u.Host+u.Path),
+		)
+	}
+	span.SetAttributes(semconv.HTTPRequestMethodKey.String(r.Method))
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+	r.SetContext(context.WithValue(ctx, clientSpanKey{}, span))
+	return nil
+}
+
+// endClientSpan 结束 startClientSpan 创建的 span。请求在 span 创建前就失败时 ctx 中没有该 span，
+// 此时不做处理，以免误结束调用方的 span
+func endClientSpan(ctx context.Context, resp *resty.Response, err error) {
+	span, ok := ctx.Value(clientSpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if resp != nil && resp.RawResponse != nil {
+		status := resp.StatusCode()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusBadRequest {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+	if err != nil {
+		// url.Error 中的地址带有查询参数，只保留底层错误
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
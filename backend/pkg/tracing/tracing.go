@@ -0,0 +1,73 @@
+// Package tracing 初始化 OpenTelemetry 链路追踪：以 W3C traceparent 在服务间传递链路，
+// 并通过 OTLP/HTTP 将 span 导出到 collector
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hellodeveye/report/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName 本服务创建的 span 所属的 instrumentation scope
+const instrumentationName = "github.com/hellodeveye/report"
+
+// tracesPath OTLP/HTTP 接收链路数据的默认路径
+const tracesPath = "/v1/traces"
+
+// Tracer 返回本服务使用的 tracer，未开启导出时为 no-op
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Setup 设置全局 propagator 与 TracerProvider，返回退出时导出剩余 span 的关闭函数。
+// 未开启时只设置 propagator，收到的 traceparent 仍会透传给下游接口
+func Setup(ctx context.Context, cfg *models.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint, err := EndpointURL(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter failed: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(cfg.ServiceName),
+		)),
+		// 上游已决定是否采样时沿用上游，否则按比例采样
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// EndpointURL 校验 OTLP/HTTP 地址，未指定路径时补全为 /v1/traces
+func EndpointURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid otlp endpoint %q: must be an http(s) URL", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"resty.dev/v3"
+)
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		want     string
+		wantErr  bool
+	}{
+		{"default path", "http://localhost:4318", "http://localhost:4318/v1/traces", false},
+		{"root path", "http://collector:4318/", "http://collector:4318/v1/traces", false},
+		{"custom path", "https://otel.example.com/otlp/v1/traces", "https://otel.example.com/otlp/v1/traces", false},
+		{"missing scheme", "localhost:4318", "", true},
+		{"grpc scheme", "grpc://localhost:4317", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EndpointURL(tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EndpointURL(%q) error = %v, wantErr %v", tt.endpoint, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("EndpointURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInstrumentClientCreatesSpanAndPropagates(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+	client := InstrumentClient(resty.New())
+	if _, err := client.R().SetContext(ctx).Get(server.URL + "/gettoken?appsecret=s3cret"); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET" || span.SpanKind() != trace.SpanKindClient {
+		t.Fatalf("unexpected client span %q (%v)", span.Name(), span.SpanKind())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("client span should be a child of the caller's span")
+	}
+	if !strings.Contains(traceparent, span.SpanContext().TraceID().String()) ||
+		!strings.Contains(traceparent, span.SpanContext().SpanID().String()) {
+		t.Fatalf("traceparent %q should reference the client span", traceparent)
+	}
+	if span.Status().Code != codes.Error {
+		t.Fatalf("expected error status for 502, got %v", span.Status())
+	}
+	for _, attr := range span.Attributes() {
+		if strings.Contains(attr.Value.Emit(), "s3cret") {
+			t.Fatalf("span attribute %s should not contain query parameters", attr.Key)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package wecom
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/hellodeveye/report/internal/models"
+)
+
+// defaultLoginURL 企业微信网页登录地址
+const defaultLoginURL = "https://login.work.weixin.qq.com/wwlogin/sso/login"
+
+// AuthService 企业微信认证服务
+type AuthService struct {
+	client *Client
+	config *models.WeComConfig
+}
+
+// NewAuthService 创建新的企业微信认证服务
+func NewAuthService(client *Client) *AuthService {
+	return &AuthService{
+		client: client,
+		config: client.config,
+	}
+}
+
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	return &AuthService{client: s.client.WithContext(ctx), config: s.config}
+}
+
+// GenerateAuthURL 生成企业微信网页登录的授权URL，state 由调用方签发并在回调时校验
+func (s *AuthService) GenerateAuthURL(state string) string {
+	authURL := fmt.Sprintf("%s?login_type=CorpApp&appid=%s&agentid=%s&redirect_uri=%s&state=%s",
+		defaultLoginURL,
+		url.QueryEscape(s.config.CorpID),
+		url.QueryEscape(s.config.AgentID),
+		url.QueryEscape(s.config.RedirectURI),
+		state,
+	)
+
+	return authURL
+}
+
+// UserDetail 通讯录成员详情
+type UserDetail struct {
+	UserID     string  `json:"userid"`
+	Name       string  `json:"name"`
+	Avatar     string  `json:"avatar"`
+	Email      string  `json:"email"`
+	BizMail    string  `json:"biz_mail"`
+	Mobile     string  `json:"mobile"`
+	Position   string  `json:"position"`
+	Department []int64 `json:"department"`
+	OpenUserID string  `json:"open_userid"`
+	// IsLeaderInDept 与 Department 一一对应，1 表示在该部门担任负责人
+	IsLeaderInDept []int    `json:"is_leader_in_dept"`
+	DirectLeader   []string `json:"direct_leader"`
+}
+
+// ExchangeCodeForUser 用授权码换取企业成员信息
+func (s *AuthService) ExchangeCodeForUser(code string) (*models.User, error) {
+	// 1. 用授权码获取成员 userid
+	var identity struct {
+		UserID string `json:"userid"`
+		OpenID string `json:"openid"`
+	}
+	if err := s.client.getWithToken("/cgi-bin/auth/getuserinfo", map[string]string{"code": code}, &identity); err != nil {
+		return nil, fmt.Errorf("failed to get user identity: %v", err)
+	}
+	if identity.UserID == "" {
+		// 非企业成员只返回 openid，无法访问汇报数据
+		return nil, fmt.Errorf("user is not a member of the corp")
+	}
+
+	// 2. 获取成员详情
+	user, err := s.GetUser(identity.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user detail: %v", err)
+	}
+
+	email := user.Email
+	if email == "" {
+		email = user.BizMail
+	}
+	return &models.User{
+		OpenID: user.OpenUserID,
+		UserID: user.UserID,
+		Name:   user.Name,
+		Avatar: user.Avatar,
+		Email:  email,
+		Mobile: user.Mobile,
+		CorpID: s.config.CorpID,
+	}, nil
+}
+
+// GetUser 获取通讯录成员详情，成员不存在时返回 errcode 60111
+func (s *AuthService) GetUser(userID string) (*UserDetail, error) {
+	var user UserDetail
+	if err := s.client.getWithToken("/cgi-bin/user/get", map[string]string{"userid": userID}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
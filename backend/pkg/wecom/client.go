@@ -0,0 +1,156 @@
+// Package wecom 封装企业微信的网页登录与汇报（日志）接口
+package wecom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/internal/tokencache"
+	"github.com/hellodeveye/report/pkg/logging"
+	"resty.dev/v3"
+)
+
+const defaultBaseURL = "https://qyapi.weixin.qq.com"
+
+// Client 企业微信API客户端
+type Client struct {
+	config     *models.WeComConfig
+	httpClient *resty.Client
+	tokens     *tokencache.Cache[string]
+	// ctx 接口调用使用的 context，见 WithContext
+	ctx context.Context
+}
+
+// NewClient 创建新的企业微信客户端
+func NewClient(config *models.WeComConfig) *Client {
+	c := &Client{
+		config:     config,
+		httpClient: logging.NewHTTPClient(30 * time.Second),
+	}
+	c.tokens = tokencache.New(c.fetchAccessToken)
+	return c
+}
+
+// WithContext 返回使用 ctx 调用接口的客户端副本，共享连接与 access_token 缓存
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// apiURL 拼接接口地址
+func (c *Client) apiURL(path string) string {
+	baseURL := c.config.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return strings.TrimRight(baseURL, "/") + path
+}
+
+// apiStatus 企业微信接口响应中的通用状态字段
+type apiStatus struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// GetAccessToken 获取应用的 access_token，优先使用缓存
+func (c *Client) GetAccessToken() (string, error) {
+	return c.tokens.Get(c.context())
+}
+
+// fetchAccessToken 向企业微信请求新的 access_token，由缓存统一调用
+func (c *Client) fetchAccessToken(ctx context.Context) (tokencache.Entry[string], error) {
+	var tokenResp struct {
+		apiStatus
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	err := c.WithContext(ctx).call("GET", "/cgi-bin/gettoken", map[string]string{
+		"corpid":     c.config.CorpID,
+		"corpsecret": c.config.CorpSecret,
+	}, nil, &tokenResp)
+	if err != nil {
+		return tokencache.Entry[string]{}, err
+	}
+
+	return tokencache.Entry[string]{
+		Token:     tokenResp.AccessToken,
+		Value:     tokenResp.AccessToken,
+		ExpiresIn: time.Duration(tokenResp.ExpiresIn) * time.Second,
+	}, nil
+}
+
+// getWithToken 携带 access_token 以 GET 调用接口
+func (c *Client) getWithToken(path string, query map[string]string, result interface{}) error {
+	return c.doWithToken("GET", path, query, nil, result)
+}
+
+// postWithToken 携带 access_token 以 POST 调用接口
+func (c *Client) postWithToken(path string, requestBody interface{}, result interface{}) error {
+	return c.doWithToken("POST", path, nil, requestBody, result)
+}
+
+// doWithToken 携带 access_token 调用接口，access_token 失效时刷新后重试一次
+func (c *Client) doWithToken(method, path string, query map[string]string, requestBody interface{}, result interface{}) error {
+	for attempt := 0; ; attempt++ {
+		token, err := c.GetAccessToken()
+		if err != nil {
+			return err
+		}
+
+		params := map[string]string{"access_token": token}
+		for key, value := range query {
+			params[key] = value
+		}
+		err = c.call(method, path, params, requestBody, result)
+		if IsTokenExpired(err) && attempt == 0 {
+			c.tokens.Invalidate(token)
+			continue
+		}
+		return err
+	}
+}
+
+// call 调用企业微信接口并解析响应，errcode 非0时返回 *APIError
+func (c *Client) call(method, path string, query map[string]string, requestBody interface{}, result interface{}) error {
+	req := c.httpClient.R().SetContext(c.context()).SetQueryParams(query)
+	if requestBody != nil {
+		req.SetBody(requestBody)
+	}
+
+	resp, err := req.Execute(method, c.apiURL(path))
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %v", err)
+	}
+
+	var status apiStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return fmt.Errorf("unmarshal response failed: %v", err)
+	}
+	if status.ErrCode != 0 {
+		return &APIError{Endpoint: path, ErrCode: status.ErrCode, ErrMsg: status.ErrMsg}
+	}
+
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("unmarshal response failed: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package wecom_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/wecom"
+	"github.com/hellodeveye/report/pkg/wecom/wecomtest"
+)
+
+func journal(id, userID, templateName string, reportTime time.Time, text string) wecom.JournalDetail {
+	return wecom.JournalDetail{
+		JournalUUID:  id,
+		TemplateID:   "tpl-" + templateName,
+		TemplateName: templateName,
+		ReportTime:   reportTime.Unix(),
+		Submitter:    wecom.JournalUser{UserID: userID},
+		ApplyData: wecom.JournalApplyData{Contents: []wecom.JournalContent{{
+			Control: "Text",
+			Title:   []wecom.JournalText{{Text: "今日工作", Lang: "zh_CN"}},
+			Value:   wecom.JournalValue{Text: text},
+		}}},
+	}
+}
+
+func TestExchangeCodeForUser(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	code := server.AddUser(wecomtest.User{UserID: "zhangsan", OpenUserID: "wo-zhangsan", Name: "张三", Email: "zs@example.com"})
+	authService := wecom.NewAuthService(wecom.NewClient(server.Config()))
+
+	user, err := authService.ExchangeCodeForUser(code)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForUser failed: %v", err)
+	}
+	if user.UserID != "zhangsan" || user.OpenID != "wo-zhangsan" || user.Name != "张三" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// 授权码只能使用一次
+	if _, err := authService.ExchangeCodeForUser(code); err == nil {
+		t.Fatal("expected error when reusing code")
+	}
+}
+
+func TestListReportsAcrossWindows(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	// 跨越三个月，超过单次查询一个月的限制，并超过单页100条
+	for day := 0; day < 75; day++ {
+		server.AddJournal(journal(fmt.Sprintf("daily-%d", day), "zhangsan", "日报", start.AddDate(0, 0, day), "联调"))
+		server.AddJournal(journal(fmt.Sprintf("other-%d", day), "lisi", "日报", start.AddDate(0, 0, day), "测试"))
+	}
+	server.AddJournal(journal("weekly-1", "zhangsan", "周报", start.AddDate(0, 0, 6), "总结"))
+
+	provider := wecom.NewReportProvider(wecom.NewClient(server.Config()))
+	reports, err := provider.ListReports(context.Background(), "zhangsan", "日报", start.Unix(), start.AddDate(0, 3, 0).Unix())
+	if err != nil {
+		t.Fatalf("ListReports failed: %v", err)
+	}
+	if len(reports) != 75 {
+		t.Fatalf("expected 75 reports, got %d", len(reports))
+	}
+	first := reports[0]
+	if first.CreatorID != "zhangsan" || first.CreateTime != start.UnixMilli() || first.Contents[0].Key != "今日工作" || first.Contents[0].Value != "联调" {
+		t.Fatalf("unexpected report: %+v", first)
+	}
+}
+
+func TestListReportsFiltersByKnownTemplateID(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for day := 0; day < 10; day++ {
+		server.AddJournal(journal(fmt.Sprintf("daily-%d", day), "zhangsan", "日报", start.AddDate(0, 0, day), "联调"))
+		server.AddJournal(journal(fmt.Sprintf("weekly-%d", day), "zhangsan", "周报", start.AddDate(0, 0, day), "总结"))
+	}
+
+	provider := wecom.NewReportProvider(wecom.NewClient(server.Config()))
+	end := start.AddDate(0, 0, 10).Unix()
+	const detailPath = "/cgi-bin/oa/journal/get_record_detail"
+
+	// 首次查询不知道模板ID，需要拉取全部详情后按名称过滤
+	reports, err := provider.ListReports(context.Background(), "zhangsan", "日报", start.Unix(), end)
+	if err != nil || len(reports) != 10 {
+		t.Fatalf("ListReports = %d reports, %v", len(reports), err)
+	}
+	if got := server.Requests(detailPath); got != 20 {
+		t.Fatalf("expected 20 detail requests, got %d", got)
+	}
+
+	// 记下模板ID后由记录列表接口过滤，只拉取该模板的详情
+	reports, err = provider.ListReports(context.Background(), "zhangsan", "日报", start.Unix(), end)
+	if err != nil || len(reports) != 10 {
+		t.Fatalf("ListReports = %d reports, %v", len(reports), err)
+	}
+	if got := server.Requests(detailPath) - 20; got != 10 {
+		t.Fatalf("expected 10 detail requests, got %d", got)
+	}
+	for i, report := range reports {
+		if report.ID != fmt.Sprintf("daily-%d", i) {
+			t.Fatalf("reports out of order: %+v", reports)
+		}
+	}
+}
+
+func TestListReportsStopsWhenContextCanceled(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	start := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	server.AddJournal(journal("daily-1", "zhangsan", "日报", start, "联调"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	provider := wecom.NewReportProvider(wecom.NewClient(server.Config()))
+	if _, err := provider.ListReports(ctx, "zhangsan", "日报", start.Unix(), start.AddDate(0, 0, 1).Unix()); err == nil {
+		t.Fatal("expected error for canceled context")
+	}
+	if got := server.Requests("/cgi-bin/oa/journal/get_record_list"); got != 0 {
+		t.Fatalf("expected no record list requests, got %d", got)
+	}
+}
+
+func TestGetTemplateFromLatestRecord(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	now := time.Now()
+	older := journal("daily-1", "zhangsan", "日报", now.AddDate(0, 0, -2), "联调")
+	latest := journal("daily-2", "zhangsan", "日报", now.AddDate(0, 0, -1), "测试")
+	latest.ApplyData.Contents = append(latest.ApplyData.Contents, wecom.JournalContent{
+		Control: "Text",
+		Title:   []wecom.JournalText{{Text: "明日计划", Lang: "zh_CN"}},
+	})
+	server.AddJournal(older)
+	server.AddJournal(latest)
+
+	provider := wecom.NewReportProvider(wecom.NewClient(server.Config()))
+	template, err := provider.GetTemplate(context.Background(), "zhangsan", "日报")
+	if err != nil {
+		t.Fatalf("GetTemplate failed: %v", err)
+	}
+	if template.ID != "tpl-日报" || len(template.Fields) != 2 || template.Fields[1].Name != "明日计划" {
+		t.Fatalf("unexpected template: %+v", template)
+	}
+
+	if _, err := provider.GetTemplate(context.Background(), "zhangsan", "周报"); err == nil {
+		t.Fatal("expected error for template without recent records")
+	}
+}
+
+func TestExpiredTokenIsRefreshed(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	journals := wecom.NewJournalService(wecom.NewClient(server.Config()))
+	now := time.Now().Unix()
+	if _, err := journals.GetRecordList(now-3600, now, 0, 10, nil); err != nil {
+		t.Fatalf("GetRecordList failed: %v", err)
+	}
+	server.ExpireTokens()
+	if _, err := journals.GetRecordList(now-3600, now, 0, 10, nil); err != nil {
+		t.Fatalf("GetRecordList after expiry failed: %v", err)
+	}
+	if server.TokenRequests() != 2 {
+		t.Fatalf("expected 2 token requests, got %d", server.TokenRequests())
+	}
+
+	server.InjectError("/cgi-bin/oa/journal/get_record_list", 45009, "api freq out of limit")
+	_, err := journals.GetRecordList(now-3600, now, 0, 10, nil)
+	if !wecom.IsRateLimited(err) {
+		t.Fatalf("expected rate limited error, got %v", err)
+	}
+}
+
+func TestGetStatList(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	server.AddUser(wecomtest.User{UserID: "lisi"})
+	server.AddUser(wecomtest.User{UserID: "zhangsan"})
+	server.AddJournal(journal("daily-1", "zhangsan", "日报", start, "联调"))
+
+	resp, err := wecom.NewJournalService(wecom.NewClient(server.Config())).
+		GetStatList("tpl-日报", start.Unix(), start.AddDate(0, 0, 1).Unix())
+	if err != nil {
+		t.Fatalf("GetStatList failed: %v", err)
+	}
+	stat := resp.StatList[0]
+	if len(stat.ReportList) != 1 || stat.ReportList[0].User.UserID != "zhangsan" || len(stat.UnreportList) != 1 {
+		t.Fatalf("unexpected stat: %+v", stat)
+	}
+}
+
+func TestRoleProviderGetMembership(t *testing.T) {
+	server := wecomtest.NewServer()
+	defer server.Close()
+
+	server.AddUser(wecomtest.User{UserID: "boss", Departments: []int64{1}, Admin: true})
+	server.AddUser(wecomtest.User{UserID: "lead", Departments: []int64{2, 3}, LeaderDepartments: []int64{2}})
+	server.AddUser(wecomtest.User{UserID: "member", Departments: []int64{2}, DirectLeader: []string{"lead"}})
+
+	roles := wecom.NewRoleProvider(wecom.NewClient(server.Config()))
+	tests := []struct {
+		userID    string
+		admin     bool
+		depts     int
+		led       []string
+		managerID string
+	}{
+		{"boss", true, 1, nil, ""},
+		{"lead", false, 2, []string{"2"}, ""},
+		{"member", false, 1, nil, "lead"},
+		{"ghost", false, 0, nil, ""},
+	}
+	for _, tt := range tests {
+		membership, err := roles.GetMembership(context.Background(), tt.userID)
+		if err != nil {
+			t.Fatalf("GetMembership(%s) failed: %v", tt.userID, err)
+		}
+		if membership.Admin != tt.admin || len(membership.Departments) != tt.depts ||
+			fmt.Sprint(membership.LedDepartments) != fmt.Sprint(tt.led) || membership.ManagerID != tt.managerID {
+			t.Errorf("GetMembership(%s) = %+v", tt.userID, membership)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package wecom
+
+import (
+	"errors"
+	"fmt"
+)
+
+// APIError 企业微信接口返回的业务错误（errcode != 0）
+type APIError struct {
+	Endpoint string
+	ErrCode  int
+	ErrMsg   string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wecom %s failed: errcode=%d errmsg=%s", e.Endpoint, e.ErrCode, e.ErrMsg)
+}
+
+// 企业微信返回的错误码
+const (
+	errCodeInvalidToken = 40014
+	errCodeTokenExpired = 42001
+	errCodeRateLimited  = 45009
+	errCodeNoPrivilege  = 60011
+	errCodeUserNotFound = 60111
+)
+
+func isTokenExpiredCode(code int) bool {
+	return code == errCodeInvalidToken || code == errCodeTokenExpired
+}
+
+// IsRateLimited 判断错误是否为接口调用频率超限
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ErrCode == errCodeRateLimited
+}
+
+// IsTokenExpired 判断错误是否为 access_token 失效
+func IsTokenExpired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && isTokenExpiredCode(apiErr.ErrCode)
+}
+
+// IsPermissionDenied 判断错误是否为应用无权限
+func IsPermissionDenied(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ErrCode == errCodeNoPrivilege
+}
+
+// IsUserNotFound 判断错误是否为成员不存在
+func IsUserNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.ErrCode == errCodeUserNotFound
+}
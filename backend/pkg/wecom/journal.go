@@ -0,0 +1,239 @@
+package wecom
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// JournalService 企业微信汇报（日志）服务
+type JournalService struct {
+	client *Client
+}
+
+// NewJournalService 创建新的企业微信汇报服务
+func NewJournalService(client *Client) *JournalService {
+	return &JournalService{client: client}
+}
+
+// WithContext 返回使用 ctx 调用接口的服务副本，见 Client.WithContext
+func (s *JournalService) WithContext(ctx context.Context) *JournalService {
+	return &JournalService{client: s.client.WithContext(ctx)}
+}
+
+const (
+	// maxRecordPageSize 汇报记录列表接口单页最大条数
+	maxRecordPageSize = 100
+	// maxRecordWindow 汇报记录列表接口单次查询的最大时间跨度
+	maxRecordWindow = 30 * 24 * time.Hour
+)
+
+// RecordFilter 汇报记录列表的过滤条件，key 可取 creator、department、template_id
+type RecordFilter struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type RecordListResponse struct {
+	ErrCode         int      `json:"errcode"`
+	ErrMsg          string   `json:"errmsg"`
+	JournalUUIDList []string `json:"journaluuid_list"`
+	NextCursor      int      `json:"next_cursor"`
+	EndFlag         int      `json:"endflag"`
+}
+
+// GetRecordList 查询时间范围内的汇报记录ID，startTime、endTime 为秒级时间戳，跨度不超过一个月
+func (s *JournalService) GetRecordList(startTime, endTime int64, cursor, limit int, filters []RecordFilter) (*RecordListResponse, error) {
+	requestBody := map[string]interface{}{
+		"starttime": startTime,
+		"endtime":   endTime,
+		"cursor":    cursor,
+		"limit":     limit,
+		"filters":   filters,
+	}
+
+	var response RecordListResponse
+	if err := s.client.postWithToken("/cgi-bin/oa/journal/get_record_list", requestBody, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ListAllRecordIDs 返回时间范围内 userID 提交的全部汇报记录ID，自动翻页，
+// 并将超过一个月的时间范围拆分为多个子区间依次查询。userID、templateID 为空时不过滤。
+func (s *JournalService) ListAllRecordIDs(userID, templateID string, startTime, endTime int64) ([]string, error) {
+	var filters []RecordFilter
+	if userID != "" {
+		filters = append(filters, RecordFilter{Key: "creator", Value: userID})
+	}
+	if templateID != "" {
+		filters = append(filters, RecordFilter{Key: "template_id", Value: templateID})
+	}
+
+	window := int64(maxRecordWindow / time.Second)
+	// 相邻子区间共享边界时间点，用 journaluuid 去重
+	seen := make(map[string]bool)
+	ids := []string{}
+	for windowStart := startTime; windowStart <= endTime; {
+		windowEnd := windowStart + window
+		if windowEnd > endTime {
+			windowEnd = endTime
+		}
+
+		cursor := 0
+		for {
+			resp, err := s.GetRecordList(windowStart, windowEnd, cursor, maxRecordPageSize, filters)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range resp.JournalUUIDList {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+			if resp.EndFlag == 1 || resp.NextCursor <= cursor {
+				break
+			}
+			cursor = resp.NextCursor
+		}
+
+		if windowEnd == endTime {
+			break
+		}
+		windowStart = windowEnd
+	}
+	return ids, nil
+}
+
+// JournalUser 汇报中的成员
+type JournalUser struct {
+	UserID string `json:"userid"`
+}
+
+// JournalText 多语言文本
+type JournalText struct {
+	Text string `json:"text"`
+	Lang string `json:"lang"`
+}
+
+// JournalValue 控件的值，只解析文本与数字两类常用控件
+type JournalValue struct {
+	Text      string `json:"text"`
+	NewNumber string `json:"new_number"`
+}
+
+// JournalContent 汇报中单个控件的内容
+type JournalContent struct {
+	Control string        `json:"control"`
+	ID      string        `json:"id"`
+	Title   []JournalText `json:"title"`
+	Value   JournalValue  `json:"value"`
+}
+
+// TitleText 返回控件标题，优先使用中文
+func (c JournalContent) TitleText() string {
+	for _, title := range c.Title {
+		if title.Lang == "zh_CN" {
+			return title.Text
+		}
+	}
+	if len(c.Title) > 0 {
+		return c.Title[0].Text
+	}
+	return ""
+}
+
+// ValueText 返回控件值的文本形式
+func (c JournalContent) ValueText() string {
+	if c.Value.Text != "" {
+		return c.Value.Text
+	}
+	return strings.TrimSpace(c.Value.NewNumber)
+}
+
+// JournalDetail 汇报记录详情
+type JournalDetail struct {
+	JournalUUID     string           `json:"journal_uuid"`
+	TemplateID      string           `json:"template_id"`
+	TemplateName    string           `json:"template_name"`
+	ReportTime      int64            `json:"report_time"`
+	Submitter       JournalUser      `json:"submitter"`
+	Receivers       []JournalUser    `json:"receivers"`
+	ReadedReceivers []JournalUser    `json:"readed_receivers"`
+	ApplyData       JournalApplyData `json:"apply_data"`
+}
+
+// JournalApplyData 汇报填写的内容
+type JournalApplyData struct {
+	Contents []JournalContent `json:"contents"`
+}
+
+type RecordDetailResponse struct {
+	ErrCode int           `json:"errcode"`
+	ErrMsg  string        `json:"errmsg"`
+	Info    JournalDetail `json:"info"`
+}
+
+// GetRecordDetail 获取汇报记录详情
+func (s *JournalService) GetRecordDetail(journalUUID string) (*RecordDetailResponse, error) {
+	requestBody := map[string]interface{}{
+		"journaluuid": journalUUID,
+	}
+
+	var response RecordDetailResponse
+	if err := s.client.postWithToken("/cgi-bin/oa/journal/get_record_detail", requestBody, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// StatItem 统计周期内的一条汇报
+type StatItem struct {
+	JournalUUID string `json:"journaluuid"`
+	ReportTime  int64  `json:"reporttime"`
+	Flag        int    `json:"flag"`
+}
+
+// StatUser 成员在统计周期内的汇报情况
+type StatUser struct {
+	User     JournalUser `json:"user"`
+	ItemList []StatItem  `json:"itemlist"`
+}
+
+// TemplateStat 汇报模板在一个统计周期内的提交统计
+type TemplateStat struct {
+	TemplateID     string     `json:"template_id"`
+	TemplateName   string     `json:"template_name"`
+	ReportType     int        `json:"report_type"`
+	CycleBeginTime int64      `json:"cycle_begin_time"`
+	CycleEndTime   int64      `json:"cycle_end_time"`
+	StatBeginTime  int64      `json:"stat_begin_time"`
+	StatEndTime    int64      `json:"stat_end_time"`
+	ReportList     []StatUser `json:"report_list"`
+	UnreportList   []StatUser `json:"unreport_list"`
+}
+
+type StatListResponse struct {
+	ErrCode  int            `json:"errcode"`
+	ErrMsg   string         `json:"errmsg"`
+	StatList []TemplateStat `json:"stat_list"`
+}
+
+// GetStatList 获取汇报模板在时间范围内各统计周期的提交统计，startTime、endTime 为秒级时间戳
+func (s *JournalService) GetStatList(templateID string, startTime, endTime int64) (*StatListResponse, error) {
+	requestBody := map[string]interface{}{
+		"template_id": templateID,
+		"starttime":   startTime,
+		"endtime":     endTime,
+	}
+
+	var response StatListResponse
+	if err := s.client.postWithToken("/cgi-bin/oa/journal/get_stat_list", requestBody, &response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
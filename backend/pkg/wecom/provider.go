@@ -0,0 +1,168 @@
+package wecom
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// recordDetailWorkers 并发拉取汇报详情的协程数，避免触发企业微信接口限流
+const recordDetailWorkers = 5
+
+// ReportProvider 基于企业微信汇报接口实现 platform.ReportProvider。
+// 企业微信只提供汇报的查询与统计接口，列出模板、创建汇报与保存草稿返回 platform.ErrNotSupported。
+type ReportProvider struct {
+	journals *JournalService
+
+	// templateIDs 从汇报详情中得知的模板名称到模板ID的映射，
+	// 记录列表接口只能按模板ID过滤，已知ID时不必拉取其他模板的详情
+	mu          sync.Mutex
+	templateIDs map[string]string
+}
+
+var _ platform.ReportProvider = (*ReportProvider)(nil)
+
+// NewReportProvider 创建企业微信汇报服务
+func NewReportProvider(client *Client) *ReportProvider {
+	return &ReportProvider{journals: NewJournalService(client), templateIDs: make(map[string]string)}
+}
+
+func (p *ReportProvider) ListTemplates(ctx context.Context, userID string) ([]platform.Template, error) {
+	return nil, platform.ErrNotSupported
+}
+
+// GetTemplate 企业微信没有查询模板的接口，以用户最近一个月内该模板的最新汇报的控件作为模板字段
+func (p *ReportProvider) GetTemplate(ctx context.Context, userID, name string) (*platform.Template, error) {
+	end := time.Now()
+	details, err := p.listDetails(ctx, userID, name, end.Add(-maxRecordWindow).Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *JournalDetail
+	for i := range details {
+		if latest == nil || details[i].ReportTime > latest.ReportTime {
+			latest = &details[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("wecom journal template %q not found in recent records", name)
+	}
+
+	template := &platform.Template{ID: latest.TemplateID, Name: latest.TemplateName}
+	for i, content := range latest.ApplyData.Contents {
+		template.Fields = append(template.Fields, platform.Field{Name: content.TitleText(), Sort: i})
+	}
+	return template, nil
+}
+
+// ListReports 返回时间范围内用户提交的汇报
+func (p *ReportProvider) ListReports(ctx context.Context, userID, templateName string, startTime, endTime int64) ([]platform.Report, error) {
+	details, err := p.listDetails(ctx, userID, templateName, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]platform.Report, 0, len(details))
+	for _, detail := range details {
+		reports = append(reports, toPlatformReport(detail))
+	}
+	return reports, nil
+}
+
+func (p *ReportProvider) CreateReport(ctx context.Context, userID string, req platform.CreateReportRequest) (*platform.Report, error) {
+	return nil, platform.ErrNotSupported
+}
+
+func (p *ReportProvider) SaveDraft(ctx context.Context, userID string, req platform.CreateReportRequest) (string, error) {
+	return "", platform.ErrNotSupported
+}
+
+// listDetails 返回时间范围内用户提交的 templateName 模板的汇报详情，按记录列表顺序排列。
+// 模板ID已知时由记录列表接口过滤，否则拉取全部详情后按名称过滤，并记下模板ID供下次使用。
+func (p *ReportProvider) listDetails(ctx context.Context, userID, templateName string, startTime, endTime int64) ([]JournalDetail, error) {
+	journals := p.journals.WithContext(ctx)
+	ids, err := journals.ListAllRecordIDs(userID, p.templateID(templateName), startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]JournalDetail, len(ids))
+	errs := make([]error, len(ids))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < recordDetailWorkers && w < len(ids); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if errs[i] = ctx.Err(); errs[i] != nil {
+					continue
+				}
+				resp, err := journals.GetRecordDetail(ids[i])
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				results[i] = resp.Info
+			}
+		}()
+	}
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	details := make([]JournalDetail, 0, len(ids))
+	for i, detail := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		p.rememberTemplate(detail.TemplateName, detail.TemplateID)
+		if templateName != "" && detail.TemplateName != templateName {
+			continue
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+// templateID 返回已知的模板ID，名称为空或未知时返回空字符串
+func (p *ReportProvider) templateID(name string) string {
+	if name == "" {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.templateIDs[name]
+}
+
+func (p *ReportProvider) rememberTemplate(name, id string) {
+	if name == "" || id == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.templateIDs[name] = id
+}
+
+// toPlatformReport 将企业微信汇报转换为平台无关的日志
+func toPlatformReport(detail JournalDetail) platform.Report {
+	report := platform.Report{
+		ID:           detail.JournalUUID,
+		TemplateName: detail.TemplateName,
+		CreatorID:    detail.Submitter.UserID,
+		CreateTime:   detail.ReportTime * 1000,
+	}
+	for i, content := range detail.ApplyData.Contents {
+		report.Contents = append(report.Contents, platform.Content{Key: content.TitleText(), Value: content.ValueText(), Sort: i})
+	}
+	for _, receiver := range detail.Receivers {
+		report.Receivers = append(report.Receivers, platform.Recipient{ID: receiver.UserID})
+	}
+	return report
+}
@@ -0,0 +1,75 @@
+package wecom
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/hellodeveye/report/pkg/platform"
+)
+
+// RoleProvider 基于企业微信通讯录的部门负责人标记与应用管理员列表实现 platform.RoleProvider
+type RoleProvider struct {
+	users *AuthService
+}
+
+var _ platform.RoleProvider = (*RoleProvider)(nil)
+
+// NewRoleProvider 创建企业微信组织关系服务，与其他服务共用同一个客户端
+func NewRoleProvider(client *Client) *RoleProvider {
+	return &RoleProvider{users: NewAuthService(client)}
+}
+
+// GetMembership 查询用户的组织关系，通讯录中不存在的用户视为不属于任何部门。
+// 企业微信不返回成员是否为企业管理员，以本应用的管理员作为 Admin
+func (p *RoleProvider) GetMembership(ctx context.Context, userID string) (*platform.Membership, error) {
+	users := p.users.WithContext(ctx)
+	user, err := users.GetUser(userID)
+	if IsUserNotFound(err) {
+		return &platform.Membership{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	admins, err := users.GetAppAdmins()
+	if err != nil {
+		return nil, err
+	}
+
+	membership := &platform.Membership{Admin: slices.Contains(admins, userID)}
+	if len(user.DirectLeader) > 0 {
+		membership.ManagerID = user.DirectLeader[0]
+	}
+	for i, deptID := range user.Department {
+		dept := strconv.FormatInt(deptID, 10)
+		membership.Departments = append(membership.Departments, dept)
+		if i < len(user.IsLeaderInDept) && user.IsLeaderInDept[i] == 1 {
+			membership.LedDepartments = append(membership.LedDepartments, dept)
+		}
+	}
+	return membership, nil
+}
+
+// GetAppAdmins 返回本应用管理员的 userid
+func (s *AuthService) GetAppAdmins() ([]string, error) {
+	agentID, err := strconv.Atoi(s.config.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wecom agent id %q: %v", s.config.AgentID, err)
+	}
+
+	var response struct {
+		Admin []struct {
+			UserID string `json:"userid"`
+		} `json:"admin"`
+	}
+	if err := s.client.postWithToken("/cgi-bin/agent/get_admin_list", map[string]int{"agentid": agentID}, &response); err != nil {
+		return nil, err
+	}
+
+	admins := make([]string, 0, len(response.Admin))
+	for _, admin := range response.Admin {
+		admins = append(admins, admin.UserID)
+	}
+	return admins, nil
+}
@@ -0,0 +1,405 @@
+// Package wecomtest 提供基于 httptest 的企业微信接口模拟服务，
+// 用于在无网络环境下测试依赖企业微信接口的代码。
+package wecomtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hellodeveye/report/internal/models"
+	"github.com/hellodeveye/report/pkg/wecom"
+)
+
+// 模拟服务使用的应用凭证
+const (
+	CorpID     = "test-corp-id"
+	CorpSecret = "test-corp-secret"
+	AgentID    = "1000002"
+)
+
+// User 模拟的企业微信成员
+type User struct {
+	UserID     string
+	OpenUserID string
+	Name       string
+	Avatar     string
+	Email      string
+	Mobile     string
+	// Departments 所在部门，LeaderDepartments 为其中担任负责人的部门
+	Departments       []int64
+	LeaderDepartments []int64
+	DirectLeader      []string
+	// Admin 是否为应用管理员
+	Admin bool
+}
+
+// Server 模拟的企业微信服务端
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	tokenSeq      int
+	tokenRequests int
+	accessTokens  map[string]bool
+	authCodes     map[string]string // 授权码 -> userid
+	users         map[string]User
+	journals      []wecom.JournalDetail
+	injected      map[string]apiError
+	requests      map[string]int // 接口路径 -> 调用次数
+}
+
+type apiError struct {
+	code int
+	msg  string
+}
+
+// NewServer 启动模拟服务，测试结束后需调用 Close
+func NewServer() *Server {
+	s := &Server{
+		accessTokens: make(map[string]bool),
+		authCodes:    make(map[string]string),
+		users:        make(map[string]User),
+		injected:     make(map[string]apiError),
+		requests:     make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cgi-bin/gettoken", s.handleGetToken)
+	mux.HandleFunc("/cgi-bin/auth/getuserinfo", s.withAccessToken(s.handleGetUserInfo))
+	mux.HandleFunc("/cgi-bin/user/get", s.withAccessToken(s.handleUserGet))
+	mux.HandleFunc("/cgi-bin/agent/get_admin_list", s.withAccessToken(s.handleAdminList))
+	mux.HandleFunc("/cgi-bin/oa/journal/get_record_list", s.withAccessToken(s.handleRecordList))
+	mux.HandleFunc("/cgi-bin/oa/journal/get_record_detail", s.withAccessToken(s.handleRecordDetail))
+	mux.HandleFunc("/cgi-bin/oa/journal/get_stat_list", s.withAccessToken(s.handleStatList))
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config 返回指向模拟服务的企业微信配置
+func (s *Server) Config() *models.WeComConfig {
+	return &models.WeComConfig{
+		CorpID:      CorpID,
+		CorpSecret:  CorpSecret,
+		AgentID:     AgentID,
+		RedirectURI: s.URL + "/auth/callback",
+		BaseURL:     s.URL,
+	}
+}
+
+// AddUser 注册成员，并返回可用于网页登录的授权码
+func (s *Server) AddUser(user User) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user.UserID] = user
+	code := "code-" + user.UserID
+	s.authCodes[code] = user.UserID
+	return code
+}
+
+// AddJournal 添加一条已提交的汇报，ReportTime 为秒级时间戳
+func (s *Server) AddJournal(journal wecom.JournalDetail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.journals = append(s.journals, journal)
+}
+
+// TokenRequests 返回 gettoken 被调用的次数
+func (s *Server) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokenRequests
+}
+
+// Requests 返回携带 access_token 调用 path 的次数
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+// ExpireTokens 使已签发的 access_token 全部失效
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accessTokens = make(map[string]bool)
+}
+
+// InjectError 让下一次对 path 的调用返回指定的 errcode
+func (s *Server) InjectError(path string, errCode int, errMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.injected[path] = apiError{code: errCode, msg: errMsg}
+}
+
+func (s *Server) handleGetToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenRequests++
+
+	query := r.URL.Query()
+	if query.Get("corpid") != CorpID || query.Get("corpsecret") != CorpSecret {
+		writeError(w, 40001, "invalid credential")
+		return
+	}
+
+	s.tokenSeq++
+	token := fmt.Sprintf("access-token-%d", s.tokenSeq)
+	s.accessTokens[token] = true
+	writeJSON(w, map[string]interface{}{
+		"errcode":      0,
+		"errmsg":       "ok",
+		"access_token": token,
+		"expires_in":   7200,
+	})
+}
+
+// withAccessToken 校验请求携带的 access_token，并处理注入的错误
+func (s *Server) withAccessToken(next func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		valid := s.accessTokens[r.URL.Query().Get("access_token")]
+		injected, hasInjected := s.injected[r.URL.Path]
+		delete(s.injected, r.URL.Path)
+		s.mu.Unlock()
+
+		if !valid {
+			writeError(w, 42001, "access_token expired")
+			return
+		}
+		if hasInjected {
+			writeError(w, injected.code, injected.msg)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handleGetUserInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID, ok := s.authCodes[r.URL.Query().Get("code")]
+	if !ok {
+		writeError(w, 40029, "invalid code")
+		return
+	}
+	// 授权码只能使用一次
+	delete(s.authCodes, r.URL.Query().Get("code"))
+	writeResult(w, map[string]interface{}{"userid": userID, "user_ticket": "ticket-" + userID})
+}
+
+func (s *Server) handleUserGet(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[r.URL.Query().Get("userid")]
+	if !ok {
+		writeError(w, 60111, "userid not found")
+		return
+	}
+	isLeader := make([]int, len(user.Departments))
+	for i, deptID := range user.Departments {
+		if slices.Contains(user.LeaderDepartments, deptID) {
+			isLeader[i] = 1
+		}
+	}
+	writeResult(w, map[string]interface{}{
+		"userid":            user.UserID,
+		"open_userid":       user.OpenUserID,
+		"name":              user.Name,
+		"avatar":            user.Avatar,
+		"email":             user.Email,
+		"mobile":            user.Mobile,
+		"department":        user.Departments,
+		"is_leader_in_dept": isLeader,
+		"direct_leader":     user.DirectLeader,
+	})
+}
+
+func (s *Server) handleAdminList(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AgentID int `json:"agentid"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if fmt.Sprint(req.AgentID) != AgentID {
+		writeError(w, 301002, "invalid agentid")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	admins := []map[string]interface{}{}
+	for _, user := range s.users {
+		if user.Admin {
+			admins = append(admins, map[string]interface{}{"userid": user.UserID, "auth_type": 1})
+		}
+	}
+	writeResult(w, map[string]interface{}{"admin": admins})
+}
+
+func (s *Server) handleRecordList(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		StartTime int64                `json:"starttime"`
+		EndTime   int64                `json:"endtime"`
+		Cursor    int                  `json:"cursor"`
+		Limit     int                  `json:"limit"`
+		Filters   []wecom.RecordFilter `json:"filters"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Limit <= 0 || req.Limit > 100 {
+		writeError(w, 301025, "invalid limit")
+		return
+	}
+	if req.EndTime-req.StartTime > int64(30*24*time.Hour/time.Second) {
+		writeError(w, 301025, "time range exceeds one month")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []string
+	for _, journal := range s.journals {
+		if journal.ReportTime < req.StartTime || journal.ReportTime > req.EndTime || !matchFilters(journal, req.Filters) {
+			continue
+		}
+		matched = append(matched, journal.JournalUUID)
+	}
+
+	ids := []string{}
+	endFlag := 1
+	nextCursor := req.Cursor
+	if req.Cursor < len(matched) {
+		end := req.Cursor + req.Limit
+		if end > len(matched) {
+			end = len(matched)
+		}
+		ids = matched[req.Cursor:end]
+		nextCursor = end
+		if end < len(matched) {
+			endFlag = 0
+		}
+	}
+	writeResult(w, map[string]interface{}{
+		"journaluuid_list": ids,
+		"next_cursor":      nextCursor,
+		"endflag":          endFlag,
+	})
+}
+
+func matchFilters(journal wecom.JournalDetail, filters []wecom.RecordFilter) bool {
+	for _, filter := range filters {
+		switch filter.Key {
+		case "creator":
+			if journal.Submitter.UserID != filter.Value {
+				return false
+			}
+		case "template_id":
+			if journal.TemplateID != filter.Value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *Server) handleRecordDetail(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		JournalUUID string `json:"journaluuid"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, journal := range s.journals {
+		if journal.JournalUUID == req.JournalUUID {
+			writeResult(w, map[string]interface{}{"info": journal})
+			return
+		}
+	}
+	writeError(w, 301025, "journal not found")
+}
+
+// handleStatList 以整个查询区间作为一个统计周期，所有已注册成员均在汇报范围内
+func (s *Server) handleStatList(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TemplateID string `json:"template_id"`
+		StartTime  int64  `json:"starttime"`
+		EndTime    int64  `json:"endtime"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stat := wecom.TemplateStat{
+		TemplateID:     req.TemplateID,
+		ReportType:     1,
+		CycleBeginTime: req.StartTime,
+		CycleEndTime:   req.EndTime,
+		StatBeginTime:  req.StartTime,
+		StatEndTime:    req.EndTime,
+		ReportList:     []wecom.StatUser{},
+		UnreportList:   []wecom.StatUser{},
+	}
+	items := make(map[string][]wecom.StatItem)
+	for _, journal := range s.journals {
+		if journal.TemplateID != req.TemplateID || journal.ReportTime < req.StartTime || journal.ReportTime > req.EndTime {
+			continue
+		}
+		stat.TemplateName = journal.TemplateName
+		userID := journal.Submitter.UserID
+		items[userID] = append(items[userID], wecom.StatItem{JournalUUID: journal.JournalUUID, ReportTime: journal.ReportTime})
+	}
+
+	userIDs := make([]string, 0, len(s.users))
+	for userID := range s.users {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	for _, userID := range userIDs {
+		statUser := wecom.StatUser{User: wecom.JournalUser{UserID: userID}, ItemList: items[userID]}
+		if len(statUser.ItemList) > 0 {
+			stat.ReportList = append(stat.ReportList, statUser)
+		} else {
+			stat.UnreportList = append(stat.UnreportList, statUser)
+		}
+	}
+	writeResult(w, map[string]interface{}{"stat_list": []wecom.TemplateStat{stat}})
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, 47001, "data format error: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeResult 在 result 的字段之外补充 errcode、errmsg
+func writeResult(w http.ResponseWriter, result map[string]interface{}) {
+	result["errcode"] = 0
+	result["errmsg"] = "ok"
+	writeJSON(w, result)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, map[string]interface{}{"errcode": code, "errmsg": msg})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -3,6 +3,9 @@ class AuthService {
   constructor() {
     this.tokenKey = 'report_app_auth_token';
     this.userKey = 'report_app_user_info';
+    this.refreshTokenKey = 'report_app_refresh_token';
+    // 正在进行的刷新请求，避免并发刷新导致刷新令牌被判定为重放
+    this.refreshPromise = null;
     // 使用相对路径，让Vite代理处理
     this.baseURL = '/api';
   }
@@ -17,12 +20,37 @@ class AuthService {
     localStorage.setItem(this.tokenKey, token);
   }
 
+  // 获取刷新令牌
+  getRefreshToken() {
+    return localStorage.getItem(this.refreshTokenKey);
+  }
+
+  // 保存登录或刷新返回的令牌
+  setTokens(authData) {
+    this.setToken(authData.token);
+    if (authData.refresh_token) {
+      localStorage.setItem(this.refreshTokenKey, authData.refresh_token);
+    }
+  }
+
   // 清除token
   clearToken() {
     localStorage.removeItem(this.tokenKey);
+    localStorage.removeItem(this.refreshTokenKey);
     localStorage.removeItem(this.userKey);
   }
 
+  // 访问令牌是否将在 leewaySeconds 秒内过期
+  isTokenExpiring(token, leewaySeconds = 0) {
+    try {
+      const payload = JSON.parse(atob(token.split('.')[1]));
+      return payload.exp <= Date.now() / 1000 + leewaySeconds;
+    } catch (error) {
+      console.error('Token validation error:', error);
+      return true;
+    }
+  }
+
   // 获取用户信息
   getUser() {
     const userStr = localStorage.getItem(this.userKey);
@@ -34,20 +62,57 @@ class AuthService {
     localStorage.setItem(this.userKey, JSON.stringify(user));
   }
 
-  // 检查是否已登录
+  // 检查是否已登录：访问令牌未过期，或持有可用于续期的刷新令牌
   isAuthenticated() {
     const token = this.getToken();
     if (!token) return false;
-    
-    // 检查token是否过期（简单检查）
-    try {
-      const payload = JSON.parse(atob(token.split('.')[1]));
-      const currentTime = Date.now() / 1000;
-      return payload.exp > currentTime;
-    } catch (error) {
-      console.error('Token validation error:', error);
-      return false;
+    return !this.isTokenExpiring(token) || !!this.getRefreshToken();
+  }
+
+  // 用刷新令牌换取新的访问令牌，失败时清除登录状态并返回 null
+  async refreshToken() {
+    if (this.refreshPromise) {
+      return this.refreshPromise;
+    }
+
+    const refreshToken = this.getRefreshToken();
+    if (!refreshToken) {
+      return null;
     }
+
+    this.refreshPromise = (async () => {
+      try {
+        const response = await fetch(`${this.baseURL}/auth/refresh`, {
+          method: 'POST',
+          headers: {
+            'Content-Type': 'application/json'
+          },
+          body: JSON.stringify({ refresh_token: refreshToken })
+        });
+        if (!response.ok) {
+          this.clearToken();
+          return null;
+        }
+        const tokenData = await response.json();
+        this.setTokens(tokenData);
+        return tokenData.token;
+      } catch (error) {
+        console.error('Refresh token error:', error);
+        return null;
+      } finally {
+        this.refreshPromise = null;
+      }
+    })();
+    return this.refreshPromise;
+  }
+
+  // 获取可用的访问令牌，即将过期时先刷新
+  async getValidToken() {
+    const token = this.getToken();
+    if (token && !this.isTokenExpiring(token, 30)) {
+      return token;
+    }
+    return (await this.refreshToken()) || token;
   }
 
   // 发起登录 - 支持多个提供商
@@ -72,12 +137,12 @@ class AuthService {
     }
   }
 
-  // 退出登录
-  async logout() {
+  // 退出登录，allDevices 为 true 时同时退出该账号在其他设备上的登录
+  async logout(allDevices = false) {
     try {
-      const token = this.getToken();
+      const token = await this.getValidToken();
       if (token) {
-        await fetch(`${this.baseURL}/auth/logout`, {
+        await fetch(`${this.baseURL}/auth/${allDevices ? 'logout-all' : 'logout'}`, {
           method: 'POST',
           headers: {
             'Authorization': `Bearer ${token}`,
@@ -94,6 +159,11 @@ class AuthService {
     }
   }
 
+  // 退出所有设备上的登录
+  async logoutAll() {
+    return this.logout(true);
+  }
+
   // 处理OAuth回调（授权码）- 支持多个提供商
   async handleAuthCallback() {
     const urlParams = new URLSearchParams(window.location.search);
@@ -131,7 +201,7 @@ class AuthService {
       const authData = await response.json();
       
       // 保存token和用户信息
-      this.setToken(authData.token);
+      this.setTokens(authData);
       this.setUser(authData.user);
       
       // 清除state和provider
@@ -148,26 +218,11 @@ class AuthService {
     }
   }
 
-  // 获取当前用户信息
+  // 从服务端获取当前用户信息（含部门与直属主管），本地只保留一份缓存
   async getCurrentUser() {
-    const token = this.getToken();
-    if (!token) {
-      throw new Error('No token found');
-    }
-
     try {
-      const response = await fetch(`${this.baseURL}/auth/user`, {
-        headers: {
-          'Authorization': `Bearer ${token}`,
-          'Content-Type': 'application/json'
-        }
-      });
-
+      const response = await this.authenticatedFetch(`${this.baseURL}/auth/user`);
       if (!response.ok) {
-        if (response.status === 401) {
-          this.clearToken();
-          throw new Error('Token expired');
-        }
         throw new Error('Failed to get user info');
       }
 
@@ -182,21 +237,27 @@ class AuthService {
 
   // 创建带认证的fetch请求
   async authenticatedFetch(url, options = {}) {
-    const token = this.getToken();
-    
-    const authOptions = {
+    const withToken = (token) => ({
       ...options,
       headers: {
         'Content-Type': 'application/json',
         ...options.headers,
         ...(token ? { 'Authorization': `Bearer ${token}` } : {})
       }
-    };
+    });
 
     try {
-      const response = await fetch(url, authOptions);
-      
-      // 如果token过期，自动跳转到登录页面
+      let response = await fetch(url, withToken(await this.getValidToken()));
+
+      // 访问令牌失效时尝试刷新一次
+      if (response.status === 401) {
+        const refreshed = await this.refreshToken();
+        if (refreshed) {
+          response = await fetch(url, withToken(refreshed));
+        }
+      }
+
+      // 刷新失败，自动跳转到登录页面
       if (response.status === 401) {
         this.clearToken();
         window.location.href = '/login';
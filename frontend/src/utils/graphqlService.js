@@ -5,7 +5,7 @@ const client = new GraphQLClient(endpoint);
 
 const graphqlService = {
   async request(query, variables = {}) {
-    const token = authService.getToken();
+    const token = await authService.getValidToken();
     const headers = {};
     if (token) {
       headers.Authorization = `Bearer ${token}`;